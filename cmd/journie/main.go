@@ -2,13 +2,19 @@ package main
 
 import (
 	"context"
+	"journie/pkg/admin"
 	chatsession "journie/pkg/chat-session"
-	firebaseClient "journie/pkg/firebase"
 	"journie/pkg/generative"
 	"journie/pkg/messaging"
+	"journie/pkg/personas"
 	"journie/pkg/pubsub"
+	"journie/pkg/ratelimit"
+	"journie/pkg/scheduler"
+	"journie/pkg/storage"
+	"journie/pkg/transcribe"
 	"log"
 	"net/http"
+	"time"
 
 	"os"
 
@@ -34,35 +40,77 @@ func main() {
 		})
 	})
 
-	go func() {
-		if err := r.Run(":" + os.Getenv("PORT")); err != nil {
-			log.Fatal(err)
-		}
-	}()
+	// operator endpoints e.g. master key rotation
+	admin.Register(r)
 
 	ctx := context.Background()
 
-	// init google pubsub
-	pubsuberr := pubsub.SubscribeToTopic(context.Background(), os.Getenv("FIREBASE_PROJECT_ID"), "remind-topic", "remind-sub")
-	if pubsuberr != nil {
-		log.Fatal(pubsuberr)
+	// init llm provider, gemini by default
+	if err := generative.Init(); err != nil {
+		log.Fatal(err)
+	}
+
+	// init personas, the built-in ones and those of PERSONAS_FILE
+	if err := personas.Init(); err != nil {
+		log.Fatal(err)
+	}
+
+	// init speech to text for voice notes
+	if err := transcribe.Init(ctx); err != nil {
+		log.Fatal(err)
 	}
 
-	// init gemini
-	generative.Init()
+	// init per-user rate limits and daily message caps
+	if err := ratelimit.Init(); err != nil {
+		log.Fatal(err)
+	}
 
 	// init chat sessions
 	chatsession.Init()
 
-	// init firebase and firestore
-	firebaseErr := firebaseClient.Init(ctx)
-	if firebaseErr != nil {
-		log.Fatal(firebaseErr)
+	// init storage, firestore by default
+	storageErr := storage.Init(ctx)
+	if storageErr != nil {
+		log.Fatal(storageErr)
+	}
+
+	// init messaging platforms, telegram and webhook, mounting their routes before the server starts
+	messagingErr := messaging.Init(r)
+	if messagingErr != nil {
+		log.Fatal(messagingErr)
+	}
+
+	// init scheduler, daily jobs run hourly and each picks users by their local hour
+	if err := scheduler.Init(); err != nil {
+		log.Fatal(err)
+	}
+	jobs := []scheduler.Job{
+		{Name: "remind", Spec: "0 * * * *", CatchUp: time.Hour, Run: messaging.RemindDaily},
+		{Name: "summarize", Spec: "0 * * * *", CatchUp: 24 * time.Hour, Run: messaging.SummarizeDaily},
+		{Name: "summary-retries", Spec: "*/5 * * * *", Run: messaging.RetrySummaries},
+		{Name: "digests", Spec: "30 5 * * *", CatchUp: 24 * time.Hour, Run: messaging.RollupDigests},
+	}
+	for _, job := range jobs {
+		if err := scheduler.SchedulerClient.Add(job); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	triggers, err := scheduler.Triggers()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if triggers[scheduler.Cron] {
+		scheduler.SchedulerClient.Start(ctx)
+	}
+	if triggers[scheduler.PubSub] {
+		pubsuberr := pubsub.SubscribeToTopic(ctx, os.Getenv("FIREBASE_PROJECT_ID"), "remind-topic", "remind-sub")
+		if pubsuberr != nil {
+			log.Fatal(pubsuberr)
+		}
 	}
 
-	// init telebot
-	teleErr := messaging.Init()
-	if teleErr != nil {
-		log.Fatal(teleErr)
+	if err := r.Run(":" + os.Getenv("PORT")); err != nil {
+		log.Fatal(err)
 	}
 }
@@ -0,0 +1,112 @@
+package admin
+
+import (
+	"crypto/subtle"
+	chatsession "journie/pkg/chat-session"
+	"journie/pkg/secrets"
+	"journie/pkg/storage"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Register mounts operator endpoints under /admin, authenticated with a bearer token from ADMIN_TOKEN env.
+// Nothing is mounted when ADMIN_TOKEN is not set
+func Register(r *gin.Engine) {
+	token := os.Getenv("ADMIN_TOKEN")
+	if token == "" {
+		log.Println("ADMIN_TOKEN not set, admin endpoints disabled")
+		return
+	}
+
+	group := r.Group("/admin", requireToken(token))
+
+	// re-wrap keys sealed with JOURNIE_MASTER_KEY_PREVIOUS using JOURNIE_MASTER_KEY
+	group.POST("/rotate-master-key", func(c *gin.Context) {
+		keyring, err := secrets.MasterKeyring()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		rotated, err := storage.RotateMasterKey(c.Request.Context(), storage.StoreClient, keyring)
+		if err != nil {
+			log.Printf("Error rotating master key: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "rotated": rotated})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"rotated": rotated, "keyId": keyring.Current.Id})
+	})
+
+	// summaries that failed every retry, listed without their history
+	group.GET("/dead-letters", func(c *gin.Context) {
+		deadLetters, err := storage.StoreClient.ListFailedSummaries(c.Request.Context(), true)
+		if err != nil {
+			log.Printf("Error listing dead letters: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		items := make([]DeadLetter, 0, len(deadLetters))
+		for _, deadLetter := range deadLetters {
+			items = append(items, DeadLetter{
+				Id:        deadLetter.Id,
+				UserId:    deadLetter.UserId,
+				Day:       deadLetter.Day,
+				Messages:  len(deadLetter.History),
+				Attempts:  deadLetter.Attempts,
+				Error:     deadLetter.Error,
+				UpdatedAt: deadLetter.UpdatedAt,
+			})
+		}
+
+		c.JSON(http.StatusOK, gin.H{"deadLetters": items})
+	})
+
+	// summarize a dead letter again, e.g. after fixing the cause, it is removed on success
+	group.POST("/dead-letters/:id/replay", func(c *gin.Context) {
+		result, err := chatsession.ReplayDeadLetter(c.Param("id"))
+		if err == storage.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "dead letter not found"})
+			return
+		}
+		if err == chatsession.ErrNotDeadLetter {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			log.Printf("Error replaying dead letter %s: %v", c.Param("id"), err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"entry": result})
+	})
+}
+
+// DeadLetter is a summary parked after failing every retry, as listed by GET /admin/dead-letters
+type DeadLetter struct {
+	Id        string    `json:"id"`
+	UserId    string    `json:"userId"`
+	Day       string    `json:"day"`
+	Messages  int       `json:"messages"` // in the raw history kept for replay
+	Attempts  int       `json:"attempts"`
+	Error     string    `json:"error"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func requireToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provided := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,258 @@
+package charts
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"journie/pkg/moods"
+	"journie/pkg/storage"
+	"strings"
+	"time"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	Week  string = "week"
+	Month string = "month"
+	Year  string = "year"
+)
+
+// Periods lists supported chart periods, first one is the default
+var Periods = []string{Week, Month, Year}
+
+// ParsePeriod normalizes a user supplied period, defaulting to week
+func ParsePeriod(period string) (string, error) {
+	switch p := strings.ToLower(strings.TrimSpace(period)); p {
+	case "":
+		return Week, nil
+	case Week, Month, Year:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unsupported chart period %q", period)
+	}
+}
+
+const (
+	width   = 800
+	height  = 520
+	margin  = 40
+	barTop  = 60
+	barBot  = 300 // bottom of stacked bar area
+	lineTop = 360 // top of timeline
+	lineBot = 420
+)
+
+var (
+	background = color.RGBA{0xff, 0xfd, 0xf8, 0xff}
+	foreground = color.RGBA{0x33, 0x33, 0x33, 0xff}
+	empty      = color.RGBA{0xe6, 0xe6, 0xe6, 0xff}
+)
+
+// Colors of each mood in charts
+var Colors = map[string]color.RGBA{
+	moods.Happy:    {0xf6, 0xc1, 0x43, 0xff},
+	moods.Surprise: {0xf2, 0x8c, 0x38, 0xff},
+	moods.Neutral:  {0xa0, 0xa8, 0xb0, 0xff},
+	moods.Sad:      {0x4a, 0x7b, 0xd0, 0xff},
+	moods.Fear:     {0x8a, 0x5c, 0xc4, 0xff},
+	moods.Disgust:  {0x5a, 0x9e, 0x5a, 0xff},
+	moods.Anger:    {0xd6, 0x45, 0x45, 0xff},
+}
+
+// Bucket counts moods of entries within [Start, End)
+type Bucket struct {
+	Label  string
+	Start  time.Time
+	End    time.Time
+	Counts map[string]int
+}
+
+// Range returns the first day and the buckets of a chart period ending on day today.
+// Week is bucketed per day, month per week and year per month
+func Range(period string, today time.Time) (time.Time, []Bucket, error) {
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+
+	var buckets []Bucket
+	switch period {
+	case Week:
+		for i := 6; i >= 0; i-- {
+			day := today.AddDate(0, 0, -i)
+			buckets = append(buckets, Bucket{Label: day.Format("Mon"), Start: day, End: day.AddDate(0, 0, 1)})
+		}
+	case Month:
+		start := today.AddDate(0, 0, -27)
+		for i := 0; i < 4; i++ {
+			from := start.AddDate(0, 0, 7*i)
+			buckets = append(buckets, Bucket{Label: from.Format("Jan 2"), Start: from, End: from.AddDate(0, 0, 7)})
+		}
+	case Year:
+		first := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+		for i := 11; i >= 0; i-- {
+			month := first.AddDate(0, -i, 0)
+			buckets = append(buckets, Bucket{Label: month.Format("Jan"), Start: month, End: month.AddDate(0, 1, 0)})
+		}
+	default:
+		return time.Time{}, nil, fmt.Errorf("unsupported chart period %q", period)
+	}
+
+	for i := range buckets {
+		buckets[i].Counts = make(map[string]int)
+	}
+
+	return buckets[0].Start, buckets, nil
+}
+
+// Count adds moods of entries into the bucket their date falls in
+func Count(buckets []Bucket, entries []storage.Entry) {
+	for _, entry := range entries {
+		day, err := time.Parse("2006-01-02", entry.Id)
+		if err != nil {
+			continue
+		}
+		for i := range buckets {
+			if day.Before(buckets[i].Start) || !day.Before(buckets[i].End) {
+				continue
+			}
+			for _, mood := range entry.Mood {
+				if m, ok := moods.Normalize(mood); ok {
+					buckets[i].Counts[m]++
+				}
+			}
+		}
+	}
+}
+
+// MoodChart renders a PNG with stacked mood frequency per bucket and a daily mood timeline
+func MoodChart(title string, period string, today time.Time, entries []storage.Entry) ([]byte, error) {
+	from, buckets, err := Range(period, today)
+	if err != nil {
+		return nil, err
+	}
+	Count(buckets, entries)
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{background}, image.Point{}, draw.Src)
+
+	drawText(img, margin, 30, title, foreground)
+	drawStackedBars(img, buckets)
+	drawTimeline(img, from, today, entries)
+	drawLegend(img)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func drawStackedBars(img *image.RGBA, buckets []Bucket) {
+	maxTotal := 1
+	for _, bucket := range buckets {
+		total := 0
+		for _, count := range bucket.Counts {
+			total += count
+		}
+		maxTotal = max(maxTotal, total)
+	}
+
+	slot := (width - 2*margin) / len(buckets)
+	barWidth := slot * 3 / 5
+	unit := float64(barBot-barTop) / float64(maxTotal)
+
+	// axis
+	fillRect(img, margin, barBot, width-margin, barBot+1, foreground)
+	drawText(img, 8, barTop+4, fmt.Sprint(maxTotal), foreground)
+	drawText(img, 8, barBot, "0", foreground)
+
+	for i, bucket := range buckets {
+		x := margin + i*slot + (slot-barWidth)/2
+		y := float64(barBot)
+		for _, mood := range moods.All {
+			count := bucket.Counts[mood]
+			if count == 0 {
+				continue
+			}
+			top := y - float64(count)*unit
+			fillRect(img, x, int(top), x+barWidth, int(y), Colors[mood])
+			y = top
+		}
+		drawText(img, x, barBot+18, bucket.Label, foreground)
+	}
+}
+
+// drawTimeline draws one cell per day, split in halves when a day has two moods
+func drawTimeline(img *image.RGBA, from time.Time, today time.Time, entries []storage.Entry) {
+	byDay := make(map[string][]string)
+	for _, entry := range entries {
+		byDay[entry.Id] = entry.Mood
+	}
+
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	days := int(today.Sub(from).Hours()/24) + 1
+	cell := float64(width-2*margin) / float64(days)
+
+	drawText(img, margin, lineTop-10, "Timeline", foreground)
+	for i := 0; i < days; i++ {
+		day := from.AddDate(0, 0, i).Format("2006-01-02")
+		x0 := margin + int(float64(i)*cell)
+		x1 := margin + int(float64(i+1)*cell)
+		if x1-x0 > 2 {
+			x1-- // gap between cells when there is room
+		}
+
+		var valid []string
+		for _, mood := range byDay[day] {
+			if m, ok := moods.Normalize(mood); ok {
+				valid = append(valid, m)
+			}
+		}
+
+		if len(valid) == 0 {
+			fillRect(img, x0, lineTop, x1, lineBot, empty)
+			continue
+		}
+
+		if len(valid) == 1 {
+			fillRect(img, x0, lineTop, x1, lineBot, Colors[valid[0]])
+			continue
+		}
+
+		half := lineTop + (lineBot-lineTop)/2
+		fillRect(img, x0, lineTop, x1, half, Colors[valid[0]])
+		fillRect(img, x0, half, x1, lineBot, Colors[valid[1]])
+	}
+
+	drawText(img, margin, lineBot+16, from.Format("2006-01-02"), foreground)
+	drawText(img, width-margin-70, lineBot+16, today.Format("2006-01-02"), foreground)
+}
+
+func drawLegend(img *image.RGBA) {
+	x := margin
+	y := height - 30
+	for _, mood := range moods.All {
+		fillRect(img, x, y-10, x+12, y+2, Colors[mood])
+		drawText(img, x+16, y, mood, foreground)
+		x += 16 + len(mood)*7 + 24
+	}
+}
+
+func fillRect(img *image.RGBA, x0, y0, x1, y1 int, c color.Color) {
+	draw.Draw(img, image.Rect(x0, y0, x1, y1), &image.Uniform{c}, image.Point{}, draw.Src)
+}
+
+func drawText(img *image.RGBA, x, y int, text string, c color.Color) {
+	d := &font.Drawer{
+		Dst:  img,
+		Src:  &image.Uniform{c},
+		Face: basicfont.Face7x13,
+		Dot:  fixed.P(x, y),
+	}
+	d.DrawString(text)
+}
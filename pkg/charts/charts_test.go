@@ -0,0 +1,49 @@
+package charts_test
+
+import (
+	"bytes"
+	"image/png"
+	"journie/pkg/charts"
+	"journie/pkg/storage"
+	"testing"
+	"time"
+)
+
+// TestCountWeek buckets a week of entries, checking moods land on their day and
+// unknown moods or entries outside the range are ignored.
+func TestCountWeek(t *testing.T) {
+	today := time.Date(2024, 5, 20, 22, 0, 0, 0, time.UTC)
+	from, buckets, err := charts.Range(charts.Week, today)
+	if err != nil || len(buckets) != 7 || from.Format("2006-01-02") != "2024-05-14" {
+		t.Fatalf(`Range(week) = %v, %d buckets, %v, want 2024-05-14, 7 buckets, nil`, from, len(buckets), err)
+	}
+
+	charts.Count(buckets, []storage.Entry{
+		{Id: "2024-05-13", Mood: []string{"sad"}},
+		{Id: "2024-05-14", Mood: []string{"Happy", "sad"}},
+		{Id: "2024-05-20", Mood: []string{"anger", "bored"}},
+	})
+
+	if c := buckets[0].Counts; c["happy"] != 1 || c["sad"] != 1 || len(c) != 2 {
+		t.Fatalf(`buckets[0].Counts = %v, want happy 1, sad 1`, c)
+	}
+	if c := buckets[6].Counts; c["anger"] != 1 || len(c) != 1 {
+		t.Fatalf(`buckets[6].Counts = %v, want anger 1`, c)
+	}
+}
+
+// TestMoodChart renders a year chart, checking the output is a decodable PNG.
+func TestMoodChart(t *testing.T) {
+	today := time.Date(2024, 5, 20, 22, 0, 0, 0, time.UTC)
+	out, err := charts.MoodChart("Your moods", charts.Year, today, []storage.Entry{
+		{Id: "2024-01-02", Mood: []string{"happy"}},
+		{Id: "2024-05-19", Mood: []string{"fear", "surprise"}},
+	})
+	if err != nil {
+		t.Fatalf(`MoodChart() = %v, want nil`, err)
+	}
+
+	if _, err := png.Decode(bytes.NewReader(out)); err != nil {
+		t.Fatalf(`png.Decode(MoodChart()) = %v, want nil`, err)
+	}
+}
@@ -2,71 +2,254 @@ package chatsession
 
 import (
 	"context"
-	"encoding/json"
-	"errors"
 	"fmt"
-	firebaseClient "journie/pkg/firebase"
 	"journie/pkg/generative"
+	"journie/pkg/storage"
+	"journie/pkg/users"
 	"log"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
-	"cloud.google.com/go/firestore"
-	"github.com/google/generative-ai-go/genai"
-	"github.com/mitchellh/mapstructure"
 	"github.com/samber/lo"
-	"google.golang.org/api/iterator"
 )
 
 var ChatSessionClient *ChatSession
 
+// DayBoundaryHour is the hour a journaling day ends, conversations before it belong to the previous day
+const DayBoundaryHour = 4
+
 type ChatSession struct {
-	Sessions map[string]*genai.ChatSession // Map of user IDs to Gemini clients
-	mu       sync.Mutex                    // Mutex to synchronize access to the map
+	Sessions map[string]*UserSession // Map of user IDs to chat sessions
+	mu       sync.Mutex              // Mutex to synchronize access to the map
+}
+
+// UserSession is a chat session belonging to a single journaling day
+type UserSession struct {
+	*generative.ChatSession
+	Day       string // journaling day in format 2006-01-02
+	OwnKey    bool   // whether Provider uses the user's own Gemini key rather than the shared provider
+	CreatedAt time.Time
 }
 
 type AnalysisResult struct {
-	Summary   string    `json:"summary"`
-	Mood      []string  `json:"mood"`
-	CreatedAt time.Time `json:"createdAt"`
+	Summary   string          `json:"summary"`
+	Mood      []string        `json:"mood"`
+	Photos    []storage.Photo `json:"photos,omitempty"`
+	Date      string          `json:"date"`
+	CreatedAt time.Time       `json:"createdAt"`
 }
 
 func Init() {
 	ChatSessionClient = &ChatSession{
-		Sessions: make(map[string]*genai.ChatSession),
+		Sessions: make(map[string]*UserSession),
 	}
 }
 
-func MapToAnalysisResult(data map[string]interface{}) (*AnalysisResult, error) {
-	var result AnalysisResult
-	err := mapstructure.Decode(data, &result)
-	if err != nil {
-		return nil, err
+// JournalDay returns the journaling day t belongs to in t's location, in format 2006-01-02
+func JournalDay(t time.Time) string {
+	return t.Add(-DayBoundaryHour * time.Hour).Format("2006-01-02")
+}
+
+func EntryToAnalysisResult(entry *storage.Entry) *AnalysisResult {
+	return &AnalysisResult{
+		Summary:   entry.Summary,
+		Mood:      entry.Mood,
+		Photos:    entry.Photos,
+		Date:      entry.Id,
+		CreatedAt: entry.CreatedAt,
 	}
-	return &result, nil
 }
 
 func AnalysisResultToHistory(data *AnalysisResult) string {
+	date := data.Date
+	if date == "" {
+		date = data.CreatedAt.Format("2006-01-02")
+	}
+
 	return fmt.Sprintf("On the date %s, the following conversation happened with you and the user, where the user is in second-person: '%s'. User's mood was: %s",
-		data.CreatedAt.Format("2006-01-02"), data.Summary, strings.Join(data.Mood, ", "))
+		date, data.Summary, strings.Join(data.Mood, ", "))
+}
+
+// DigestToHistory describes a digest for the model, as long-term context of a chat session
+func DigestToHistory(digest *storage.Digest) string {
+	moods := make([]string, 0, len(digest.Moods))
+	for mood, days := range digest.Moods {
+		moods = append(moods, fmt.Sprintf("%s on %d days", mood, days))
+	}
+	sort.Strings(moods)
+
+	return fmt.Sprintf("From %s to %s, the user journaled on %d days. Summary of that %s, where the user is in second-person: '%s'. Highlights: %s. User's moods were: %s",
+		digest.Start, digest.End, digest.Days, digest.Period, digest.Summary, strings.Join(digest.Highlights, "; "), strings.Join(moods, ", "))
+}
+
+func getUser(userId string) *storage.User {
+	user, err := storage.StoreClient.GetUser(context.Background(), userId)
+	if err != nil && err != storage.ErrNotFound {
+		log.Printf("Error retrieving user %s: %v", userId, err)
+	}
+	return user
+}
+
+// providerForUser returns a Gemini provider using user's own key,
+// falling back to the shared provider if user has none or it cannot be used
+func providerForUser(user *storage.User) (generative.Provider, bool) {
+	apiKey, err := users.GeminiKey(user)
+	if err != nil {
+		log.Printf("Error opening gemini key of user %s, using shared key: %v", user.Id, err)
+		return generative.GenAiClient.Provider, false
+	}
+
+	if apiKey == "" {
+		return generative.GenAiClient.Provider, false
+	}
+
+	provider, err := generative.GenAiClient.UserProvider(user.Id, apiKey)
+	if err != nil {
+		log.Printf("Error creating gemini provider of user %s, using shared key: %v", user.Id, err)
+		return generative.GenAiClient.Provider, false
+	}
+
+	return provider, true
+}
+
+// preferencesOf returns the preferences of user for the model, from their settings and chat app language
+func preferencesOf(user *storage.User) generative.Preferences {
+	settings := users.SettingsOf(user)
+
+	prefs := generative.Preferences{Persona: settings.Persona, SummaryStyle: settings.SummaryStyle}
+	if language := users.Language(user); language != "" {
+		prefs.Language, _ = users.OptionLabel(users.Languages, language)
+	}
+
+	return prefs
+}
+
+// userModel returns the chat config of user, in their location and with their preferences
+func userModel(user *storage.User) *generative.Config {
+	return generative.GetUserModel(users.Location(user), preferencesOf(user))
+}
+
+// HistoryToMessages converts chat history into storage messages
+func HistoryToMessages(history []generative.Message) []storage.Message {
+	messages := make([]storage.Message, 0, len(history))
+	for _, content := range history {
+		message := storage.Message{Role: content.Role}
+		for _, part := range content.Parts {
+			stored := storage.Part{Text: part.Text, MIMEType: part.MIMEType, Data: part.Data, Ref: part.Ref}
+			if part.Ref != "" {
+				// data is kept by the platform, e.g. telegram photos
+				stored.Data = nil
+			}
+			message.Parts = append(message.Parts, stored)
+		}
+		messages = append(messages, message)
+	}
+
+	return messages
+}
+
+// MessagesToHistory converts storage messages back into chat history
+func MessagesToHistory(messages []storage.Message) []generative.Message {
+	history := make([]generative.Message, 0, len(messages))
+	for _, message := range messages {
+		content := generative.Message{Role: message.Role}
+		for _, part := range message.Parts {
+			content.Parts = append(content.Parts, generative.Part{Text: part.Text, MIMEType: part.MIMEType, Data: part.Data, Ref: part.Ref})
+		}
+		history = append(history, content)
+	}
+
+	return history
+}
+
+// SessionPhotos returns references to photos the user shared in history,
+// captioned with the text sent along with each photo
+func SessionPhotos(history []generative.Message) []storage.Photo {
+	var photos []storage.Photo
+	for _, message := range history {
+		if message.Role != "user" {
+			continue
+		}
+
+		var captions []string
+		for _, part := range message.Parts {
+			if part.MIMEType == "" && part.Text != "" {
+				captions = append(captions, part.Text)
+			}
+		}
+
+		for _, part := range message.Parts {
+			if part.IsPhoto() && part.Ref != "" {
+				photos = append(photos, storage.Photo{FileId: part.Ref, Caption: strings.Join(captions, "\n")})
+			}
+		}
+	}
+
+	return photos
 }
 
 // GetChatSession retrieves chat-session of user if it exists.
+// Sessions not in memory, e.g. after a restart, are rehydrated from storage
 // userId should be a string in format {platform}-{indentifier}
-func (cs *ChatSession) GetChatSession(userId string) *genai.ChatSession {
+func (cs *ChatSession) GetChatSession(userId string) *UserSession {
+	if session := cs.cached(userId); session != nil {
+		return session
+	}
+
+	stored, err := storage.StoreClient.GetSession(context.Background(), userId)
+	if err != nil {
+		if err != storage.ErrNotFound {
+			log.Printf("Error retrieving stored chat session for user %s: %v", userId, err)
+		}
+		return nil
+	}
+
+	user := getUser(userId)
+	provider, ownKey := providerForUser(user)
+	chatSession := generative.StartChat(provider, userModel(user))
+	chatSession.History = MessagesToHistory(stored.History)
+
+	session := &UserSession{
+		ChatSession: chatSession,
+		Day:         stored.Day,
+		OwnKey:      ownKey,
+		CreatedAt:   stored.CreatedAt,
+	}
+	if session, added := cs.add(userId, session); !added {
+		return session
+	}
+	log.Printf("Chat session for %s rehydrated for user %s", stored.Day, userId)
+
+	return session
+}
+
+// cached returns the in-memory chat session of user, nil if there is none
+func (cs *ChatSession) cached(userId string) *UserSession {
 	cs.mu.Lock()
 	defer cs.mu.Unlock()
 
-	if session, ok := cs.Sessions[userId]; ok {
-		return session
+	return cs.Sessions[userId]
+}
+
+// add keeps session as the chat session of user unless another was added while it was built without the lock,
+// returning the one kept and whether it is session
+func (cs *ChatSession) add(userId string, session *UserSession) (*UserSession, bool) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if existing, ok := cs.Sessions[userId]; ok {
+		return existing, false
 	}
+	cs.Sessions[userId] = session
 
-	return nil
+	return session, true
 }
 
-// GetOrCreateChatSession retrieves existing chat session or creates new one with historical context
-func (cs *ChatSession) GetOrCreateChatSession(userID string) (*genai.ChatSession, error) {
+// GetOrCreateChatSession retrieves existing chat session or creates new one with historical context,
+// the latest entries and those most relevant to query, the user's first message
+func (cs *ChatSession) GetOrCreateChatSession(userID string, query string) (*UserSession, error) {
 	ctx := context.Background()
 
 	existingSession := cs.GetChatSession(userID)
@@ -74,52 +257,52 @@ func (cs *ChatSession) GetOrCreateChatSession(userID string) (*genai.ChatSession
 		return existingSession, nil
 	}
 
-	model := generative.GetUserModel()
-	chatSession := model.StartChat()
+	user := getUser(userID)
+	loc := users.Location(user)
+	provider, ownKey := providerForUser(user)
+	chatSession := generative.StartChat(provider, generative.GetUserModel(loc, preferencesOf(user)))
 	fmt.Printf("New chat session created for user %s", userID)
 
-	err := firebaseClient.UpsertUserLastCreatedSession(ctx, userID)
+	now := time.Now().In(loc)
+	err := storage.StoreClient.UpsertUserLastCreatedSession(ctx, userID, now)
 	if err != nil {
 		log.Printf("Error updating last created session for user %s: %v", userID, err)
 		return nil, err
 	}
 
-	// get past x days of summaries for user from firestore, insert into history
-	userRef := firebaseClient.FirestoreClient.Collection("users").Doc(userID)
-	subcollectionRef := userRef.Collection("entries")
-	iter := subcollectionRef.OrderBy("createdAt", firestore.Asc).Limit(30).Documents(ctx)
-
-	var summaries []AnalysisResult
-	for {
-		doc, err := iter.Next()
-		if err == iterator.Done {
-			break
-		}
-
-		if doc == nil {
-			break
-		}
-
-		result, err := MapToAnalysisResult(doc.Data())
-		if err != nil {
-			log.Printf("Error mapping document to AnalysisResult for user %s: %v", userID, err)
-			return nil, err
-		}
+	// get digests of past weeks, months and years for user from storage as long-term context,
+	// then recent and relevant summaries with the rest of the budget, insert into history
+	budget := ContextTokenBudget()
+	digests, used, err := digestContext(ctx, userID, budget/2)
+	if err != nil {
+		log.Printf("Error retrieving digests for user %s: %v", userID, err)
+		return nil, err
+	}
 
-		summaries = append(summaries, *result)
+	entries, err := contextEntries(ctx, provider, userID, query, budget-used)
+	if err != nil {
+		log.Printf("Error retrieving entries for user %s: %v", userID, err)
+		return nil, err
 	}
 
-	if len(summaries) != 0 {
-		histories := lo.Map(summaries, func(i AnalysisResult, index int) string {
-			return AnalysisResultToHistory(&i)
+	summaries := lo.Map(entries, func(entry storage.Entry, index int) AnalysisResult {
+		return *EntryToAnalysisResult(&entry)
+	})
+
+	if len(digests) != 0 || len(summaries) != 0 {
+		histories := lo.Map(digests, func(digest storage.Digest, index int) string {
+			return DigestToHistory(&digest)
 		})
+		histories = append(histories, lo.Map(summaries, func(i AnalysisResult, index int) string {
+			return AnalysisResultToHistory(&i)
+		})...)
 
-		parts := make([]genai.Part, len(histories))
+		parts := make([]generative.Part, len(histories))
 		for i, history := range histories {
-			parts[i] = genai.Text(history)
+			parts[i] = generative.Text(history)
 		}
 
-		chatSession.History = []*genai.Content{
+		chatSession.History = []generative.Message{
 			{
 				Parts: parts,
 				Role:  "model",
@@ -127,56 +310,92 @@ func (cs *ChatSession) GetOrCreateChatSession(userID string) (*genai.ChatSession
 		}
 	}
 
-	cs.Sessions[userID] = chatSession
-	return chatSession, nil
+	session := &UserSession{
+		ChatSession: chatSession,
+		Day:         JournalDay(now),
+		OwnKey:      ownKey,
+		CreatedAt:   now,
+	}
+
+	if session, added := cs.add(userID, session); !added {
+		return session, nil
+	}
+
+	if err := cs.SaveChatSession(userID); err != nil {
+		log.Printf("Error saving chat session for user %s: %v", userID, err)
+		return nil, err
+	}
+
+	return session, nil
 }
 
-// DeleteChatSession delete chat session for user
-func (cs *ChatSession) DeleteChatSession(userID string) error {
+// SaveChatSession writes the current history of user's chat session through to storage.
+// Should be called after every turn so a restart does not lose the conversation
+func (cs *ChatSession) SaveChatSession(userID string) error {
+	cs.mu.Lock()
+	session, ok := cs.Sessions[userID]
+	cs.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("chat session not found for user %s", userID)
+	}
+
+	return storage.StoreClient.SaveSession(context.Background(), userID, &storage.Session{
+		Day:       session.Day,
+		History:   HistoryToMessages(session.History),
+		CreatedAt: session.CreatedAt,
+		UpdatedAt: time.Now(),
+	})
+}
+
+// EvictChatSession drops the in-memory chat session of user, keeping it in storage.
+// The next message rehydrates it, e.g. with a different Gemini key
+func (cs *ChatSession) EvictChatSession(userID string) {
 	cs.mu.Lock()
 	defer cs.mu.Unlock()
 
 	delete(cs.Sessions, userID)
+}
 
-	return nil
+// DeleteChatSession delete chat session for user, from memory then from storage without the lock
+func (cs *ChatSession) DeleteChatSession(userID string) error {
+	cs.EvictChatSession(userID)
+
+	return storage.StoreClient.DeleteSession(context.Background(), userID)
 }
 
 // IngestChatSession summarize chat seesion for user
 // Persists entry into db
-func IngestChatSession(chatSession *genai.ChatSession, platformUserId string) (*AnalysisResult, error) {
+func IngestChatSession(chatSession *UserSession, platformUserId string) (*AnalysisResult, error) {
 	ctx := context.Background()
 
-	response, err := generative.SummarizeSession(chatSession)
+	summary, err := generative.SummarizeSession(chatSession.Provider, chatSession.History, preferencesOf(getUser(platformUserId)))
 	if err != nil {
 		log.Println("Error generating summary:", err)
 		return nil, err
 	}
 
-	jsonString := response.Candidates[0].Content.Parts[0]
-	text, ok := jsonString.(genai.Text)
-	if !ok {
-		return nil, errors.New("failed to convert to text")
+	result := AnalysisResult{
+		Summary:   summary.Summary,
+		Mood:      summary.Mood,
+		Photos:    SessionPhotos(chatSession.History),
+		Date:      chatSession.Day,
+		CreatedAt: time.Now(),
 	}
 
-	cleanJSON := strings.TrimSpace(strings.TrimPrefix(strings.TrimSuffix(string(text), "``` \n"), "```json\n"))
-
-	var result AnalysisResult
-	if err := json.Unmarshal([]byte(cleanJSON), &result); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	// store entry under the journaling day of the session
+	entry := &storage.Entry{
+		Id:        result.Date,
+		Summary:   result.Summary,
+		Mood:      result.Mood,
+		Photos:    result.Photos,
+		CreatedAt: result.CreatedAt,
 	}
-	now := time.Now()
-	result.CreatedAt = now
-
-	// store text into firestore
-	collectionPath := fmt.Sprintf("users/%s/%s", platformUserId, "entries")
-	_, err = firebaseClient.FirestoreClient.Collection(collectionPath).Doc(now.Format("2006-01-02")).Set(ctx, map[string]interface{}{
-		"summary":   &result.Summary,
-		"mood":      &result.Mood,
-		"createdAt": &result.CreatedAt,
-	})
+	embedEntry(ctx, chatSession.Provider, entry)
 
+	err = storage.StoreClient.SaveEntry(ctx, platformUserId, entry)
 	if err != nil {
-		return nil, fmt.Errorf("error saving summary to firestore: %w", err)
+		return nil, fmt.Errorf("error saving summary to storage: %w", err)
 	}
 
 	return &result, nil
@@ -0,0 +1,255 @@
+package chatsession_test
+
+import (
+	"context"
+	"fmt"
+	chatsession "journie/pkg/chat-session"
+	"journie/pkg/generative"
+	"journie/pkg/storage"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestJournalDay calls chatsession.JournalDay around the day boundary, checking
+// that early morning conversations belong to the previous day.
+func TestJournalDay(t *testing.T) {
+	tests := map[time.Time]string{
+		time.Date(2024, 5, 20, 22, 0, 0, 0, time.UTC): "2024-05-20",
+		time.Date(2024, 5, 21, 3, 59, 0, 0, time.UTC): "2024-05-20",
+		time.Date(2024, 5, 21, 4, 0, 0, 0, time.UTC):  "2024-05-21",
+	}
+
+	for at, want := range tests {
+		if got := chatsession.JournalDay(at); got != want {
+			t.Fatalf(`JournalDay(%v) = %q, want %q`, at, got, want)
+		}
+	}
+}
+
+// TestHistoryRoundTrip converts chat history to storage messages and back,
+// checking that text and inline data survive.
+func TestHistoryRoundTrip(t *testing.T) {
+	history := []generative.Message{
+		{Role: "user", Parts: []generative.Part{generative.Text("hi journie"), {MIMEType: "image/jpeg", Data: []byte{0xff, 0xd8}}}},
+		{Role: "model", Parts: []generative.Part{generative.Text("Hi there! How are you feeling today?")}},
+	}
+
+	got := chatsession.MessagesToHistory(chatsession.HistoryToMessages(history))
+	if !reflect.DeepEqual(got, history) {
+		t.Fatalf(`MessagesToHistory(HistoryToMessages(history)) = %v, want %v`, got, history)
+	}
+}
+
+// TestIngestChatSession journals a conversation against a fake provider,
+// checking the summary is stored under the session's journaling day.
+func TestIngestChatSession(t *testing.T) {
+	storage.StoreClient = storage.NewMemoryStore()
+	generative.GenAiClient = generative.NewGenAiManager(generative.NewFakeProvider(
+		"How are you feeling today?",
+		`{"summary": "You went for a run.", "mood": ["happy"]}`,
+	))
+	chatsession.Init()
+
+	cs, err := chatsession.ChatSessionClient.GetOrCreateChatSession("telegram-1", "")
+	if err != nil {
+		t.Fatalf(`GetOrCreateChatSession() = %v, want nil`, err)
+	}
+
+	if resp, err := cs.SendMessage(context.Background(), generative.Text("I went for a run")); err != nil || resp.Text != "How are you feeling today?" {
+		t.Fatalf(`SendMessage() = %v, %v, want scripted reply`, resp, err)
+	}
+	if len(cs.History) != 2 {
+		t.Fatalf(`len(History) = %d, want 2`, len(cs.History))
+	}
+
+	result, err := chatsession.IngestChatSession(cs, "telegram-1")
+	if err != nil || result.Summary != "You went for a run." {
+		t.Fatalf(`IngestChatSession() = %v, %v, want stored summary`, result, err)
+	}
+
+	entries, _ := storage.StoreClient.ListEntries(context.Background(), "telegram-1", 0)
+	if len(entries) != 1 || entries[0].Id != cs.Day || !reflect.DeepEqual(entries[0].Mood, []string{"happy"}) {
+		t.Fatalf(`ListEntries() = %v, want entry for %s`, entries, cs.Day)
+	}
+}
+
+// slowStore takes a while to read users, as a remote store does
+type slowStore struct {
+	*storage.MemoryStore
+}
+
+func (s slowStore) GetUser(ctx context.Context, userId string) (*storage.User, error) {
+	time.Sleep(10 * time.Millisecond)
+	return s.MemoryStore.GetUser(ctx, userId)
+}
+
+// TestGetOrCreateChatSessionConcurrent gets the chat session of a user from several goroutines at once,
+// checking they all continue the same session rather than each creating one.
+func TestGetOrCreateChatSessionConcurrent(t *testing.T) {
+	storage.StoreClient = slowStore{storage.NewMemoryStore()}
+	generative.GenAiClient = generative.NewGenAiManager(generative.NewFakeProvider())
+	chatsession.Init()
+
+	sessions := make([]*chatsession.UserSession, 8)
+	var wg sync.WaitGroup
+	for i := range sessions {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sessions[i], _ = chatsession.ChatSessionClient.GetOrCreateChatSession("telegram-1", "")
+		}(i)
+	}
+	wg.Wait()
+
+	for i, session := range sessions {
+		if session == nil || session != sessions[0] {
+			t.Fatalf(`GetOrCreateChatSession() #%d = %p, want the session of #0 %p`, i, session, sessions[0])
+		}
+	}
+	if got := chatsession.ChatSessionClient.GetChatSession("telegram-1"); got != sessions[0] {
+		t.Fatalf(`GetChatSession() = %p, want the shared session %p`, got, sessions[0])
+	}
+}
+
+// blockingStore deletes sessions once released, as a store that is slow to respond.
+// deleting is closed once a deletion waits
+type blockingStore struct {
+	*storage.MemoryStore
+	deleting chan struct{}
+	released chan struct{}
+}
+
+func (s blockingStore) DeleteSession(ctx context.Context, userId string) error {
+	close(s.deleting)
+	<-s.released
+	return s.MemoryStore.DeleteSession(ctx, userId)
+}
+
+// TestDeleteChatSessionUnlocked deletes a user's chat session while storage is slow to respond,
+// checking sessions of other users are still got meanwhile.
+func TestDeleteChatSessionUnlocked(t *testing.T) {
+	store := blockingStore{MemoryStore: storage.NewMemoryStore(), deleting: make(chan struct{}), released: make(chan struct{})}
+	storage.StoreClient = store
+	generative.GenAiClient = generative.NewGenAiManager(generative.NewFakeProvider())
+	chatsession.Init()
+
+	chatsession.ChatSessionClient.GetOrCreateChatSession("telegram-1", "")
+	other, _ := chatsession.ChatSessionClient.GetOrCreateChatSession("telegram-2", "")
+
+	deleted := make(chan error)
+	go func() { deleted <- chatsession.ChatSessionClient.DeleteChatSession("telegram-1") }()
+	<-store.deleting
+
+	got := make(chan *chatsession.UserSession)
+	go func() { got <- chatsession.ChatSessionClient.GetChatSession("telegram-2") }()
+	select {
+	case session := <-got:
+		if session != other {
+			t.Fatalf(`GetChatSession("telegram-2") = %p, want %p`, session, other)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf(`GetChatSession("telegram-2") blocked by the deletion of another session`)
+	}
+
+	close(store.released)
+	if err := <-deleted; err != nil {
+		t.Fatalf(`DeleteChatSession() = %v, want nil`, err)
+	}
+	if session := chatsession.ChatSessionClient.GetChatSession("telegram-1"); session != nil {
+		t.Fatalf(`GetChatSession("telegram-1") = %v, want deleted`, session)
+	}
+}
+
+// TestSessionPhotos stores a session with a captioned photo, checking only the
+// reference is stored and the photo is collected for the day's entry.
+func TestSessionPhotos(t *testing.T) {
+	history := []generative.Message{
+		{Role: "user", Parts: []generative.Part{{MIMEType: "image/jpeg", Data: []byte{0xff, 0xd8}, Ref: "AgACAgUAAxkBAAI"}, generative.Text("sunset at the beach")}},
+		{Role: "model", Parts: []generative.Part{generative.Text("What a beautiful sunset!")}},
+	}
+
+	messages := chatsession.HistoryToMessages(history)
+	if part := messages[0].Parts[0]; part.Data != nil || part.Ref != "AgACAgUAAxkBAAI" {
+		t.Fatalf(`HistoryToMessages() photo part = %v, want reference without data`, part)
+	}
+
+	want := []storage.Photo{{FileId: "AgACAgUAAxkBAAI", Caption: "sunset at the beach"}}
+	if got := chatsession.SessionPhotos(chatsession.MessagesToHistory(messages)); !reflect.DeepEqual(got, want) {
+		t.Fatalf(`SessionPhotos() = %v, want %v`, got, want)
+	}
+}
+
+// TestContextEntries starts chat sessions for a user with entries about work and an old one about
+// a marathon, checking the latest entries and the one most relevant to the first message are injected
+// in order within the token budget, and vectors of another embedding model are left out.
+func TestContextEntries(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("CONTEXT_RECENT_ENTRIES", "2")
+	t.Setenv("CONTEXT_TOKEN_BUDGET", "150") // about 3 entries
+
+	provider := generative.NewFakeProvider()
+	storage.StoreClient = storage.NewMemoryStore()
+	generative.GenAiClient = generative.NewGenAiManager(provider)
+	chatsession.Init()
+
+	summaries := map[string]string{"2024-05-01": "You trained for the marathon, running twenty kilometres."}
+	for day := 2; day <= 9; day++ {
+		summaries[fmt.Sprintf("2024-05-%02d", day)] = "You had meetings at the office all day."
+	}
+	for id, summary := range summaries {
+		embeddings, _ := provider.Embed(ctx, []string{summary})
+		storage.StoreClient.SaveEntry(ctx, "telegram-1", &storage.Entry{Id: id, Summary: summary, Embedding: embeddings[0], EmbeddingModel: provider.EmbeddingModel()})
+	}
+
+	// a vector of another model, of the same length, is not compared however close it seems
+	stray, _ := provider.Embed(ctx, []string{"Went running again, the marathon is close"})
+	storage.StoreClient.SaveEntry(ctx, "telegram-1", &storage.Entry{Id: "2024-04-30", Summary: "You had meetings at the office all day.", Embedding: stray[0], EmbeddingModel: "other/model"})
+
+	injected := func(cs *chatsession.UserSession) []string {
+		var dates []string
+		for _, message := range cs.History {
+			for _, part := range message.Parts {
+				dates = append(dates, part.Text[len("On the date "):len("On the date 2024-05-01")])
+			}
+		}
+		return dates
+	}
+
+	cs, err := chatsession.ChatSessionClient.GetOrCreateChatSession("telegram-1", "Went running again, the marathon is close")
+	if err != nil {
+		t.Fatalf(`GetOrCreateChatSession() = %v, want nil`, err)
+	}
+	if got := injected(cs); !reflect.DeepEqual(got, []string{"2024-05-01", "2024-05-08", "2024-05-09"}) {
+		t.Fatalf(`injected entries = %v, want the marathon and the 2 latest`, got)
+	}
+
+	// a tight budget leaves out what does not fit
+	chatsession.ChatSessionClient.DeleteChatSession("telegram-1")
+	t.Setenv("CONTEXT_TOKEN_BUDGET", "1")
+	cs, _ = chatsession.ChatSessionClient.GetOrCreateChatSession("telegram-1", "Went running again, the marathon is close")
+	if len(cs.History) != 0 {
+		t.Fatalf(`History = %v, want no entries within budget`, cs.History)
+	}
+}
+
+// TestIngestEmbedding journals a conversation, checking the stored entry carries the embedding of its summary.
+func TestIngestEmbedding(t *testing.T) {
+	provider := generative.NewFakeProvider("Nice!", `{"summary": "You baked bread.", "mood": ["happy"]}`)
+	storage.StoreClient = storage.NewMemoryStore()
+	generative.GenAiClient = generative.NewGenAiManager(provider)
+	chatsession.Init()
+
+	cs, _ := chatsession.ChatSessionClient.GetOrCreateChatSession("telegram-1", "I baked bread")
+	cs.SendMessage(context.Background(), generative.Text("I baked bread"))
+	if _, err := chatsession.IngestChatSession(cs, "telegram-1"); err != nil {
+		t.Fatalf(`IngestChatSession() = %v, want nil`, err)
+	}
+
+	want, _ := provider.Embed(context.Background(), []string{"You baked bread."})
+	entries, _ := storage.StoreClient.ListEntries(context.Background(), "telegram-1", 0)
+	if len(entries) != 1 || !reflect.DeepEqual(entries[0].Embedding, want[0]) {
+		t.Fatalf(`ListEntries() = %v, want entry with embedding of its summary`, entries)
+	}
+}
@@ -0,0 +1,224 @@
+package chatsession
+
+import (
+	"context"
+	"fmt"
+	"journie/pkg/generative"
+	"journie/pkg/storage"
+	"journie/pkg/users"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// digestDelay is how many days after a period ends it is rolled up, leaving time for late and retried summaries
+const digestDelay = 2
+
+// DigestRollupLimit returns how many digests are created per user per rollup, from DIGEST_ROLLUP_LIMIT env,
+// defaulting to 8. A long history is backfilled over several daily runs rather than in one burst of requests
+func DigestRollupLimit() int {
+	if limit, err := strconv.Atoi(os.Getenv("DIGEST_ROLLUP_LIMIT")); err == nil && limit > 0 {
+		return limit
+	}
+	return 8
+}
+
+// Periods follow ISO weeks, a week belongs to the month and year of its thursday,
+// so each week rolls up into exactly one month and each month into one year
+type span struct {
+	key   string // e.g. 2024-W21, 2024-05 or 2024
+	start time.Time
+	end   time.Time // last day
+}
+
+func weekStart(day time.Time) time.Time {
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
+
+// weekOf returns the ISO week containing day
+func weekOf(day time.Time) span {
+	start := weekStart(day)
+	year, week := start.AddDate(0, 0, 3).ISOWeek()
+	return span{key: fmt.Sprintf("%d-W%02d", year, week), start: start, end: start.AddDate(0, 0, 6)}
+}
+
+// monthOf returns the weeks whose thursday falls in the month of day
+func monthOf(day time.Time) span {
+	first := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+	last := first.AddDate(0, 1, -1)
+	firstThursday := first.AddDate(0, 0, (int(time.Thursday)-int(first.Weekday())+7)%7)
+	lastThursday := last.AddDate(0, 0, -((int(last.Weekday()) - int(time.Thursday) + 7) % 7))
+	return span{key: first.Format("2006-01"), start: firstThursday.AddDate(0, 0, -3), end: lastThursday.AddDate(0, 0, 3)}
+}
+
+// yearOf returns the weeks whose thursday falls in the year of day
+func yearOf(day time.Time) span {
+	return span{
+		key:   fmt.Sprint(day.Year()),
+		start: monthOf(time.Date(day.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)).start,
+		end:   monthOf(time.Date(day.Year(), time.December, 1, 0, 0, 0, 0, time.UTC)).end,
+	}
+}
+
+func digestId(period string, key string) string {
+	return period + "-" + key
+}
+
+// digestGroup is the entries or digests of a period to roll up
+type digestGroup struct {
+	span
+	inputs []generative.DigestInput
+	days   int
+	moods  map[string]int
+}
+
+// RollupDigests rolls up a user's journal of periods that ended at least digestDelay days before now,
+// daily entries into weekly digests, weekly into monthly and monthly into yearly.
+// Periods already rolled up are left alone, returns the number of digests created.
+// At most DigestRollupLimit digests are created, once reached the next run continues, and
+// longer periods wait until the shorter ones they are made of are all rolled up
+func RollupDigests(userId string, now time.Time) (int, error) {
+	ctx := context.Background()
+	user := getUser(userId)
+	provider, _ := providerForUser(user)
+	prefs := preferencesOf(user)
+
+	today, err := time.Parse("2006-01-02", JournalDay(now.In(users.Location(user))))
+	if err != nil {
+		return 0, err
+	}
+	complete := func(s span) bool {
+		return s.end.AddDate(0, 0, digestDelay).Before(today)
+	}
+
+	limit := DigestRollupLimit()
+	created := 0
+
+	// weeks from daily entries
+	weeks, err := storage.StoreClient.ListDigests(ctx, userId, storage.Weekly)
+	if err != nil {
+		return created, err
+	}
+	query := storage.EntryQuery{}
+	if len(weeks) > 0 {
+		query.After = weeks[len(weeks)-1].End
+	}
+	entries, err := storage.StoreClient.QueryEntries(ctx, userId, query)
+	if err != nil {
+		return created, err
+	}
+
+	var groups []*digestGroup
+	for _, entry := range entries {
+		day, err := time.Parse("2006-01-02", entry.Id)
+		if err != nil {
+			log.Printf("Skipping entry %s of user %s in digests: %v", entry.Id, userId, err)
+			continue
+		}
+
+		group := groupFor(&groups, weekOf(day))
+		group.inputs = append(group.inputs, generative.DigestInput{Period: entry.Id, Summary: entry.Summary})
+		group.days++
+		for _, mood := range entry.Mood {
+			group.moods[mood]++
+		}
+	}
+	n, err := saveDigests(ctx, provider, prefs, userId, storage.Weekly, groups, complete, limit)
+	if created += n; err != nil || created >= limit {
+		return created, err
+	}
+
+	// months from weeks, then years from months
+	for _, level := range []struct {
+		from, to string
+		spanOf   func(day time.Time) span
+	}{
+		{storage.Weekly, storage.Monthly, monthOf},
+		{storage.Monthly, storage.Yearly, yearOf},
+	} {
+		digests, err := storage.StoreClient.ListDigests(ctx, userId, level.to)
+		if err != nil {
+			return created, err
+		}
+		after := ""
+		if len(digests) > 0 {
+			after = digests[len(digests)-1].End
+		}
+
+		children, err := storage.StoreClient.ListDigests(ctx, userId, level.from)
+		if err != nil {
+			return created, err
+		}
+
+		groups = nil
+		for _, child := range children {
+			if child.Start <= after {
+				continue
+			}
+			start, err := time.Parse("2006-01-02", child.Start)
+			if err != nil {
+				log.Printf("Skipping digest %s of user %s: %v", child.Id, userId, err)
+				continue
+			}
+
+			group := groupFor(&groups, level.spanOf(start.AddDate(0, 0, 3)))
+			group.inputs = append(group.inputs, generative.DigestInput{Period: child.Id[len(level.from)+1:], Summary: child.Summary, Highlights: child.Highlights})
+			group.days += child.Days
+			for mood, days := range child.Moods {
+				group.moods[mood] += days
+			}
+		}
+
+		n, err := saveDigests(ctx, provider, prefs, userId, level.to, groups, complete, limit-created)
+		if created += n; err != nil || created >= limit {
+			return created, err
+		}
+	}
+
+	return created, nil
+}
+
+// groupFor returns the group of s, appending one if s is not the last group
+func groupFor(groups *[]*digestGroup, s span) *digestGroup {
+	if n := len(*groups); n > 0 && (*groups)[n-1].key == s.key {
+		return (*groups)[n-1]
+	}
+
+	group := &digestGroup{span: s, moods: make(map[string]int)}
+	*groups = append(*groups, group)
+	return group
+}
+
+// saveDigests summarizes and saves up to limit digests of complete groups, in order so a failure or the limit leaves no gaps
+func saveDigests(ctx context.Context, provider generative.Provider, prefs generative.Preferences, userId string, period string, groups []*digestGroup, complete func(span) bool, limit int) (int, error) {
+	created := 0
+	for _, group := range groups {
+		if !complete(group.span) || created >= limit {
+			break
+		}
+
+		summary, err := generative.SummarizeDigest(provider, period, group.inputs, prefs)
+		if err != nil {
+			return created, fmt.Errorf("summarizing %s digest %s: %w", period, group.key, err)
+		}
+
+		digest := &storage.Digest{
+			Id:         digestId(period, group.key),
+			Period:     period,
+			Start:      group.start.Format("2006-01-02"),
+			End:        group.end.Format("2006-01-02"),
+			Days:       group.days,
+			Summary:    summary.Summary,
+			Highlights: summary.Highlights,
+			Moods:      group.moods,
+			CreatedAt:  time.Now(),
+		}
+		if err := storage.StoreClient.SaveDigest(ctx, userId, digest); err != nil {
+			return created, err
+		}
+		created++
+	}
+
+	return created, nil
+}
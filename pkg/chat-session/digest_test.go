@@ -0,0 +1,111 @@
+package chatsession_test
+
+import (
+	"context"
+	chatsession "journie/pkg/chat-session"
+	"journie/pkg/generative"
+	"journie/pkg/storage"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRollupDigests rolls up entries of May and June, then again half a year later, checking weeks
+// roll into the months of their thursday, moods add up and periods are rolled up once.
+func TestRollupDigests(t *testing.T) {
+	ctx := context.Background()
+	storage.StoreClient = storage.NewMemoryStore()
+	generative.GenAiClient = generative.NewGenAiManager(generative.NewFakeProvider())
+	chatsession.Init()
+
+	for day, mood := range map[string][]string{
+		"2024-05-06": {"happy"},
+		"2024-05-08": {"happy", "sad"},
+		"2024-05-15": {"sad"},
+		"2024-06-03": {"neutral"},
+		"2024-06-30": {"happy"},
+	} {
+		storage.StoreClient.SaveEntry(ctx, "telegram-1", &storage.Entry{Id: day, Summary: "You journaled.", Mood: mood})
+	}
+
+	july := time.Date(2024, 7, 10, 12, 0, 0, 0, time.UTC)
+	if created, err := chatsession.RollupDigests("telegram-1", july); err != nil || created != 6 {
+		t.Fatalf(`RollupDigests(july) = %d, %v, want 4 weeks and 2 months`, created, err)
+	}
+
+	weeks, _ := storage.StoreClient.ListDigests(ctx, "telegram-1", storage.Weekly)
+	var ids []string
+	for _, week := range weeks {
+		ids = append(ids, week.Id)
+	}
+	if !reflect.DeepEqual(ids, []string{"week-2024-W19", "week-2024-W20", "week-2024-W23", "week-2024-W26"}) {
+		t.Fatalf(`weekly digests = %v, want weeks with entries`, ids)
+	}
+
+	months, _ := storage.StoreClient.ListDigests(ctx, "telegram-1", storage.Monthly)
+	if len(months) != 2 || months[0].Id != "month-2024-05" || months[0].Days != 3 || !reflect.DeepEqual(months[0].Moods, map[string]int{"happy": 2, "sad": 2}) {
+		t.Fatalf(`monthly digests = %+v, want may with 3 days, happy and sad twice`, months)
+	}
+	if months[1].Start != "2024-06-03" || months[1].End != "2024-06-30" {
+		t.Fatalf(`june digest covers %s to %s, want 2024-06-03 to 2024-06-30`, months[1].Start, months[1].End)
+	}
+
+	if created, err := chatsession.RollupDigests("telegram-1", july.Add(24*time.Hour)); err != nil || created != 0 {
+		t.Fatalf(`RollupDigests(july again) = %d, %v, want nothing new`, created, err)
+	}
+
+	if created, err := chatsession.RollupDigests("telegram-1", time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)); err != nil || created != 1 {
+		t.Fatalf(`RollupDigests(next january) = %d, %v, want 2024`, created, err)
+	}
+	years, _ := storage.StoreClient.ListDigests(ctx, "telegram-1", storage.Yearly)
+	if len(years) != 1 || years[0].Id != "year-2024" || years[0].Days != 5 || years[0].Moods["happy"] != 3 {
+		t.Fatalf(`yearly digests = %+v, want 2024 with 5 days`, years)
+	}
+
+	// digests are injected as long-term context, longest first
+	cs, err := chatsession.ChatSessionClient.GetOrCreateChatSession("telegram-1", "")
+	if err != nil || len(cs.History) != 1 {
+		t.Fatalf(`GetOrCreateChatSession() = %v, %v, want context`, cs, err)
+	}
+	if first := cs.History[0].Parts[0].Text; !strings.HasPrefix(first, "From 2024-01-01 to 2024-12-29") {
+		t.Fatalf(`first context = %q, want the 2024 digest`, first)
+	}
+}
+
+// TestRollupDigestsLimit backfills months of entries with a limit of 3 digests per rollup, checking
+// later rollups continue where the last stopped and months wait for all of their weeks.
+func TestRollupDigestsLimit(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("DIGEST_ROLLUP_LIMIT", "3")
+	storage.StoreClient = storage.NewMemoryStore()
+	generative.GenAiClient = generative.NewGenAiManager(generative.NewFakeProvider())
+	chatsession.Init()
+
+	for _, day := range []string{"2024-05-06", "2024-05-08", "2024-05-15", "2024-06-03", "2024-06-30"} {
+		storage.StoreClient.SaveEntry(ctx, "telegram-1", &storage.Entry{Id: day, Summary: "You journaled.", Mood: []string{"happy"}})
+	}
+
+	july := time.Date(2024, 7, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		created, weeks, months int
+	}{
+		{3, 3, 0}, // june's last week is not rolled up yet, so neither are the months
+		{3, 4, 2},
+		{0, 4, 2},
+	}
+	for i, test := range tests {
+		created, err := chatsession.RollupDigests("telegram-1", july.AddDate(0, 0, i))
+		weeks, _ := storage.StoreClient.ListDigests(ctx, "telegram-1", storage.Weekly)
+		months, _ := storage.StoreClient.ListDigests(ctx, "telegram-1", storage.Monthly)
+		if err != nil || created != test.created || len(weeks) != test.weeks || len(months) != test.months {
+			t.Fatalf(`RollupDigests() #%d = %d, %v with %d weeks and %d months, want %d with %d weeks and %d months`,
+				i+1, created, err, len(weeks), len(months), test.created, test.weeks, test.months)
+		}
+	}
+
+	months, _ := storage.StoreClient.ListDigests(ctx, "telegram-1", storage.Monthly)
+	if months[0].Days != 3 || months[1].Days != 2 {
+		t.Fatalf(`monthly digests = %+v, want may with 3 days and june with 2`, months)
+	}
+}
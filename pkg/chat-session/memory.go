@@ -0,0 +1,159 @@
+package chatsession
+
+import (
+	"context"
+	"journie/pkg/generative"
+	"journie/pkg/storage"
+	"journie/pkg/vectors"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// ContextTokenBudget returns the tokens of past entries injected into a new chat session,
+// from CONTEXT_TOKEN_BUDGET env, defaulting to 2000
+func ContextTokenBudget() int {
+	if budget, err := strconv.Atoi(os.Getenv("CONTEXT_TOKEN_BUDGET")); err == nil && budget > 0 {
+		return budget
+	}
+	return 2000
+}
+
+// ContextRecentEntries returns how many of the latest entries are injected into a new chat session
+// before relevant older ones, from CONTEXT_RECENT_ENTRIES env, defaulting to 3
+func ContextRecentEntries() int {
+	if recent, err := strconv.Atoi(os.Getenv("CONTEXT_RECENT_ENTRIES")); err == nil && recent >= 0 {
+		return recent
+	}
+	return 3
+}
+
+// embedEntry sets the embedding of an entry's summary and its model, none if it cannot be embedded
+func embedEntry(ctx context.Context, provider generative.Provider, entry *storage.Entry) {
+	embeddings, err := provider.Embed(ctx, []string{entry.Summary})
+	if err != nil || len(embeddings) != 1 {
+		log.Printf("Error embedding entry, storing it without: %v", err)
+		return
+	}
+
+	entry.Embedding, entry.EmbeddingModel = embeddings[0], provider.EmbeddingModel()
+}
+
+// contextDigests lists how many of the latest digests of each period are injected into a new chat session
+var contextDigests = []struct {
+	period string
+	count  int
+}{
+	{storage.Weekly, 2},
+	{storage.Monthly, 3},
+	{storage.Yearly, 2},
+}
+
+// digestContext returns digests of user to inject into a new chat session as long-term context,
+// the latest weeks, months and years while they fit in budget, oldest and longest first
+func digestContext(ctx context.Context, userId string, budget int) ([]storage.Digest, int, error) {
+	var (
+		selected []storage.Digest
+		used     int
+	)
+	for _, level := range contextDigests {
+		digests, err := storage.StoreClient.ListDigests(ctx, userId, level.period)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		for i := len(digests) - 1; i >= 0 && i >= len(digests)-level.count; i-- {
+			tokens := digestTokens(&digests[i])
+			if used+tokens > budget {
+				continue
+			}
+			used += tokens
+			selected = append(selected, digests[i])
+		}
+	}
+
+	sort.Slice(selected, func(i, j int) bool {
+		if selected[i].Start != selected[j].Start {
+			return selected[i].Start < selected[j].Start
+		}
+		return selected[i].End > selected[j].End
+	})
+	return selected, used, nil
+}
+
+// contextEntries returns past entries of user to inject into a new chat session, oldest first.
+// The latest ContextRecentEntries are picked first, then older entries most similar to query,
+// or to the latest entry if there is no query, while they fit in budget. Only entries embedded
+// by the embedding model of provider are compared, e.g. not those of a user's own key with another provider
+func contextEntries(ctx context.Context, provider generative.Provider, userId string, query string, budget int) ([]storage.Entry, error) {
+	entries, err := storage.StoreClient.QueryEntries(ctx, userId, storage.EntryQuery{Descending: true})
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	picked := make(map[string]bool)
+	var selected []storage.Entry
+	pick := func(entry storage.Entry) {
+		tokens := entryTokens(&entry)
+		if tokens > budget || picked[entry.Id] {
+			return
+		}
+		budget -= tokens
+		picked[entry.Id] = true
+		selected = append(selected, entry)
+	}
+
+	recent := min(ContextRecentEntries(), len(entries))
+	for _, entry := range entries[:recent] {
+		pick(entry)
+	}
+
+	model := provider.EmbeddingModel()
+	var queryVector []float32
+	if entries[0].EmbeddingModel == model {
+		queryVector = entries[0].Embedding
+	}
+	if query != "" {
+		if embeddings, err := provider.Embed(ctx, []string{query}); err == nil && len(embeddings) == 1 {
+			queryVector = embeddings[0]
+		} else {
+			log.Printf("Error embedding first message of user %s, relating to the latest entry: %v", userId, err)
+		}
+	}
+
+	if len(queryVector) != 0 {
+		index := vectors.NewIndex()
+		byId := make(map[string]storage.Entry)
+		for _, entry := range entries[recent:] {
+			if entry.EmbeddingModel != model {
+				continue
+			}
+			index.Add(entry.Id, entry.Embedding)
+			byId[entry.Id] = entry
+		}
+
+		for _, match := range index.Search(queryVector, 0) {
+			if match.Score <= 0 {
+				break
+			}
+			pick(byId[match.Id])
+		}
+	}
+
+	sort.Slice(selected, func(i, j int) bool { return selected[i].Id < selected[j].Id })
+	return selected, nil
+}
+
+// digestTokens estimates the tokens a digest takes up in a chat session's history
+func digestTokens(digest *storage.Digest) int {
+	return generative.EstimateTokens([]generative.Message{{Parts: []generative.Part{generative.Text(DigestToHistory(digest))}}})
+}
+
+// entryTokens estimates the tokens an entry takes up in a chat session's history
+func entryTokens(entry *storage.Entry) int {
+	return generative.EstimateTokens([]generative.Message{{Parts: []generative.Part{generative.Text(AnalysisResultToHistory(EntryToAnalysisResult(entry)))}}})
+}
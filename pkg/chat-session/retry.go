@@ -0,0 +1,136 @@
+package chatsession
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"journie/pkg/generative"
+	"journie/pkg/storage"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// ErrNotDeadLetter is returned when replaying a failed summary still queued for retry
+var ErrNotDeadLetter = errors.New("summary is still queued for retry")
+
+// Failed summaries are retried after summaryRetryBase, doubling with each attempt up to summaryRetryMax
+const (
+	summaryRetryBase = 5 * time.Minute
+	summaryRetryMax  = 6 * time.Hour
+)
+
+// SummaryRetryLimit returns attempts at summarizing a session before it is parked as a dead letter,
+// from SUMMARY_RETRY_LIMIT env, defaulting to 6
+func SummaryRetryLimit() int {
+	if limit, err := strconv.Atoi(os.Getenv("SUMMARY_RETRY_LIMIT")); err == nil && limit > 0 {
+		return limit
+	}
+	return 6
+}
+
+// RetryDelay returns the backoff after a number of failed attempts
+func RetryDelay(attempts int) time.Duration {
+	delay := summaryRetryBase
+	for i := 1; i < attempts && delay < summaryRetryMax; i++ {
+		delay *= 2
+	}
+
+	return min(delay, summaryRetryMax)
+}
+
+// QueueSummaryRetry keeps the raw history of a chat session that failed to summarize for retries,
+// then deletes the session so the user starts the next day afresh
+func QueueSummaryRetry(chatSession *UserSession, platformUserId string, cause error) error {
+	ctx := context.Background()
+	now := time.Now()
+
+	failed := &storage.FailedSummary{
+		Id:          storage.FailedSummaryId(platformUserId, chatSession.Day),
+		UserId:      platformUserId,
+		Day:         chatSession.Day,
+		History:     HistoryToMessages(chatSession.History),
+		Attempts:    1,
+		Error:       cause.Error(),
+		NextAttempt: now.Add(RetryDelay(1)),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := storage.StoreClient.SaveFailedSummary(ctx, failed); err != nil {
+		return err
+	}
+	log.Printf("Summary of %s for user %s queued for retry at %s", failed.Day, platformUserId, failed.NextAttempt.Format(time.RFC3339))
+
+	return ChatSessionClient.DeleteChatSession(platformUserId)
+}
+
+// RetrySummary summarizes a failed summary again. On failure it is queued with a longer backoff,
+// or parked as a dead letter once SummaryRetryLimit attempts failed
+func RetrySummary(failed *storage.FailedSummary) (*AnalysisResult, error) {
+	ctx := context.Background()
+
+	result, err := ingestFailedSummary(failed)
+	if err == nil {
+		return result, storage.StoreClient.DeleteFailedSummary(ctx, failed.Id)
+	}
+
+	now := time.Now()
+	failed.Attempts++
+	failed.Error = err.Error()
+	failed.NextAttempt = now.Add(RetryDelay(failed.Attempts))
+	failed.UpdatedAt = now
+	if failed.Attempts >= SummaryRetryLimit() {
+		failed.Dead = true
+		log.Printf("Summary of %s for user %s parked as dead letter after %d attempts: %v", failed.Day, failed.UserId, failed.Attempts, err)
+	}
+
+	if saveErr := storage.StoreClient.SaveFailedSummary(ctx, failed); saveErr != nil {
+		return nil, fmt.Errorf("%w, and saving the failure: %v", err, saveErr)
+	}
+
+	return nil, err
+}
+
+// ReplayDeadLetter summarizes a dead letter again on an operator's request, deleting it on success.
+// On failure the dead letter is kept with the new error
+func ReplayDeadLetter(id string) (*AnalysisResult, error) {
+	ctx := context.Background()
+
+	failed, err := storage.StoreClient.GetFailedSummary(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !failed.Dead {
+		return nil, ErrNotDeadLetter
+	}
+
+	result, err := ingestFailedSummary(failed)
+	if err == nil {
+		return result, storage.StoreClient.DeleteFailedSummary(ctx, id)
+	}
+
+	failed.Attempts++
+	failed.Error = err.Error()
+	failed.UpdatedAt = time.Now()
+	if saveErr := storage.StoreClient.SaveFailedSummary(ctx, failed); saveErr != nil {
+		log.Printf("Error saving dead letter %s: %v", id, saveErr)
+	}
+
+	return nil, err
+}
+
+// ingestFailedSummary summarizes the raw history of a failed summary with the user's current provider
+func ingestFailedSummary(failed *storage.FailedSummary) (*AnalysisResult, error) {
+	user := getUser(failed.UserId)
+	provider, ownKey := providerForUser(user)
+	chatSession := generative.StartChat(provider, userModel(user))
+	chatSession.History = MessagesToHistory(failed.History)
+
+	return IngestChatSession(&UserSession{
+		ChatSession: chatSession,
+		Day:         failed.Day,
+		OwnKey:      ownKey,
+		CreatedAt:   failed.CreatedAt,
+	}, failed.UserId)
+}
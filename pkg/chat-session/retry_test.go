@@ -0,0 +1,90 @@
+package chatsession_test
+
+import (
+	"context"
+	"errors"
+	chatsession "journie/pkg/chat-session"
+	"journie/pkg/generative"
+	"journie/pkg/storage"
+	"testing"
+	"time"
+)
+
+// TestRetryDelay calls chatsession.RetryDelay, checking the backoff doubles up to its cap.
+func TestRetryDelay(t *testing.T) {
+	tests := map[int]time.Duration{
+		1:  5 * time.Minute,
+		2:  10 * time.Minute,
+		4:  40 * time.Minute,
+		10: 6 * time.Hour,
+	}
+
+	for attempts, want := range tests {
+		if got := chatsession.RetryDelay(attempts); got != want {
+			t.Errorf(`RetryDelay(%d) = %s, want %s`, attempts, got, want)
+		}
+	}
+}
+
+// TestSummaryRetry fails to summarize a session until it is parked as a dead letter,
+// checking the raw history is kept throughout and replaying it stores the day's entry.
+func TestSummaryRetry(t *testing.T) {
+	t.Setenv("SUMMARY_MAX_ATTEMPTS", "1")
+	t.Setenv("SUMMARY_RETRY_LIMIT", "2")
+	ctx := context.Background()
+
+	storage.StoreClient = storage.NewMemoryStore()
+	fake := generative.NewFakeProvider("How are you feeling today?")
+	fake.Script = append(fake.Script,
+		generative.FakeReply{Err: errors.New("model overloaded")},
+		generative.FakeReply{Text: "not json"},
+	)
+	generative.GenAiClient = generative.NewGenAiManager(fake)
+	chatsession.Init()
+
+	cs, _ := chatsession.ChatSessionClient.GetOrCreateChatSession("telegram-1", "")
+	cs.SendMessage(ctx, generative.Text("I went for a run"))
+	chatsession.ChatSessionClient.SaveChatSession("telegram-1")
+
+	_, err := chatsession.IngestChatSession(cs, "telegram-1")
+	if err == nil {
+		t.Fatalf(`IngestChatSession() = nil, want error`)
+	}
+	if err := chatsession.QueueSummaryRetry(cs, "telegram-1", err); err != nil {
+		t.Fatalf(`QueueSummaryRetry() = %v, want nil`, err)
+	}
+	if session, err := storage.StoreClient.GetSession(ctx, "telegram-1"); err != storage.ErrNotFound {
+		t.Fatalf(`GetSession() after queueing retry = %v, %v, want ErrNotFound`, session, err)
+	}
+
+	queued, _ := storage.StoreClient.ListFailedSummaries(ctx, false)
+	if len(queued) != 1 || queued[0].Attempts != 1 || queued[0].Day != cs.Day || len(queued[0].History) != 2 {
+		t.Fatalf(`ListFailedSummaries(false) = %+v, want 1 queued summary with history`, queued)
+	}
+	if delay := queued[0].NextAttempt.Sub(queued[0].UpdatedAt); delay != 5*time.Minute {
+		t.Fatalf(`first retry after %s, want 5m`, delay)
+	}
+
+	if _, err := chatsession.ReplayDeadLetter(queued[0].Id); err != chatsession.ErrNotDeadLetter {
+		t.Fatalf(`ReplayDeadLetter() of queued summary = %v, want ErrNotDeadLetter`, err)
+	}
+
+	if _, err := chatsession.RetrySummary(&queued[0]); err == nil {
+		t.Fatalf(`RetrySummary() = nil, want error`)
+	}
+
+	dead, _ := storage.StoreClient.ListFailedSummaries(ctx, true)
+	if queued, _ := storage.StoreClient.ListFailedSummaries(ctx, false); len(queued) != 0 || len(dead) != 1 || dead[0].Attempts != 2 || len(dead[0].History) != 2 {
+		t.Fatalf(`ListFailedSummaries() = %+v, %+v, want 1 dead letter with history`, queued, dead)
+	}
+
+	result, err := chatsession.ReplayDeadLetter(dead[0].Id)
+	if err != nil || result.Date != cs.Day {
+		t.Fatalf(`ReplayDeadLetter() = %v, %v, want entry for %s`, result, err, cs.Day)
+	}
+
+	entries, _ := storage.StoreClient.ListEntries(ctx, "telegram-1", 0)
+	if dead, _ := storage.StoreClient.ListFailedSummaries(ctx, true); len(entries) != 1 || len(dead) != 0 {
+		t.Fatalf(`after replay entries = %v, dead letters = %v, want 1 entry and no dead letters`, entries, dead)
+	}
+}
@@ -0,0 +1,118 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"journie/pkg/storage"
+	"strings"
+	"time"
+)
+
+const (
+	Markdown string = "markdown"
+	JSON     string = "json"
+	CSV      string = "csv"
+)
+
+// Formats lists supported export formats, first one is the default
+var Formats = []string{Markdown, JSON, CSV}
+
+// Document is a rendered export ready to be sent as a file
+type Document struct {
+	Data     []byte
+	FileName string
+	MIME     string
+}
+
+type jsonEntry struct {
+	Date      string   `json:"date"`
+	Summary   string   `json:"summary"`
+	Mood      []string `json:"mood"`
+	CreatedAt string   `json:"createdAt"`
+}
+
+// ParseFormat normalizes a user supplied format, e.g. "MD" -> markdown
+func ParseFormat(format string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", "md", Markdown:
+		return Markdown, nil
+	case JSON:
+		return JSON, nil
+	case CSV:
+		return CSV, nil
+	default:
+		return "", fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+// Render renders entries, oldest first, into a document of format.
+// name is used as file name without extension
+func Render(format string, name string, entries []storage.Entry) (*Document, error) {
+	switch format {
+	case Markdown:
+		return &Document{Data: RenderMarkdown(entries), FileName: name + ".md", MIME: "text/markdown"}, nil
+	case JSON:
+		data, err := RenderJSON(entries)
+		if err != nil {
+			return nil, err
+		}
+		return &Document{Data: data, FileName: name + ".json", MIME: "application/json"}, nil
+	case CSV:
+		data, err := RenderCSV(entries)
+		if err != nil {
+			return nil, err
+		}
+		return &Document{Data: data, FileName: name + ".csv", MIME: "text/csv"}, nil
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+func RenderMarkdown(entries []storage.Entry) []byte {
+	var buf bytes.Buffer
+
+	buf.WriteString("# Journie Journal\n")
+	for _, entry := range entries {
+		fmt.Fprintf(&buf, "\n## %s\n\n", entry.Id)
+		if len(entry.Mood) != 0 {
+			fmt.Fprintf(&buf, "**Mood:** %s\n\n", strings.Join(entry.Mood, ", "))
+		}
+		fmt.Fprintf(&buf, "%s\n", entry.Summary)
+	}
+
+	return buf.Bytes()
+}
+
+func RenderJSON(entries []storage.Entry) ([]byte, error) {
+	out := make([]jsonEntry, len(entries))
+	for i, entry := range entries {
+		out[i] = jsonEntry{
+			Date:      entry.Id,
+			Summary:   entry.Summary,
+			Mood:      entry.Mood,
+			CreatedAt: entry.CreatedAt.Format(time.RFC3339),
+		}
+	}
+
+	return json.MarshalIndent(out, "", "  ")
+}
+
+func RenderCSV(entries []storage.Entry) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	w.Write([]string{"date", "mood", "summary", "createdAt"})
+	for _, entry := range entries {
+		w.Write([]string{
+			entry.Id,
+			strings.Join(entry.Mood, ";"),
+			entry.Summary,
+			entry.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	w.Flush()
+
+	return buf.Bytes(), w.Error()
+}
@@ -0,0 +1,51 @@
+package export_test
+
+import (
+	"journie/pkg/export"
+	"journie/pkg/storage"
+	"testing"
+	"time"
+)
+
+var entries = []storage.Entry{
+	{
+		Id:        "2024-05-20",
+		Summary:   "You shared with Journie that the offer was lower than expected, \"but fair\".",
+		Mood:      []string{"happy", "sad"},
+		CreatedAt: time.Date(2024, 5, 21, 4, 0, 0, 0, time.UTC),
+	},
+}
+
+// TestParseFormat calls export.ParseFormat with aliases and unknown formats,
+// checking they are normalized or rejected.
+func TestParseFormat(t *testing.T) {
+	for input, want := range map[string]string{"": export.Markdown, "MD": export.Markdown, "csv": export.CSV, " json ": export.JSON} {
+		if got, err := export.ParseFormat(input); got != want || err != nil {
+			t.Fatalf(`ParseFormat(%q) = %q, %v, want %q, nil`, input, got, err, want)
+		}
+	}
+
+	if got, err := export.ParseFormat("pdf"); got != "" || err == nil {
+		t.Fatalf(`ParseFormat("pdf") = %q, %v, want "", error`, got, err)
+	}
+}
+
+// TestRenderCSV calls export.RenderCSV, checking summaries with commas and quotes are escaped.
+func TestRenderCSV(t *testing.T) {
+	want := "date,mood,summary,createdAt\n" +
+		"2024-05-20,happy;sad,\"You shared with Journie that the offer was lower than expected, \"\"but fair\"\".\",2024-05-21T04:00:00Z\n"
+
+	got, err := export.RenderCSV(entries)
+	if string(got) != want || err != nil {
+		t.Fatalf(`RenderCSV() = %q, %v, want %q, nil`, got, err, want)
+	}
+}
+
+// TestRenderMarkdown calls export.RenderMarkdown, checking each entry gets a dated heading with moods.
+func TestRenderMarkdown(t *testing.T) {
+	want := "# Journie Journal\n\n## 2024-05-20\n\n**Mood:** happy, sad\n\n" + entries[0].Summary + "\n"
+
+	if got := export.RenderMarkdown(entries); string(got) != want {
+		t.Fatalf(`RenderMarkdown() = %q, want %q`, got, want)
+	}
+}
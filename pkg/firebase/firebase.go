@@ -4,7 +4,6 @@ import (
 	"context"
 	"log"
 	"os"
-	"time"
 
 	"cloud.google.com/go/firestore"
 	firebase "firebase.google.com/go"
@@ -31,12 +30,3 @@ func Init(ctx context.Context) error {
 
 	return nil
 }
-
-func UpsertUserLastCreatedSession(ctx context.Context, platformUserId string) error {
-	now := time.Now()
-	_, err := FirestoreClient.Collection("users").Doc(platformUserId).Set(ctx, map[string]interface{}{
-		"lastCreatedSession": now,
-	}, firestore.MergeAll)
-
-	return err
-}
@@ -0,0 +1,108 @@
+package generative
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// MaxDigestWords is the word limit of a digest summary
+const MaxDigestWords = 150
+
+// MaxHighlights is the number of highlights kept in a digest
+const MaxHighlights = 5
+
+// Digest is a rollup of a week, month or year of journal summaries
+type Digest struct {
+	Summary    string   `json:"summary"`
+	Highlights []string `json:"highlights"`
+}
+
+// DigestInput is a summary rolled up into a digest, a day's entry or a shorter period's digest
+type DigestInput struct {
+	Period     string   `json:"period"` // day or period covered, e.g. 2024-05-20, 2024-W21 or 2024-05
+	Summary    string   `json:"summary"`
+	Highlights []string `json:"highlights,omitempty"`
+}
+
+// DigestSchema constrains model output of SummarizeDigest
+var DigestSchema = &Schema{
+	Type: "object",
+	Properties: map[string]*Schema{
+		"summary": {
+			Type:        "string",
+			Description: fmt.Sprintf("Summary of the period addressed to the user in second-person, at most %d words", MaxDigestWords),
+		},
+		"highlights": {
+			Type:        "array",
+			Description: fmt.Sprintf("Notable moments of the period, at most %d short phrases", MaxHighlights),
+			Items:       &Schema{Type: "string"},
+		},
+	},
+	Required: []string{"summary", "highlights"},
+}
+
+// ParseDigest parses and validates model output, dropping empty and extra highlights.
+// Anything else wrong is returned as a *SummaryError to re-request
+func ParseDigest(text string) (*Digest, error) {
+	start := strings.Index(text, "{")
+	end := strings.LastIndex(text, "}")
+	if start == -1 || end < start {
+		return nil, &SummaryError{Problems: []string{"output is not a JSON object"}}
+	}
+
+	var digest Digest
+	if err := json.Unmarshal([]byte(text[start:end+1]), &digest); err != nil {
+		return nil, &SummaryError{Problems: []string{fmt.Sprintf("output is not valid JSON: %v", err)}}
+	}
+
+	var problems []string
+
+	digest.Summary = strings.TrimSpace(digest.Summary)
+	if digest.Summary == "" {
+		problems = append(problems, `"summary" is empty`)
+	}
+	if words := len(strings.Fields(digest.Summary)); words > MaxDigestWords {
+		problems = append(problems, fmt.Sprintf(`"summary" has %d words, limit is %d`, words, MaxDigestWords))
+	}
+
+	var highlights []string
+	for _, highlight := range digest.Highlights {
+		if highlight = strings.TrimSpace(highlight); highlight != "" && len(highlights) < MaxHighlights {
+			highlights = append(highlights, highlight)
+		}
+	}
+	digest.Highlights = highlights
+
+	if len(problems) > 0 {
+		return nil, &SummaryError{Problems: problems}
+	}
+
+	return &digest, nil
+}
+
+// SummarizeDigest rolls up summaries of a week, month or year into a digest using provider,
+// the shared provider if nil, in the language of prefs. Invalid output is re-requested like SummarizeSession
+func SummarizeDigest(provider Provider, period string, inputs []DigestInput, prefs Preferences) (*Digest, error) {
+	if provider == nil {
+		provider = GenAiClient.Provider
+	}
+
+	input, err := json.Marshal(inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := []Part{
+		Text(fmt.Sprintf("as a journaling chatbot called Journie, roll up the user's journal of the past %s. the input is a JSON array of summaries, each with the \"period\" it covers, a \"summary\" and possibly \"highlights\". "+
+			"summarise by providing an output in JSON with the following fields: \"summary\" and \"highlights\". Address user in second-person.\n\n"+
+			"the field \"summary\" should describe recurring themes, how the user's mood and circumstances changed, and notable events, in at most %d words. keep names of other people as initials, as in the input.\n\n"+
+			"the field \"highlights\" lists at most %d of the most notable moments as short phrases.", period, MaxDigestWords, MaxHighlights)),
+	}
+	if prefs.Language != "" {
+		parts = append(parts, Text(fmt.Sprintf("write the fields \"summary\" and \"highlights\" in %s.", prefs.Language)))
+	}
+	parts = append(parts, Text("input: "+string(input)), Text("output: "))
+
+	return requestValid(provider, &Config{Schema: DigestSchema}, parts, ParseDigest)
+}
@@ -0,0 +1,15 @@
+package generative
+
+import (
+	"context"
+	"testing"
+)
+
+// UseUserProviders makes UserProvider wrap providers made by create instead of Gemini ones, until the test ends
+func UseUserProviders(t *testing.T, create func(apiKey string) Provider) {
+	previous := newUserProvider
+	newUserProvider = func(ctx context.Context, apiKey string) (Provider, error) {
+		return create(apiKey), nil
+	}
+	t.Cleanup(func() { newUserProvider = previous })
+}
@@ -0,0 +1,123 @@
+package generative
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// fakeDimensions is the length of FakeProvider embeddings
+const fakeDimensions = 64
+
+// FakeReply is one scripted turn of FakeProvider, Err is returned instead of Text when set
+type FakeReply struct {
+	Text string
+	Err  error
+}
+
+// FakeCall records a request received by FakeProvider
+type FakeCall struct {
+	Config   *Config
+	Messages []Message
+}
+
+// FakeProvider replies from a script, for tests and running without a model.
+// Once the script runs out it echoes the user, or returns a neutral summary when JSON is requested
+type FakeProvider struct {
+	Script []FakeReply
+	Calls  []FakeCall
+	mu     sync.Mutex
+}
+
+func NewFakeProvider(replies ...string) *FakeProvider {
+	fake := &FakeProvider{}
+	for _, reply := range replies {
+		fake.Script = append(fake.Script, FakeReply{Text: reply})
+	}
+	return fake
+}
+
+func (p *FakeProvider) Chat(ctx context.Context, config *Config, messages []Message) (*Response, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.Calls = append(p.Calls, FakeCall{Config: config, Messages: messages})
+
+	if len(p.Script) > 0 {
+		reply := p.Script[0]
+		p.Script = p.Script[1:]
+		if reply.Err != nil {
+			return nil, reply.Err
+		}
+		return &Response{Text: reply.Text}, nil
+	}
+
+	if config != nil && (config.JSON || config.Schema != nil) {
+		return &Response{Text: `{"summary": "You journaled with Journie.", "mood": ["neutral"]}`}, nil
+	}
+
+	last := ""
+	if len(messages) > 0 {
+		last = messageText(messages[len(messages)-1])
+	}
+	return &Response{Text: "You said: " + last}, nil
+}
+
+// ChatStream replies like Chat, streaming the reply word by word
+func (p *FakeProvider) ChatStream(ctx context.Context, config *Config, messages []Message, onChunk func(chunk string)) (*Response, error) {
+	resp, err := p.Chat(ctx, config, messages)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, word := range strings.SplitAfter(resp.Text, " ") {
+		onChunk(word)
+	}
+
+	return resp, nil
+}
+
+func (p *FakeProvider) Generate(ctx context.Context, config *Config, parts ...Part) (*Response, error) {
+	return p.Chat(ctx, config, []Message{{Role: "user", Parts: parts}})
+}
+
+func (p *FakeProvider) CountTokens(ctx context.Context, messages []Message) (int, error) {
+	return EstimateTokens(messages), nil
+}
+
+func (p *FakeProvider) EmbeddingModel() string {
+	return "fake"
+}
+
+// Embed hashes the words of each text into a normalized vector, so texts sharing words are similar
+func (p *FakeProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vector := make([]float32, fakeDimensions)
+		for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsNumber(r) }) {
+			hash := fnv.New32a()
+			hash.Write([]byte(word))
+			vector[hash.Sum32()%fakeDimensions]++
+		}
+
+		var norm float64
+		for _, v := range vector {
+			norm += float64(v * v)
+		}
+		if norm > 0 {
+			for j := range vector {
+				vector[j] /= float32(math.Sqrt(norm))
+			}
+		}
+		vectors[i] = vector
+	}
+
+	return vectors, nil
+}
+
+func (p *FakeProvider) Close() error {
+	return nil
+}
@@ -0,0 +1,286 @@
+package generative
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/google/generative-ai-go/genai"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+const defaultGeminiEmbeddingModel = "text-embedding-004"
+
+// defaultGeminiModel is used when GEMINI_MODEL is not set, e.g. for users' own keys while another provider is shared
+const defaultGeminiModel = "gemini-1.5-pro-latest"
+
+// GeminiProvider calls Gemini with an API key, the shared GEMINI_API_KEY or a user's own
+type GeminiProvider struct {
+	client         *genai.Client
+	model          string
+	embeddingModel string
+}
+
+func NewGeminiProvider(ctx context.Context, apiKey string, model string) (*GeminiProvider, error) {
+	client, err := genai.NewClient(ctx, option.WithAPIKey(apiKey))
+	if err != nil {
+		return nil, err
+	}
+
+	if model == "" {
+		model = geminiModel()
+	}
+
+	embeddingModel := os.Getenv("GEMINI_EMBEDDING_MODEL")
+	if embeddingModel == "" {
+		embeddingModel = defaultGeminiEmbeddingModel
+	}
+
+	return &GeminiProvider{client: client, model: model, embeddingModel: embeddingModel}, nil
+}
+
+// geminiModel returns GEMINI_MODEL, or defaultGeminiModel if it is not set
+func geminiModel() string {
+	if model := os.Getenv("GEMINI_MODEL"); model != "" {
+		return model
+	}
+	return defaultGeminiModel
+}
+
+func (p *GeminiProvider) Chat(ctx context.Context, config *Config, messages []Message) (*Response, error) {
+	if len(messages) == 0 {
+		return nil, errors.New("gemini: no message to reply to")
+	}
+
+	chatSession := p.generativeModel(config).StartChat()
+	chatSession.History = toGeminiContents(messages[:len(messages)-1])
+
+	resp, err := chatSession.SendMessage(ctx, toGeminiParts(messages[len(messages)-1].Parts)...)
+	if err != nil {
+		return nil, geminiError(err)
+	}
+
+	return geminiResponse(resp)
+}
+
+func (p *GeminiProvider) ChatStream(ctx context.Context, config *Config, messages []Message, onChunk func(chunk string)) (*Response, error) {
+	if len(messages) == 0 {
+		return nil, errors.New("gemini: no message to reply to")
+	}
+
+	chatSession := p.generativeModel(config).StartChat()
+	chatSession.History = toGeminiContents(messages[:len(messages)-1])
+
+	var text strings.Builder
+	iter := chatSession.SendMessageStream(ctx, toGeminiParts(messages[len(messages)-1].Parts)...)
+	for {
+		resp, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, geminiError(err)
+		}
+
+		for _, candidate := range resp.Candidates {
+			if candidate.Content == nil {
+				continue
+			}
+			for _, part := range candidate.Content.Parts {
+				if t, ok := part.(genai.Text); ok && t != "" {
+					text.WriteString(string(t))
+					onChunk(string(t))
+				}
+			}
+		}
+	}
+
+	if text.Len() == 0 {
+		return nil, errors.New("gemini: response has no text")
+	}
+
+	return &Response{Text: text.String()}, nil
+}
+
+func (p *GeminiProvider) Generate(ctx context.Context, config *Config, parts ...Part) (*Response, error) {
+	resp, err := p.generativeModel(config).GenerateContent(ctx, toGeminiParts(parts)...)
+	if err != nil {
+		return nil, geminiError(err)
+	}
+
+	return geminiResponse(resp)
+}
+
+func (p *GeminiProvider) CountTokens(ctx context.Context, messages []Message) (int, error) {
+	var parts []genai.Part
+	for _, content := range toGeminiContents(messages) {
+		parts = append(parts, content.Parts...)
+	}
+
+	resp, err := p.client.GenerativeModel(p.model).CountTokens(ctx, parts...)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(resp.TotalTokens), nil
+}
+
+func (p *GeminiProvider) EmbeddingModel() string {
+	return Gemini + "/" + p.embeddingModel
+}
+
+func (p *GeminiProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	model := p.client.EmbeddingModel(p.embeddingModel)
+
+	batch := model.NewBatch()
+	for _, text := range texts {
+		batch.AddContent(genai.Text(text))
+	}
+
+	resp, err := model.BatchEmbedContents(ctx, batch)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("gemini: %d embeddings for %d texts", len(resp.Embeddings), len(texts))
+	}
+
+	vectors := make([][]float32, len(texts))
+	for i, embedding := range resp.Embeddings {
+		vectors[i] = embedding.Values
+	}
+
+	return vectors, nil
+}
+
+func (p *GeminiProvider) Close() error {
+	return p.client.Close()
+}
+
+func (p *GeminiProvider) generativeModel(config *Config) *genai.GenerativeModel {
+	model := p.client.GenerativeModel(p.model)
+
+	model.SafetySettings = []*genai.SafetySetting{
+		{
+			Category:  genai.HarmCategoryDangerousContent,
+			Threshold: genai.HarmBlockOnlyHigh,
+		},
+		{
+			Category:  genai.HarmCategoryHarassment,
+			Threshold: genai.HarmBlockOnlyHigh,
+		},
+		{
+			Category:  genai.HarmCategoryHateSpeech,
+			Threshold: genai.HarmBlockOnlyHigh,
+		},
+		{
+			Category:  genai.HarmCategorySexuallyExplicit,
+			Threshold: genai.HarmBlockOnlyHigh,
+		},
+	}
+
+	if config == nil {
+		return model
+	}
+
+	model.Temperature = config.Temperature
+	model.TopP = config.TopP
+	model.TopK = config.TopK
+	if config.MaxOutputTokens > 0 {
+		model.SetMaxOutputTokens(config.MaxOutputTokens)
+	}
+	if config.JSON || config.Schema != nil {
+		model.ResponseMIMEType = "application/json"
+		model.ResponseSchema = toGeminiSchema(config.Schema)
+	}
+
+	if len(config.SystemInstruction) > 0 {
+		model.SystemInstruction = &genai.Content{}
+		for _, instruction := range config.SystemInstruction {
+			model.SystemInstruction.Parts = append(model.SystemInstruction.Parts, genai.Text(instruction))
+		}
+	}
+
+	return model
+}
+
+var geminiTypes = map[string]genai.Type{
+	"object":  genai.TypeObject,
+	"array":   genai.TypeArray,
+	"string":  genai.TypeString,
+	"integer": genai.TypeInteger,
+	"number":  genai.TypeNumber,
+	"boolean": genai.TypeBoolean,
+}
+
+func toGeminiSchema(schema *Schema) *genai.Schema {
+	if schema == nil {
+		return nil
+	}
+
+	converted := &genai.Schema{
+		Type:        geminiTypes[schema.Type],
+		Description: schema.Description,
+		Enum:        schema.Enum,
+		Items:       toGeminiSchema(schema.Items),
+		Required:    schema.Required,
+	}
+	if len(schema.Enum) > 0 {
+		converted.Format = "enum"
+	}
+	if len(schema.Properties) > 0 {
+		converted.Properties = make(map[string]*genai.Schema)
+		for name, property := range schema.Properties {
+			converted.Properties[name] = toGeminiSchema(property)
+		}
+	}
+
+	return converted
+}
+
+func toGeminiParts(parts []Part) []genai.Part {
+	converted := make([]genai.Part, 0, len(parts))
+	for _, part := range parts {
+		switch {
+		case part.inline():
+			converted = append(converted, genai.Blob{MIMEType: part.MIMEType, Data: part.Data})
+		case part.MIMEType != "":
+			converted = append(converted, genai.Text(part.placeholder()))
+		default:
+			converted = append(converted, genai.Text(part.Text))
+		}
+	}
+	return converted
+}
+
+func toGeminiContents(messages []Message) []*genai.Content {
+	contents := make([]*genai.Content, 0, len(messages))
+	for _, message := range messages {
+		contents = append(contents, &genai.Content{Role: message.Role, Parts: toGeminiParts(message.Parts)})
+	}
+	return contents
+}
+
+func geminiResponse(resp *genai.GenerateContentResponse) (*Response, error) {
+	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil {
+		return nil, errors.New("gemini: response has no candidates")
+	}
+
+	var text string
+	for _, part := range resp.Candidates[0].Content.Parts {
+		if t, ok := part.(genai.Text); ok {
+			text += string(t)
+		}
+	}
+
+	if text == "" {
+		log.Printf("Gemini response without text, finish reason %v", resp.Candidates[0].FinishReason)
+		return nil, errors.New("gemini: response has no text")
+	}
+
+	return &Response{Text: text}, nil
+}
@@ -3,97 +3,102 @@ package generative
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"log"
 	"os"
+	"sync"
 	"time"
-
-	"github.com/google/generative-ai-go/genai"
-	"google.golang.org/api/option"
 )
 
 var GenAiClient *GenAiManager
 
 type GenAiManager struct {
-	Client *genai.Client
-	Model  *genai.GenerativeModel
+	Provider      Provider                 // shared provider selected by LLM_PROVIDER
+	SharedQuota   *Quota                   // daily request budget of the shared provider
+	userProviders map[string]*userProvider // Map of user IDs to Gemini providers using their own key
+	mu            sync.Mutex
+}
+
+func NewGenAiManager(provider Provider) *GenAiManager {
+	return &GenAiManager{
+		Provider:      provider,
+		SharedQuota:   NewQuota(sharedDailyLimit()),
+		userProviders: make(map[string]*userProvider),
+	}
 }
 
 func Init() error {
 	ctx := context.Background()
 
-	client, err := genai.NewClient(ctx, option.WithAPIKey(os.Getenv("GEMINI_API_KEY")))
-
-	model := client.GenerativeModel(os.Getenv("GEMINI_MODEL"))
-
+	name := os.Getenv("LLM_PROVIDER")
+	provider, err := NewProvider(ctx, name)
 	if err != nil {
 		return err
 	}
 
-	GenAiClient = &GenAiManager{
-		Client: client,
-		Model:  model,
-	}
+	GenAiClient = NewGenAiManager(provider)
 
-	log.Println("gemini client created")
+	log.Printf("llm provider %q created", name)
 
 	return nil
 }
 
-func ResponseToString(resp *genai.GenerateContentResponse) (string, error) {
-	part := resp.Candidates[0].Content.Parts[0]
-
-	text, ok := part.(genai.Text)
-	if !ok {
-		return "", errors.New("unexpected part type")
+// GetUserModel returns the chat config of a user, with the date in the system instruction in user's location
+// and the persona and language of their preferences
+func GetUserModel(loc *time.Location, prefs Preferences) *Config {
+	var (
+		temperature float32 = 1
+		topP        float32 = 0.95
+		topK        int32   = 1
+	)
+
+	datetime := time.Now().In(loc).Format("2006-01-02")
+
+	instructions := append(prefs.personaInstructions(),
+		"Make use of conversation history to make the chat engaging. Assume conversation history is accurate",
+		fmt.Sprintf("Today is %s", datetime),
+	)
+	if prefs.Language != "" {
+		instructions = append(instructions, fmt.Sprintf("Always respond in %s, whatever language the user writes in.", prefs.Language))
 	}
-	return string(text), nil
-}
 
-func GetUserModel() *genai.GenerativeModel {
-	model := GenAiClient.Model
-	model.SetTemperature(1)
-	model.SetTopP(0.95)
-	model.SetTopK(1)
-	model.SetMaxOutputTokens(1024)
-
-	model.SafetySettings = []*genai.SafetySetting{
-		{
-			Category:  genai.HarmCategoryDangerousContent,
-			Threshold: genai.HarmBlockOnlyHigh,
-		},
-		{
-			Category:  genai.HarmCategoryHarassment,
-			Threshold: genai.HarmBlockOnlyHigh,
-		},
-		{
-			Category:  genai.HarmCategoryHateSpeech,
-			Threshold: genai.HarmBlockOnlyHigh,
-		},
-		{
-			Category:  genai.HarmCategorySexuallyExplicit,
-			Threshold: genai.HarmBlockOnlyHigh,
-		},
+	return &Config{
+		Temperature:       &temperature,
+		TopP:              &topP,
+		TopK:              &topK,
+		MaxOutputTokens:   1024,
+		SystemInstruction: instructions,
 	}
+}
 
-	datetime := time.Now().Format("2006-01-02")
+// summaryInput is a chat turn in the format of the summary examples
+type summaryInput struct {
+	Parts []string
+	Role  string
+}
 
-	model.SystemInstruction = &genai.Content{
-		Parts: []genai.Part{
-			genai.Text("You are a journaling chatbot called Journie, respond to user with empathy with a focus on how they are feeling."),
-			genai.Text("Do not over ask too many questions. If you have asked 3 questions in a row, ask user whether there is anything else they want to share for the day."),
-			genai.Text("Make use of conversation history to make the chat engaging. Assume conversation history is accurate"),
-			genai.Text(fmt.Sprintf("Today is %s", datetime)),
-		},
+// SummarizeSession summarizes chat history using provider, the shared provider if nil, in the style and language of prefs.
+// Invalid output is re-requested with the problems found, up to SUMMARY_MAX_ATTEMPTS times
+func SummarizeSession(provider Provider, history []Message, prefs Preferences) (*Summary, error) {
+	if provider == nil {
+		provider = GenAiClient.Provider
 	}
 
-	return model
-}
-
-func SummarizeSession(chatSession *genai.ChatSession) (*genai.GenerateContentResponse, error) {
+	input := make([]summaryInput, 0, len(history))
+	for _, message := range history {
+		turn := summaryInput{Role: message.Role}
+		for _, part := range message.Parts {
+			switch {
+			case part.IsPhoto():
+				turn.Parts = append(turn.Parts, "[photo]")
+			case part.MIMEType == "":
+				turn.Parts = append(turn.Parts, part.Text)
+			}
+		}
+		input = append(input, turn)
+	}
 
-	chatSessionInput, err := json.Marshal(chatSession.History)
+	chatSessionInput, err := json.Marshal(input)
 	if err != nil {
 		log.Println("Error marshalling ChatSession object:", err)
 		return nil, err
@@ -114,14 +119,47 @@ func SummarizeSession(chatSession *genai.ChatSession) (*genai.GenerateContentRes
 		"input: [{\"Parts\":[\"hi Journie\"],\"Role\":\"user\"},{\"Parts\":[\"Hi there! How are you feeling today? \\n\"],\"Role\":\"model\"},{\"Parts\":[\"nothing eventful today, but i witnessed an uncle clearing his throat and spitting REPEATEDLY while i was having my lunch... i really think people like him should be shamed and named publicly. I think it really reflects the quality of our society even though people like him is part of a minority.\"],\"Role\":\"user\"},{\"Parts\":[\"Ew, that sounds unpleasant. I understand why you would feel angry and disgusted by his behavior. It's perfectly normal to feel that way when someone acts so inconsiderately.  Is there anything else you would like to share about what happened? \\n\"],\"Role\":\"model\"},{\"Parts\":[\"nah, thats all, ill just head to bed after watching tiktok for abit\"],\"Role\":\"user\"},{\"Parts\":[\"Okay, I hope that watching Tiktok will help you relax and unwind after that unpleasant experience. Sleep well and have a good night!\\n\"],\"Role\":\"model\"}]",
 		"output: {\"summary\": \"You shared an unpleasant experience you witnessed with Journie. You expressed anger and disgust at an elderly man who repeatedly cleared his throat and spat in public while you were having lunch. Journie acknowledged your feelings and validated your reaction. You chose to end the conversation and relax by watching TikTok before going to bed.\",\"mood\": [\"anger\", \"disgust\"]}",
 		//end
-		"input: " + string(chatSessionInput),
-		"output: ",
+		"parts \"[photo]\" are photos the user shared, the text after it in the same parts is the caption. if there are photos, mention in the summary that the user shared them and what they showed, as described in the chat.",
 	}
+	examples = append(examples, prefs.summaryInstructions()...)
+	examples = append(examples, "input: "+string(chatSessionInput), "output: ")
 
-	parts := make([]genai.Part, len(examples))
+	parts := make([]Part, len(examples))
 	for i, examples := range examples {
-		parts[i] = genai.Text(examples)
+		parts[i] = Text(examples)
+	}
+
+	return requestValid(provider, &Config{Schema: SummarySchema}, parts, ParseSummary)
+}
+
+// requestValid requests JSON output from provider until parse accepts it.
+// Invalid output is re-requested with the problems found, up to SUMMARY_MAX_ATTEMPTS times
+func requestValid[T any](provider Provider, config *Config, parts []Part, parse func(text string) (*T, error)) (*T, error) {
+	ctx := context.Background()
+	messages := []Message{{Role: "user", Parts: parts}}
+	attempts := summaryAttempts()
+
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		resp, err := provider.Chat(ctx, config, messages)
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := parse(resp.Text)
+		if err == nil {
+			return result, nil
+		}
+
+		lastErr = err
+		log.Printf("Invalid summary on attempt %d of %d: %v", attempt, attempts, err)
+
+		// show the model its output and what is wrong with it
+		messages = append(messages,
+			Message{Role: "model", Parts: []Part{Text(resp.Text)}},
+			Message{Role: "user", Parts: []Part{Text(fmt.Sprintf("That output is invalid: %v. Respond again with only the corrected JSON object.", err))}},
+		)
 	}
 
-	return GenAiClient.Model.GenerateContent(context.Background(), parts...)
+	return nil, fmt.Errorf("%w after %d attempts: %v", ErrInvalidSummary, attempts, lastErr)
 }
@@ -0,0 +1,66 @@
+package generative
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// httpClient used by providers speaking plain HTTP
+var httpClient = &http.Client{Timeout: 2 * time.Minute}
+
+// APIError is a non 2xx response from an HTTP provider
+type APIError struct {
+	Provider   string
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s: status %d: %s", e.Provider, e.StatusCode, e.Body)
+}
+
+// postJSON posts in as JSON to url and decodes the response into out
+func postJSON(ctx context.Context, provider string, url string, headers map[string]string, in any, out any) error {
+	resp, err := post(ctx, provider, url, headers, in)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// post posts in as JSON to url, returning the response for the caller to read and close
+func post(ctx context.Context, provider string, url string, headers map[string]string, in any) (*http.Response, error) {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, &APIError{Provider: provider, StatusCode: resp.StatusCode, Body: string(data)}
+	}
+
+	return resp, nil
+}
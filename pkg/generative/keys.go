@@ -0,0 +1,252 @@
+package generative
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/generative-ai-go/genai"
+	"github.com/googleapis/gax-go/v2/apierror"
+	"google.golang.org/api/option"
+)
+
+// defaultSharedDailyLimit of requests on the shared key per day, override with SHARED_KEY_DAILY_LIMIT
+const defaultSharedDailyLimit = 1000
+
+// geminiKeyPattern matches Google API keys as issued by AI Studio
+var geminiKeyPattern = regexp.MustCompile(`AIza[0-9A-Za-z_\-]{35}`)
+
+// errProviderClosed is returned by calls to the provider of a user's replaced or removed key once it is closed
+var errProviderClosed = errors.New("gemini: provider of a replaced key is closed")
+
+// newUserProvider creates the provider of a user's own key
+var newUserProvider = func(ctx context.Context, apiKey string) (Provider, error) {
+	return NewGeminiProvider(ctx, apiKey, geminiModel())
+}
+
+// userProvider is the provider of a user's own key. Once the key is replaced or removed it is retired,
+// and closed when no call is in flight, as sessions started with it may still be replying
+type userProvider struct {
+	Provider
+	keyHash [32]byte
+	calls   int
+	retired bool
+	closed  bool
+	mu      sync.Mutex
+}
+
+func (p *userProvider) acquire() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return errProviderClosed
+	}
+	p.calls++
+	return nil
+}
+
+func (p *userProvider) release() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.calls--
+	p.closeIfIdle()
+}
+
+// retire closes the provider once calls in flight are done
+func (p *userProvider) retire() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.retired = true
+	p.closeIfIdle()
+}
+
+// closeIfIdle closes the provider if it is retired and no call is in flight. Caller must hold mu
+func (p *userProvider) closeIfIdle() {
+	if !p.retired || p.calls > 0 || p.closed {
+		return
+	}
+
+	p.closed = true
+	if err := p.Provider.Close(); err != nil {
+		log.Printf("Error closing retired gemini provider: %v", err)
+	}
+}
+
+func (p *userProvider) Chat(ctx context.Context, config *Config, messages []Message) (*Response, error) {
+	if err := p.acquire(); err != nil {
+		return nil, err
+	}
+	defer p.release()
+
+	return p.Provider.Chat(ctx, config, messages)
+}
+
+func (p *userProvider) ChatStream(ctx context.Context, config *Config, messages []Message, onChunk func(chunk string)) (*Response, error) {
+	if err := p.acquire(); err != nil {
+		return nil, err
+	}
+	defer p.release()
+
+	return p.Provider.ChatStream(ctx, config, messages, onChunk)
+}
+
+func (p *userProvider) Generate(ctx context.Context, config *Config, parts ...Part) (*Response, error) {
+	if err := p.acquire(); err != nil {
+		return nil, err
+	}
+	defer p.release()
+
+	return p.Provider.Generate(ctx, config, parts...)
+}
+
+func (p *userProvider) CountTokens(ctx context.Context, messages []Message) (int, error) {
+	if err := p.acquire(); err != nil {
+		return 0, err
+	}
+	defer p.release()
+
+	return p.Provider.CountTokens(ctx, messages)
+}
+
+func (p *userProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if err := p.acquire(); err != nil {
+		return nil, err
+	}
+	defer p.release()
+
+	return p.Provider.Embed(ctx, texts)
+}
+
+// Close retires the provider, it is shared by the sessions of the user
+func (p *userProvider) Close() error {
+	p.retire()
+	return nil
+}
+
+// FindGeminiKey returns the first Gemini API key in text, empty if there is none
+func FindGeminiKey(text string) string {
+	return geminiKeyPattern.FindString(text)
+}
+
+// ValidateKey checks apiKey is accepted by Gemini by counting tokens of a short prompt
+func ValidateKey(ctx context.Context, apiKey string) error {
+	client, err := genai.NewClient(ctx, option.WithAPIKey(apiKey))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	_, err = client.GenerativeModel(geminiModel()).CountTokens(ctx, genai.Text("hi"))
+	return err
+}
+
+// IsInvalidKeyError reports whether err was caused by Gemini rejecting the API key itself
+func IsInvalidKeyError(err error) bool {
+	var apiErr *apierror.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	return apiErr.Reason() == "API_KEY_INVALID"
+}
+
+// IsForbiddenError reports whether Gemini refused a request with a valid API key,
+// e.g. because of the region, billing or the API being disabled for the key's project
+func IsForbiddenError(err error) bool {
+	var apiErr *apierror.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	return apiErr.HTTPCode() == http.StatusForbidden && apiErr.Reason() != "API_KEY_INVALID"
+}
+
+// UserProvider returns the cached Gemini provider of user, creating it if apiKey changed.
+// Users' own keys are always Gemini keys, whichever provider is shared
+func (m *GenAiManager) UserProvider(userId string, apiKey string) (Provider, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keyHash := sha256.Sum256([]byte(apiKey))
+	if cached, ok := m.userProviders[userId]; ok {
+		if cached.keyHash == keyHash {
+			return cached, nil
+		}
+		cached.retire()
+		delete(m.userProviders, userId)
+	}
+
+	provider, err := newUserProvider(context.Background(), apiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	cached := &userProvider{Provider: provider, keyHash: keyHash}
+	m.userProviders[userId] = cached
+	log.Printf("gemini provider created for user %s", userId)
+
+	return cached, nil
+}
+
+// RemoveUserProvider forgets the cached provider of user, closing it once calls in flight are done
+func (m *GenAiManager) RemoveUserProvider(userId string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if cached, ok := m.userProviders[userId]; ok {
+		cached.retire()
+		delete(m.userProviders, userId)
+	}
+}
+
+func sharedDailyLimit() int {
+	if limit, err := strconv.Atoi(os.Getenv("SHARED_KEY_DAILY_LIMIT")); err == nil {
+		return limit
+	}
+	return defaultSharedDailyLimit
+}
+
+// Quota is a request budget that resets every UTC day, limit <= 0 means unlimited
+type Quota struct {
+	limit int
+	day   string
+	used  int
+	mu    sync.Mutex
+}
+
+func NewQuota(limit int) *Quota {
+	return &Quota{limit: limit}
+}
+
+// Allow consumes one request, returning false if the day's budget is spent
+func (q *Quota) Allow(now time.Time) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.limit <= 0 {
+		return true
+	}
+
+	day := now.UTC().Format("2006-01-02")
+	if day != q.day {
+		q.day = day
+		q.used = 0
+	}
+
+	if q.used >= q.limit {
+		return false
+	}
+	q.used++
+
+	return true
+}
@@ -0,0 +1,191 @@
+package generative
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	defaultOllamaHost           = "http://localhost:11434"
+	defaultOllamaEmbeddingModel = "nomic-embed-text"
+)
+
+// OllamaProvider calls a local Ollama server, so journaling works fully offline
+type OllamaProvider struct {
+	host           string
+	model          string
+	embeddingModel string
+}
+
+func NewOllamaProvider(host string, model string) *OllamaProvider {
+	if host == "" {
+		host = defaultOllamaHost
+	}
+
+	embeddingModel := os.Getenv("OLLAMA_EMBEDDING_MODEL")
+	if embeddingModel == "" {
+		embeddingModel = defaultOllamaEmbeddingModel
+	}
+
+	return &OllamaProvider{host: strings.TrimSuffix(host, "/"), model: model, embeddingModel: embeddingModel}
+}
+
+type ollamaMessage struct {
+	Role    string   `json:"role"`
+	Content string   `json:"content"`
+	Images  [][]byte `json:"images,omitempty"` // base64 encoded by encoding/json
+}
+
+type ollamaRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Stream   bool            `json:"stream"`
+	Format   any             `json:"format,omitempty"` // "json" or a JSON schema
+	Options  map[string]any  `json:"options,omitempty"`
+}
+
+type ollamaResponse struct {
+	Message struct {
+		Content string `json:"content"`
+	} `json:"message"`
+	Done  bool   `json:"done"`
+	Error string `json:"error"`
+}
+
+func (p *OllamaProvider) Chat(ctx context.Context, config *Config, messages []Message) (*Response, error) {
+	var resp ollamaResponse
+	if err := postJSON(ctx, Ollama, p.host+"/api/chat", nil, p.request(config, messages), &resp); err != nil {
+		return nil, err
+	}
+
+	if resp.Message.Content == "" {
+		return nil, errors.New("ollama: response has no content")
+	}
+
+	return &Response{Text: resp.Message.Content}, nil
+}
+
+func (p *OllamaProvider) ChatStream(ctx context.Context, config *Config, messages []Message, onChunk func(chunk string)) (*Response, error) {
+	req := p.request(config, messages)
+	req.Stream = true
+
+	resp, err := post(ctx, Ollama, p.host+"/api/chat", nil, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// streamed as one JSON object per line, the last one has done set
+	var text strings.Builder
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var chunk ollamaResponse
+		if err := decoder.Decode(&chunk); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("ollama: malformed stream chunk: %w", err)
+		}
+
+		if chunk.Error != "" {
+			return nil, fmt.Errorf("ollama: %s", chunk.Error)
+		}
+		if chunk.Message.Content != "" {
+			text.WriteString(chunk.Message.Content)
+			onChunk(chunk.Message.Content)
+		}
+		if chunk.Done {
+			break
+		}
+	}
+
+	if text.Len() == 0 {
+		return nil, errors.New("ollama: response has no content")
+	}
+
+	return &Response{Text: text.String()}, nil
+}
+
+func (p *OllamaProvider) request(config *Config, messages []Message) ollamaRequest {
+	req := ollamaRequest{Model: p.model, Options: map[string]any{}}
+	if config != nil {
+		if len(config.SystemInstruction) > 0 {
+			req.Messages = append(req.Messages, ollamaMessage{Role: "system", Content: strings.Join(config.SystemInstruction, "\n")})
+		}
+		if config.Temperature != nil {
+			req.Options["temperature"] = *config.Temperature
+		}
+		if config.TopP != nil {
+			req.Options["top_p"] = *config.TopP
+		}
+		if config.TopK != nil {
+			req.Options["top_k"] = *config.TopK
+		}
+		if config.MaxOutputTokens > 0 {
+			req.Options["num_predict"] = config.MaxOutputTokens
+		}
+		switch {
+		case config.Schema != nil:
+			req.Format = config.Schema
+		case config.JSON:
+			req.Format = "json"
+		}
+	}
+
+	for _, message := range messages {
+		req.Messages = append(req.Messages, toOllamaMessage(message))
+	}
+
+	return req
+}
+
+func (p *OllamaProvider) Generate(ctx context.Context, config *Config, parts ...Part) (*Response, error) {
+	return p.Chat(ctx, config, []Message{{Role: "user", Parts: parts}})
+}
+
+func (p *OllamaProvider) CountTokens(ctx context.Context, messages []Message) (int, error) {
+	return EstimateTokens(messages), nil
+}
+
+func (p *OllamaProvider) EmbeddingModel() string {
+	return Ollama + "/" + p.embeddingModel
+}
+
+func (p *OllamaProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	var resp struct {
+		Embeddings [][]float32 `json:"embeddings"`
+	}
+	req := map[string]any{"model": p.embeddingModel, "input": texts}
+	if err := postJSON(ctx, Ollama, p.host+"/api/embed", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("ollama: %d embeddings for %d texts", len(resp.Embeddings), len(texts))
+	}
+
+	return resp.Embeddings, nil
+}
+
+func (p *OllamaProvider) Close() error {
+	return nil
+}
+
+func toOllamaMessage(message Message) ollamaMessage {
+	role := message.Role
+	if role == "model" {
+		role = "assistant"
+	}
+
+	converted := ollamaMessage{Role: role, Content: messageText(message)}
+	for _, part := range message.Parts {
+		if part.inline() && part.IsPhoto() {
+			converted.Images = append(converted.Images, part.Data)
+		}
+	}
+
+	return converted
+}
@@ -0,0 +1,265 @@
+package generative
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const (
+	defaultOpenAIBaseURL        = "https://api.openai.com/v1"
+	defaultOpenAIEmbeddingModel = "text-embedding-3-small"
+)
+
+// OpenAIProvider calls any OpenAI compatible chat completions endpoint,
+// e.g. OpenAI, Groq, OpenRouter, vLLM or LM Studio
+type OpenAIProvider struct {
+	baseURL        string
+	apiKey         string
+	model          string
+	embeddingModel string
+}
+
+func NewOpenAIProvider(baseURL string, apiKey string, model string) *OpenAIProvider {
+	if baseURL == "" {
+		baseURL = defaultOpenAIBaseURL
+	}
+
+	embeddingModel := os.Getenv("OPENAI_EMBEDDING_MODEL")
+	if embeddingModel == "" {
+		embeddingModel = defaultOpenAIEmbeddingModel
+	}
+
+	return &OpenAIProvider{baseURL: strings.TrimSuffix(baseURL, "/"), apiKey: apiKey, model: model, embeddingModel: embeddingModel}
+}
+
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content any    `json:"content"` // string, or []openAIContent with images
+}
+
+type openAIContent struct {
+	Type     string          `json:"type"`
+	Text     string          `json:"text,omitempty"`
+	ImageURL *openAIImageURL `json:"image_url,omitempty"`
+}
+
+type openAIImageURL struct {
+	URL string `json:"url"`
+}
+
+type openAIRequest struct {
+	Model          string          `json:"model"`
+	Messages       []openAIMessage `json:"messages"`
+	Temperature    *float32        `json:"temperature,omitempty"`
+	TopP           *float32        `json:"top_p,omitempty"`
+	MaxTokens      int32           `json:"max_tokens,omitempty"`
+	ResponseFormat map[string]any  `json:"response_format,omitempty"`
+	Stream         bool            `json:"stream,omitempty"`
+}
+
+type openAIResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+}
+
+func (p *OpenAIProvider) Chat(ctx context.Context, config *Config, messages []Message) (*Response, error) {
+	var resp openAIResponse
+	if err := postJSON(ctx, OpenAI, p.baseURL+"/chat/completions", p.headers(), p.request(config, messages), &resp); err != nil {
+		return nil, openAIError(err)
+	}
+
+	if len(resp.Choices) > 0 && resp.Choices[0].FinishReason == "content_filter" {
+		return nil, &ResponseBlockedError{Reason: "content_filter"}
+	}
+
+	if len(resp.Choices) == 0 || resp.Choices[0].Message.Content == "" {
+		return nil, errors.New("openai: response has no content")
+	}
+
+	return &Response{Text: resp.Choices[0].Message.Content}, nil
+}
+
+// openAIChunk is a server-sent event of a streamed chat completion
+type openAIChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+}
+
+func (p *OpenAIProvider) ChatStream(ctx context.Context, config *Config, messages []Message, onChunk func(chunk string)) (*Response, error) {
+	req := p.request(config, messages)
+	req.Stream = true
+
+	resp, err := post(ctx, OpenAI, p.baseURL+"/chat/completions", p.headers(), req)
+	if err != nil {
+		return nil, openAIError(err)
+	}
+	defer resp.Body.Close()
+
+	var text strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk openAIChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return nil, fmt.Errorf("openai: malformed stream chunk: %w", err)
+		}
+
+		for _, choice := range chunk.Choices {
+			if choice.FinishReason == "content_filter" {
+				return nil, &ResponseBlockedError{Reason: "content_filter"}
+			}
+			if choice.Delta.Content != "" {
+				text.WriteString(choice.Delta.Content)
+				onChunk(choice.Delta.Content)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if text.Len() == 0 {
+		return nil, errors.New("openai: response has no content")
+	}
+
+	return &Response{Text: text.String()}, nil
+}
+
+func (p *OpenAIProvider) request(config *Config, messages []Message) openAIRequest {
+	req := openAIRequest{Model: p.model}
+	if config != nil {
+		if len(config.SystemInstruction) > 0 {
+			req.Messages = append(req.Messages, openAIMessage{Role: "system", Content: strings.Join(config.SystemInstruction, "\n")})
+		}
+		req.Temperature = config.Temperature
+		req.TopP = config.TopP
+		req.MaxTokens = config.MaxOutputTokens
+		switch {
+		case config.Schema != nil:
+			req.ResponseFormat = map[string]any{"type": "json_schema", "json_schema": map[string]any{"name": "response", "schema": config.Schema}}
+		case config.JSON:
+			req.ResponseFormat = map[string]any{"type": "json_object"}
+		}
+	}
+
+	for _, message := range messages {
+		req.Messages = append(req.Messages, toOpenAIMessage(message))
+	}
+
+	return req
+}
+
+func (p *OpenAIProvider) headers() map[string]string {
+	headers := map[string]string{}
+	if p.apiKey != "" {
+		headers["Authorization"] = "Bearer " + p.apiKey
+	}
+	return headers
+}
+
+// openAIError converts prompts refused by content filters into PromptBlockedError,
+// azure style endpoints refuse them with a content_filter error code
+func openAIError(err error) error {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest && strings.Contains(apiErr.Body, "content_filter") {
+		return &PromptBlockedError{Reason: "content_filter", Err: err}
+	}
+	return err
+}
+
+func (p *OpenAIProvider) Generate(ctx context.Context, config *Config, parts ...Part) (*Response, error) {
+	return p.Chat(ctx, config, []Message{{Role: "user", Parts: parts}})
+}
+
+func (p *OpenAIProvider) CountTokens(ctx context.Context, messages []Message) (int, error) {
+	return EstimateTokens(messages), nil
+}
+
+func (p *OpenAIProvider) EmbeddingModel() string {
+	return OpenAI + "/" + p.embeddingModel
+}
+
+func (p *OpenAIProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	var resp struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	req := map[string]any{"model": p.embeddingModel, "input": texts}
+	if err := postJSON(ctx, OpenAI, p.baseURL+"/embeddings", p.headers(), req, &resp); err != nil {
+		return nil, err
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, data := range resp.Data {
+		if data.Index < 0 || data.Index >= len(texts) {
+			return nil, fmt.Errorf("openai: embedding index %d out of range", data.Index)
+		}
+		vectors[data.Index] = data.Embedding
+	}
+	for i, vector := range vectors {
+		if vector == nil {
+			return nil, fmt.Errorf("openai: no embedding for text %d", i)
+		}
+	}
+
+	return vectors, nil
+}
+
+func (p *OpenAIProvider) Close() error {
+	return nil
+}
+
+func toOpenAIMessage(message Message) openAIMessage {
+	role := message.Role
+	if role == "model" {
+		role = "assistant"
+	}
+
+	hasData := false
+	for _, part := range message.Parts {
+		hasData = hasData || (part.inline() && part.IsPhoto())
+	}
+	if !hasData {
+		return openAIMessage{Role: role, Content: messageText(message)}
+	}
+
+	var content []openAIContent
+	for _, part := range message.Parts {
+		switch {
+		case part.MIMEType == "":
+			content = append(content, openAIContent{Type: "text", Text: part.Text})
+		case !part.inline():
+			content = append(content, openAIContent{Type: "text", Text: part.placeholder()})
+		case part.IsPhoto():
+			url := "data:" + part.MIMEType + ";base64," + base64.StdEncoding.EncodeToString(part.Data)
+			content = append(content, openAIContent{Type: "image_url", ImageURL: &openAIImageURL{URL: url}})
+		}
+	}
+
+	return openAIMessage{Role: role, Content: content}
+}
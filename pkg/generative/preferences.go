@@ -0,0 +1,41 @@
+package generative
+
+import (
+	"fmt"
+	"journie/pkg/personas"
+)
+
+// Preferences tailor replies and summaries to a user, from their settings
+type Preferences struct {
+	Language     string // name of the language to write in, e.g. English, empty to follow the user
+	Persona      string // key of a persona, the default if unknown
+	SummaryStyle string // key of summaryStyleInstructions, the default if unknown
+}
+
+// summaryStyleInstructions are added to the summary prompt, by summary style
+var summaryStyleInstructions = map[string]string{
+	"paragraph": "",
+	"bullets":   "write the field \"summary\" as short bullet points, each on its own line starting with \"- \".",
+	"brief":     "write the field \"summary\" in one or two sentences.",
+}
+
+// personaInstructions open the system instruction of a chat, a copy to append to
+func (p Preferences) personaInstructions() []string {
+	persona, ok := personas.Get(p.Persona)
+	if !ok {
+		persona = personas.Default()
+	}
+	return append([]string(nil), persona.Instructions...)
+}
+
+// summaryInstructions returns the prompts for the style and language of a summary, if any
+func (p Preferences) summaryInstructions() []string {
+	var instructions []string
+	if style := summaryStyleInstructions[p.SummaryStyle]; style != "" {
+		instructions = append(instructions, style)
+	}
+	if p.Language != "" {
+		instructions = append(instructions, fmt.Sprintf("write the field \"summary\" in %s. moods stay in English, as listed.", p.Language))
+	}
+	return instructions
+}
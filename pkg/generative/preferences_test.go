@@ -0,0 +1,26 @@
+package generative_test
+
+import (
+	"journie/pkg/generative"
+	"journie/pkg/personas"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestGetUserModelPersona calls generative.GetUserModel with personas, checking the system
+// instruction opens with the instructions of the persona, of the default one if unknown.
+func TestGetUserModelPersona(t *testing.T) {
+	for persona, want := range map[string]string{"socratic": "socratic", "removed": personas.Default().Key} {
+		instructions := generative.GetUserModel(time.UTC, generative.Preferences{Persona: persona, Language: "Español"}).SystemInstruction
+
+		p, _ := personas.Get(want)
+		if got := instructions[:len(p.Instructions)]; !reflect.DeepEqual(got, p.Instructions) {
+			t.Errorf(`GetUserModel(%q) instructions start with %q, want those of %s`, persona, got, want)
+		}
+		if last := instructions[len(instructions)-1]; !strings.Contains(last, "Español") {
+			t.Errorf(`GetUserModel(%q) instructions end with %q, want the language`, persona, last)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package generative
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	Gemini string = "gemini"
+	OpenAI string = "openai"
+	Ollama string = "ollama"
+	Fake   string = "fake"
+)
+
+// Provider is a large language model backend, selected with LLM_PROVIDER env
+type Provider interface {
+	// Chat replies to the last of messages, earlier messages are the conversation so far
+	Chat(ctx context.Context, config *Config, messages []Message) (*Response, error)
+	// ChatStream is Chat calling onChunk with each piece of text as it is generated
+	ChatStream(ctx context.Context, config *Config, messages []Message, onChunk func(chunk string)) (*Response, error)
+	// Generate replies to a single prompt made of parts, without conversation
+	Generate(ctx context.Context, config *Config, parts ...Part) (*Response, error)
+	// CountTokens counts tokens of messages, estimated by providers without a tokenizer endpoint
+	CountTokens(ctx context.Context, messages []Message) (int, error)
+
+	// Embed returns an embedding vector for each of texts, for semantic search of journal entries
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+	// EmbeddingModel names the model of Embed, e.g. gemini/text-embedding-004. Vectors of different models cannot be compared
+	EmbeddingModel() string
+	Close() error
+}
+
+// Message is one turn of a conversation, Role is "user" or "model"
+type Message struct {
+	Role  string
+	Parts []Part
+}
+
+// Part is text, or inline data such as an image when MIMEType is set.
+// Ref identifies where inline data is kept, e.g. a Telegram file id, so history can be stored without Data
+type Part struct {
+	Text     string
+	MIMEType string
+	Data     []byte
+	Ref      string
+}
+
+// Config of a request, zero values leave the provider's defaults
+type Config struct {
+	SystemInstruction []string
+	Temperature       *float32
+	TopP              *float32
+	TopK              *int32
+	MaxOutputTokens   int32
+	JSON              bool    // respond with a JSON object
+	Schema            *Schema // constrain the JSON object, implies JSON
+}
+
+// Schema is the subset of JSON schema supported by all providers for structured output
+type Schema struct {
+	Type        string             `json:"type"` // object, array, string, integer, number or boolean
+	Description string             `json:"description,omitempty"`
+	Enum        []string           `json:"enum,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+}
+
+type Response struct {
+	Text string
+}
+
+func Text(text string) Part {
+	return Part{Text: text}
+}
+
+// IsPhoto reports whether part is an image, with or without its data
+func (p Part) IsPhoto() bool {
+	return strings.HasPrefix(p.MIMEType, "image/")
+}
+
+// inline reports whether part carries data to send, parts only holding a Ref are sent as placeholder text
+func (p Part) inline() bool {
+	return p.MIMEType != "" && len(p.Data) > 0
+}
+
+// placeholder describes inline data that is no longer held, e.g. a photo of a session rehydrated from storage
+func (p Part) placeholder() string {
+	if p.IsPhoto() {
+		return "[photo shared by the user earlier]"
+	}
+	return "[attachment shared by the user earlier]"
+}
+
+// NewProvider creates the provider called name from its env config, defaulting to gemini
+func NewProvider(ctx context.Context, name string) (Provider, error) {
+	switch strings.ToLower(name) {
+	case "", Gemini:
+		return NewGeminiProvider(ctx, os.Getenv("GEMINI_API_KEY"), os.Getenv("GEMINI_MODEL"))
+	case OpenAI:
+		return NewOpenAIProvider(os.Getenv("OPENAI_BASE_URL"), os.Getenv("OPENAI_API_KEY"), os.Getenv("OPENAI_MODEL")), nil
+	case Ollama:
+		return NewOllamaProvider(os.Getenv("OLLAMA_HOST"), os.Getenv("OLLAMA_MODEL")), nil
+	case Fake:
+		return NewFakeProvider(), nil
+	default:
+		return nil, fmt.Errorf("unsupported llm provider %q", name)
+	}
+}
+
+// ChatSession is a conversation with a provider, History grows with every successful turn
+type ChatSession struct {
+	Provider Provider
+	Config   *Config
+	History  []Message
+}
+
+func StartChat(provider Provider, config *Config) *ChatSession {
+	return &ChatSession{Provider: provider, Config: config}
+}
+
+// SendMessage sends parts as the user and appends both turns to History on success.
+// On error, e.g. a blocked prompt or response, History is left at its last good state
+func (cs *ChatSession) SendMessage(ctx context.Context, parts ...Part) (*Response, error) {
+	message := Message{Role: "user", Parts: parts}
+
+	resp, err := cs.Provider.Chat(ctx, cs.Config, append(cs.History[:len(cs.History):len(cs.History)], message))
+	if err != nil {
+		return nil, err
+	}
+
+	cs.History = append(cs.History, message, Message{Role: "model", Parts: []Part{Text(resp.Text)}})
+
+	return resp, nil
+}
+
+// SendMessageStream is SendMessage calling onChunk as the reply is generated.
+// Only the complete reply is committed to History, a stream failing midway leaves History unchanged
+func (cs *ChatSession) SendMessageStream(ctx context.Context, onChunk func(chunk string), parts ...Part) (*Response, error) {
+	message := Message{Role: "user", Parts: parts}
+
+	resp, err := cs.Provider.ChatStream(ctx, cs.Config, append(cs.History[:len(cs.History):len(cs.History)], message), onChunk)
+	if err != nil {
+		return nil, err
+	}
+
+	cs.History = append(cs.History, message, Message{Role: "model", Parts: []Part{Text(resp.Text)}})
+
+	return resp, nil
+}
+
+// messageText joins text parts of message, dropping inline data and describing data no longer held
+func messageText(message Message) string {
+	var texts []string
+	for _, part := range message.Parts {
+		switch {
+		case part.MIMEType == "":
+			texts = append(texts, part.Text)
+		case !part.inline():
+			texts = append(texts, part.placeholder())
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// EstimateTokens approximates token count of messages at ~4 characters per token
+func EstimateTokens(messages []Message) int {
+	chars := 0
+	for _, message := range messages {
+		chars += len(messageText(message))
+	}
+	return (chars + 3) / 4
+}
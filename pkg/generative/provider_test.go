@@ -0,0 +1,219 @@
+package generative_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"journie/pkg/generative"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// TestChatSessionHistory sends messages through a fake provider, checking
+// turns are appended on success and left alone on error.
+func TestChatSessionHistory(t *testing.T) {
+	fake := generative.NewFakeProvider("Hi there!")
+	fake.Script = append(fake.Script, generative.FakeReply{Err: errors.New("unavailable")})
+
+	cs := generative.StartChat(fake, &generative.Config{})
+	if resp, err := cs.SendMessage(context.Background(), generative.Text("hi journie")); err != nil || resp.Text != "Hi there!" {
+		t.Fatalf(`SendMessage() = %v, %v, want "Hi there!", nil`, resp, err)
+	}
+	if _, err := cs.SendMessage(context.Background(), generative.Text("are you there?")); err == nil {
+		t.Fatalf(`SendMessage() = nil error, want scripted error`)
+	}
+
+	if len(cs.History) != 2 || cs.History[1].Role != "model" {
+		t.Fatalf(`History = %v, want only the successful turn`, cs.History)
+	}
+	if got := len(fake.Calls[1].Messages); got != 3 {
+		t.Fatalf(`messages sent on second turn = %d, want 3`, got)
+	}
+}
+
+// TestOpenAIProvider calls a stub chat completions endpoint, checking roles,
+// the system instruction and JSON mode are sent and the reply is parsed.
+func TestOpenAIProvider(t *testing.T) {
+	var got map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" || r.Header.Get("Authorization") != "Bearer sk-test" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "{\"mood\": [\"happy\"]}"}}]}`))
+	}))
+	defer server.Close()
+
+	provider := generative.NewOpenAIProvider(server.URL+"/v1/", "sk-test", "gpt-4o-mini")
+	resp, err := provider.Chat(context.Background(), &generative.Config{SystemInstruction: []string{"You are Journie."}, JSON: true}, []generative.Message{
+		{Role: "user", Parts: []generative.Part{generative.Text("hi")}},
+		{Role: "model", Parts: []generative.Part{generative.Text("hello")}},
+		{Role: "user", Parts: []generative.Part{generative.Text("how was my day?")}},
+	})
+	if err != nil || resp.Text != `{"mood": ["happy"]}` {
+		t.Fatalf(`Chat() = %v, %v, want stubbed content`, resp, err)
+	}
+
+	messages := got["messages"].([]any)
+	if len(messages) != 4 || messages[0].(map[string]any)["role"] != "system" || messages[2].(map[string]any)["role"] != "assistant" {
+		t.Fatalf(`request messages = %v, want system, user, assistant, user`, messages)
+	}
+	if got["response_format"].(map[string]any)["type"] != "json_object" {
+		t.Fatalf(`request response_format = %v, want json_object`, got["response_format"])
+	}
+}
+
+// TestOllamaProvider calls a stub Ollama server, checking images are attached
+// and non 2xx responses surface as APIError.
+func TestOllamaProvider(t *testing.T) {
+	var got map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		if got["model"] != "llama3" {
+			http.Error(w, "model not found", http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"message": {"role": "assistant", "content": "What a lovely photo."}}`))
+	}))
+	defer server.Close()
+
+	parts := []generative.Part{generative.Text("my cat"), {MIMEType: "image/jpeg", Data: []byte{0xff, 0xd8}}}
+
+	resp, err := generative.NewOllamaProvider(server.URL, "llama3").Generate(context.Background(), nil, parts...)
+	if err != nil || resp.Text != "What a lovely photo." {
+		t.Fatalf(`Generate() = %v, %v, want stubbed content`, resp, err)
+	}
+	if images := got["messages"].([]any)[0].(map[string]any)["images"].([]any); len(images) != 1 {
+		t.Fatalf(`request images = %v, want 1 image`, images)
+	}
+
+	var apiErr *generative.APIError
+	_, err = generative.NewOllamaProvider(server.URL, "missing").Generate(context.Background(), nil, parts...)
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Fatalf(`Generate() with missing model = %v, want APIError 404`, err)
+	}
+}
+
+// TestChatSessionBlocked sends messages blocked for safety through a fake provider,
+// checking the typed errors surface and history stays at its last good state.
+func TestChatSessionBlocked(t *testing.T) {
+	fake := generative.NewFakeProvider("Hi there!")
+	fake.Script = append(fake.Script,
+		generative.FakeReply{Err: &generative.PromptBlockedError{Reason: "SAFETY"}},
+		generative.FakeReply{Err: &generative.ResponseBlockedError{Reason: "FinishReasonSafety"}},
+		generative.FakeReply{Text: "I'm listening."},
+	)
+
+	cs := generative.StartChat(fake, nil)
+	cs.SendMessage(context.Background(), generative.Text("hi journie"))
+
+	var promptErr *generative.PromptBlockedError
+	if _, err := cs.SendMessage(context.Background(), generative.Text("blocked prompt")); !errors.As(err, &promptErr) {
+		t.Fatalf(`SendMessage() = %v, want PromptBlockedError`, err)
+	}
+	var responseErr *generative.ResponseBlockedError
+	if _, err := cs.SendMessage(context.Background(), generative.Text("blocked response")); !errors.As(err, &responseErr) || !generative.IsBlocked(err) {
+		t.Fatalf(`SendMessage() = %v, want ResponseBlockedError`, err)
+	}
+	if len(cs.History) != 2 {
+		t.Fatalf(`len(History) after blocked turns = %d, want 2`, len(cs.History))
+	}
+
+	cs.SendMessage(context.Background(), generative.Text("let me put it another way"))
+	if got := fake.Calls[3].Messages; len(got) != 3 || got[2].Parts[0].Text != "let me put it another way" {
+		t.Fatalf(`messages after blocked turns = %v, want only good turns and the new message`, got)
+	}
+}
+
+// TestOpenAIContentFilter calls a stub endpoint filtering the response,
+// checking it surfaces as ResponseBlockedError.
+func TestOpenAIContentFilter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"choices": [{"message": {"content": ""}, "finish_reason": "content_filter"}]}`))
+	}))
+	defer server.Close()
+
+	var responseErr *generative.ResponseBlockedError
+	_, err := generative.NewOpenAIProvider(server.URL, "", "gpt-4o-mini").Generate(context.Background(), nil, generative.Text("hi"))
+	if !errors.As(err, &responseErr) {
+		t.Fatalf(`Generate() = %v, want ResponseBlockedError`, err)
+	}
+}
+
+// TestChatSessionStream streams replies through stub OpenAI and Ollama servers,
+// checking chunks arrive in order and the complete reply is committed to history.
+func TestChatSessionStream(t *testing.T) {
+	openai := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: {\"choices\": [{\"delta\": {\"role\": \"assistant\"}}]}\n\n"))
+		w.Write([]byte("data: {\"choices\": [{\"delta\": {\"content\": \"How are \"}}]}\n\n"))
+		w.Write([]byte("data: {\"choices\": [{\"delta\": {\"content\": \"you feeling?\"}, \"finish_reason\": \"stop\"}]}\n\n"))
+		w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer openai.Close()
+
+	ollama := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"message": {"role": "assistant", "content": "How are "}, "done": false}` + "\n"))
+		w.Write([]byte(`{"message": {"role": "assistant", "content": "you feeling?"}, "done": false}` + "\n"))
+		w.Write([]byte(`{"message": {"role": "assistant", "content": ""}, "done": true}` + "\n"))
+	}))
+	defer ollama.Close()
+
+	providers := map[string]generative.Provider{
+		"openai": generative.NewOpenAIProvider(openai.URL, "", "gpt-4o-mini"),
+		"ollama": generative.NewOllamaProvider(ollama.URL, "llama3"),
+		"fake":   generative.NewFakeProvider("How are you feeling?"),
+	}
+
+	for name, provider := range providers {
+		var chunks []string
+		cs := generative.StartChat(provider, nil)
+		resp, err := cs.SendMessageStream(context.Background(), func(chunk string) { chunks = append(chunks, chunk) }, generative.Text("hi"))
+		if err != nil || resp.Text != "How are you feeling?" || len(chunks) < 2 {
+			t.Fatalf(`%s SendMessageStream() = %v, %v with chunks %q, want streamed reply`, name, resp, err, chunks)
+		}
+		if len(cs.History) != 2 || cs.History[1].Parts[0].Text != "How are you feeling?" {
+			t.Fatalf(`%s History = %v, want complete reply committed`, name, cs.History)
+		}
+	}
+}
+
+// TestEmbed calls stub embedding endpoints of the openai and ollama providers, checking
+// the embedding model is sent and vectors are returned in the order of the texts.
+func TestEmbed(t *testing.T) {
+	var models []any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]any
+		json.NewDecoder(r.Body).Decode(&req)
+		models = append(models, req["model"])
+
+		switch r.URL.Path {
+		case "/v1/embeddings":
+			w.Write([]byte(`{"data": [{"index": 1, "embedding": [0, 1]}, {"index": 0, "embedding": [1, 0]}]}`))
+		case "/api/embed":
+			w.Write([]byte(`{"embeddings": [[1, 0], [0, 1]]}`))
+		default:
+			http.Error(w, "unexpected request", http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("OLLAMA_EMBEDDING_MODEL", "all-minilm")
+	providers := []generative.Provider{
+		generative.NewOpenAIProvider(server.URL+"/v1", "sk-test", "gpt-4o-mini"),
+		generative.NewOllamaProvider(server.URL, "llama3"),
+	}
+	for _, provider := range providers {
+		vectors, err := provider.Embed(context.Background(), []string{"ran", "worked"})
+		if err != nil || !reflect.DeepEqual(vectors, [][]float32{{1, 0}, {0, 1}}) {
+			t.Fatalf(`Embed() = %v, %v, want vectors in order`, vectors, err)
+		}
+	}
+
+	if !reflect.DeepEqual(models, []any{"text-embedding-3-small", "all-minilm"}) {
+		t.Fatalf(`embedding models = %v, want default openai and configured ollama`, models)
+	}
+}
@@ -0,0 +1,61 @@
+package generative
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/generative-ai-go/genai"
+)
+
+// PromptBlockedError is returned when safety filters refuse the user's message before the model replies
+type PromptBlockedError struct {
+	Reason string
+	Err    error
+}
+
+func (e *PromptBlockedError) Error() string {
+	return fmt.Sprintf("prompt blocked by safety filters: %s", e.Reason)
+}
+
+func (e *PromptBlockedError) Unwrap() error {
+	return e.Err
+}
+
+// ResponseBlockedError is returned when safety filters withhold the model's reply
+type ResponseBlockedError struct {
+	Reason string
+	Err    error
+}
+
+func (e *ResponseBlockedError) Error() string {
+	return fmt.Sprintf("response blocked by safety filters: %s", e.Reason)
+}
+
+func (e *ResponseBlockedError) Unwrap() error {
+	return e.Err
+}
+
+// IsBlocked reports whether err is a blocked prompt or response
+func IsBlocked(err error) bool {
+	var promptErr *PromptBlockedError
+	var responseErr *ResponseBlockedError
+	return errors.As(err, &promptErr) || errors.As(err, &responseErr)
+}
+
+// geminiError converts Gemini's BlockedError into a typed blocked error, leaving other errors as is
+func geminiError(err error) error {
+	var blocked *genai.BlockedError
+	if !errors.As(err, &blocked) {
+		return err
+	}
+
+	if blocked.PromptFeedback != nil {
+		return &PromptBlockedError{Reason: blocked.PromptFeedback.BlockReason.String(), Err: err}
+	}
+
+	reason := "unknown"
+	if blocked.Candidate != nil {
+		reason = blocked.Candidate.FinishReason.String()
+	}
+	return &ResponseBlockedError{Reason: reason, Err: err}
+}
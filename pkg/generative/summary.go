@@ -0,0 +1,111 @@
+package generative
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"journie/pkg/moods"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidSummary is returned when the model did not produce a valid summary within the allowed attempts
+var ErrInvalidSummary = errors.New("generative: invalid summary")
+
+// MaxSummaryWords is the word limit of a summary
+const MaxSummaryWords = 100
+
+// defaultSummaryAttempts before giving up on a summary, override with SUMMARY_MAX_ATTEMPTS
+const defaultSummaryAttempts = 3
+
+// Summary of a day's chat session
+type Summary struct {
+	Summary string   `json:"summary"`
+	Mood    []string `json:"mood"`
+}
+
+// SummarySchema constrains model output of SummarizeSession
+var SummarySchema = &Schema{
+	Type: "object",
+	Properties: map[string]*Schema{
+		"summary": {
+			Type:        "string",
+			Description: fmt.Sprintf("Summary of the day addressed to the user in second-person, at most %d words", MaxSummaryWords),
+		},
+		"mood": {
+			Type:        "array",
+			Description: fmt.Sprintf("Moods of the user, at most %d", moods.MaxPerEntry),
+			Items:       &Schema{Type: "string", Enum: moods.All},
+		},
+	},
+	Required: []string{"summary", "mood"},
+}
+
+// SummaryError lists why model output is not a valid summary
+type SummaryError struct {
+	Problems []string
+}
+
+func (e *SummaryError) Error() string {
+	return strings.Join(e.Problems, "; ")
+}
+
+// ParseSummary parses and validates model output, repairing what it safely can:
+// text or code fences around the JSON object, mood casing, duplicate, unknown and extra moods.
+// Anything else is returned as a *SummaryError to re-request
+func ParseSummary(text string) (*Summary, error) {
+	start := strings.Index(text, "{")
+	end := strings.LastIndex(text, "}")
+	if start == -1 || end < start {
+		return nil, &SummaryError{Problems: []string{"output is not a JSON object"}}
+	}
+
+	var summary Summary
+	if err := json.Unmarshal([]byte(text[start:end+1]), &summary); err != nil {
+		return nil, &SummaryError{Problems: []string{fmt.Sprintf("output is not valid JSON: %v", err)}}
+	}
+
+	var problems []string
+
+	summary.Summary = strings.TrimSpace(summary.Summary)
+	if summary.Summary == "" {
+		problems = append(problems, `"summary" is empty`)
+	}
+	if words := len(strings.Fields(summary.Summary)); words > MaxSummaryWords {
+		problems = append(problems, fmt.Sprintf(`"summary" has %d words, limit is %d`, words, MaxSummaryWords))
+	}
+
+	var valid []string
+	for _, mood := range summary.Mood {
+		if m, ok := moods.Normalize(mood); ok && !contains(valid, m) {
+			valid = append(valid, m)
+		}
+	}
+	if len(valid) == 0 {
+		problems = append(problems, fmt.Sprintf(`"mood" must contain one or two of %s, got %q`, strings.Join(moods.All, ", "), summary.Mood))
+	}
+	summary.Mood = valid[:min(len(valid), moods.MaxPerEntry)]
+
+	if len(problems) > 0 {
+		return nil, &SummaryError{Problems: problems}
+	}
+
+	return &summary, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func summaryAttempts() int {
+	if attempts, err := strconv.Atoi(os.Getenv("SUMMARY_MAX_ATTEMPTS")); err == nil && attempts > 0 {
+		return attempts
+	}
+	return defaultSummaryAttempts
+}
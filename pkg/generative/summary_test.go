@@ -0,0 +1,100 @@
+package generative_test
+
+import (
+	"errors"
+	"fmt"
+	"journie/pkg/generative"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestParseSummary parses model output, checking repairable output is
+// repaired and the rest is rejected.
+func TestParseSummary(t *testing.T) {
+	repaired := map[string][]string{
+		`{"summary": "You went for a run.", "mood": ["happy"]}`:                          {"happy"},
+		"```json\n{\"summary\": \"You went for a run.\", \"mood\": [\"Happy\"]}\n```":    {"happy"},
+		`Here you go: {"summary": "You went for a run.", "mood": ["sad", "joy", "sad"]}`: {"sad"},
+		`{"summary": "You went for a run.", "mood": ["happy", "surprise", "fear"]}`:      {"happy", "surprise"},
+	}
+	for text, want := range repaired {
+		summary, err := generative.ParseSummary(text)
+		if err != nil || !reflect.DeepEqual(summary.Mood, want) {
+			t.Fatalf(`ParseSummary(%q) = %v, %v, want mood %v`, text, summary, err, want)
+		}
+	}
+
+	rejected := []string{
+		`not json`,
+		`{"summary": "You went for a run.", "mood": "happy"}`,
+		`{"summary": "", "mood": ["happy"]}`,
+		`{"summary": "You went for a run.", "mood": ["joy"]}`,
+		`{"summary": "` + strings.Repeat("word ", generative.MaxSummaryWords+1) + `", "mood": ["happy"]}`,
+	}
+	for _, text := range rejected {
+		var summaryErr *generative.SummaryError
+		if summary, err := generative.ParseSummary(text); !errors.As(err, &summaryErr) {
+			t.Fatalf(`ParseSummary(%q) = %v, %v, want SummaryError`, text, summary, err)
+		}
+	}
+}
+
+// TestSummarizeSessionRetries summarizes against a fake provider returning
+// invalid output, checking the problems are fed back and attempts are bounded.
+func TestSummarizeSessionRetries(t *testing.T) {
+	t.Setenv("SUMMARY_MAX_ATTEMPTS", "2")
+	history := []generative.Message{{Role: "user", Parts: []generative.Part{generative.Text("I went for a run")}}}
+
+	fake := generative.NewFakeProvider(`{"summary": "You went for a run.", "mood": ["joy"]}`, `{"summary": "You went for a run.", "mood": ["happy"]}`)
+	summary, err := generative.SummarizeSession(fake, history, generative.Preferences{})
+	if err != nil || summary.Mood[0] != "happy" {
+		t.Fatalf(`SummarizeSession() = %v, %v, want summary from second attempt`, summary, err)
+	}
+	if retry := fake.Calls[1].Messages; len(retry) != 3 || !strings.Contains(retry[2].Parts[0].Text, `"mood"`) {
+		t.Fatalf(`retry messages = %v, want output and problems fed back`, retry)
+	}
+
+	fake = generative.NewFakeProvider("no", "still no", `{"summary": "You went for a run.", "mood": ["happy"]}`)
+	if _, err := generative.SummarizeSession(fake, history, generative.Preferences{}); !errors.Is(err, generative.ErrInvalidSummary) || len(fake.Calls) != 2 {
+		t.Fatalf(`SummarizeSession() = %v after %d calls, want ErrInvalidSummary after 2`, err, len(fake.Calls))
+	}
+}
+
+// TestParseDigest parses model output, checking highlights are trimmed to the limit
+// and output without a summary is rejected.
+func TestParseDigest(t *testing.T) {
+	digest, err := generative.ParseDigest("```json\n{\"summary\": \"A busy week.\", \"highlights\": [\"a\", \" \", \"b\", \"c\", \"d\", \"e\", \"f\"]}\n```")
+	if err != nil || digest.Summary != "A busy week." || !reflect.DeepEqual(digest.Highlights, []string{"a", "b", "c", "d", "e"}) {
+		t.Fatalf(`ParseDigest() = %+v, %v, want 5 highlights`, digest, err)
+	}
+
+	var summaryErr *generative.SummaryError
+	if digest, err := generative.ParseDigest(`{"summary": " ", "highlights": []}`); !errors.As(err, &summaryErr) {
+		t.Fatalf(`ParseDigest(empty summary) = %+v, %v, want SummaryError`, digest, err)
+	}
+}
+
+// TestSummarizeSessionPreferences summarizes with a summary style and language, checking
+// both are added to the prompt and the default prompt has neither
+func TestSummarizeSessionPreferences(t *testing.T) {
+	history := []generative.Message{{Role: "user", Parts: []generative.Part{generative.Text("I went for a run")}}}
+	output := `{"summary": "You went for a run.", "mood": ["happy"]}`
+
+	fake := generative.NewFakeProvider(output)
+	if _, err := generative.SummarizeSession(fake, history, generative.Preferences{Language: "Español", SummaryStyle: "bullets"}); err != nil {
+		t.Fatalf(`SummarizeSession() = %v, want summary`, err)
+	}
+	prompt := fmt.Sprint(fake.Calls[0].Messages)
+	if !strings.Contains(prompt, "in Español") || !strings.Contains(prompt, "bullet points") {
+		t.Fatalf(`prompt = %s, want language and style instructions`, prompt)
+	}
+
+	fake = generative.NewFakeProvider(output)
+	if _, err := generative.SummarizeSession(fake, history, generative.Preferences{}); err != nil {
+		t.Fatalf(`SummarizeSession() = %v, want summary`, err)
+	}
+	if prompt := fmt.Sprint(fake.Calls[0].Messages); strings.Contains(prompt, "bullet points") || strings.Contains(prompt, "moods stay in English") {
+		t.Fatalf(`prompt = %s, want no preference instructions`, prompt)
+	}
+}
@@ -0,0 +1,140 @@
+package i18n
+
+// en is the source catalog, every other catalog translates its keys
+var en = Catalog{
+	"welcome": {Other: `
+Hi there %s\!
+
+Welcome to Journie, your private and engaging journaling companion\.
+
+Here, you can chat with a friendly genie who remembers your past entries and helps you explore your thoughts and feelings\.
+
+Simply say *Hi* and we can begin\!
+	`},
+	"reminder":                      {Other: "Hi, take 5 minutes to write a journal entry!"},
+	"video.unsupported":             {Other: "Sorry! I am unable to process videos as of now!"},
+	"session.deleted":               {Other: "Chat session deleted"},
+	"button.back":                   {Other: "« Back"},
+	"button.newer":                  {Other: "« Prev"},
+	"button.older":                  {Other: "Next »"},
+	"button.jump":                   {Other: "Jump to date"},
+	"button.geminiWhy":              {Other: "Why do I need this?"},
+	"quota.shared":                  {Other: "Journie's shared Gemini key has reached its limit for today. Add your own free key with /gemini_key to keep journaling, or try again tomorrow."},
+	"limit.seconds":                 {One: "You're writing faster than I can keep up 🙂 Take a breath, you can continue in %d second.", Other: "You're writing faster than I can keep up 🙂 Take a breath, you can continue in %d seconds."},
+	"limit.minutes":                 {One: "You're writing faster than I can keep up 🙂 Take a breath, you can continue in %d minute.", Other: "You're writing faster than I can keep up 🙂 Take a breath, you can continue in %d minutes."},
+	"limit.daily":                   {Other: "That's all the messages I can take for today 🌙 You can continue from %s your time."},
+	"limit.dailyOwnKey":             {Other: "Add your own free Gemini key with /gemini_key for a higher limit."},
+	"safety.prompt":                 {Other: "I'm sorry, I wasn't able to respond to that message. It sounds like it might be about something really heavy, and I want you to know your feelings matter.\n\nIf you're going through a hard time, please consider reaching out to someone you trust or a local helpline, they can support you in ways I can't.\n\nWhenever you're ready, you can keep telling me about your day in different words, I'm still here."},
+	"safety.response":               {Other: "I started to reply but couldn't finish my thoughts on that one, sorry about that. Your message is still welcome here. Could you tell me a little more, or share it another way?"},
+	"voice.transcript":              {Other: "🎙️ I heard:\n\n%s"},
+	"voice.empty":                   {Other: "I couldn't hear any words in that voice note. Could you try again, or type it out?"},
+	"voice.tooLong":                 {Other: "That voice note is too long for me to listen to. Could you split it into shorter notes?"},
+	"voice.unavailable":             {Other: "Sorry! This Journie can't listen to voice notes yet, please type your entry instead."},
+	"error.user":                    {Other: "Error handling user id"},
+	"error.request":                 {Other: "Error processing your request"},
+	"error.createChat":              {Other: "Error creating chat session"},
+	"error.getChat":                 {Other: "Error retrieving chat session"},
+	"error.deleteChat":              {Other: "Error deleting chat session"},
+	"error.analysis":                {Other: "Error processing analysis"},
+	"error.entries":                 {Other: "Error retrieving journal entries"},
+	"error.export":                  {Other: "Error exporting journal"},
+	"error.chart":                   {Other: "Error rendering mood chart"},
+	"error.photo":                   {Other: "Error downloading your photo"},
+	"error.voice":                   {Other: "Error downloading your voice note"},
+	"error.transcribe":              {Other: "Error transcribing your voice note"},
+	"error.saveKey":                 {Other: "Error saving your Gemini API key"},
+	"error.removeKey":               {Other: "Error removing your Gemini API key"},
+	"timezone.prompt":               {Other: "Which timezone are you in? Journie uses it to remind you at your reminder time, 10pm unless you change it in /settings, and to close your journal day at 4am.\n\nPick one below, or send /timezone followed by your timezone, e.g. /timezone Europe/Berlin. Until then, %s is used."},
+	"timezone.current":              {Other: "Your timezone is %s.\n\nTo change it, pick one below or send /timezone followed by your timezone, e.g. /timezone Europe/Berlin."},
+	"timezone.updated":              {Other: "Timezone set to %s, where it is now %s."},
+	"timezone.invalid":              {Other: "Sorry, I don't recognise the timezone %q. Use a name from https://en.wikipedia.org/wiki/List_of_tz_database_time_zones, e.g. Asia/Singapore."},
+	"settings.menu":                 {Other: "⚙️ Your settings. Tap one to change it."},
+	"settings.options":              {Other: "%s, pick one:"},
+	"settings.saved":                {Other: "Saved!"},
+	"settings.invalid":              {Other: "Sorry, that option is no longer available. Send /settings to see the current ones."},
+	"settings.on":                   {Other: "On"},
+	"settings.off":                  {Other: "Off"},
+	"setting.reminders":             {Other: "🔔 Reminders"},
+	"setting.reminderHour":          {Other: "⏰ Reminder time"},
+	"setting.language":              {Other: "🌐 Language"},
+	"setting.persona":               {Other: "🎭 Persona"},
+	"setting.summaryStyle":          {Other: "📝 Summary style"},
+	"option.language.auto":          {Other: "Automatic"},
+	"option.persona.gentle":         {Other: "Gentle listener"},
+	"option.persona.socratic":       {Other: "Socratic coach"},
+	"option.persona.stoic":          {Other: "Stoic mentor"},
+	"option.persona.brief":          {Other: "Brief logger"},
+	"option.summaryStyle.paragraph": {Other: "Paragraph"},
+	"option.summaryStyle.bullets":   {Other: "Bullet points"},
+	"option.summaryStyle.brief":     {Other: "One or two sentences"},
+	"persona.menu":                  {Other: "🎭 Current persona: %s\n\n%s\n\nPick another below, or send /persona followed by its name."},
+	"persona.updated":               {Other: "Persona set to %s. Journie talks this way from your next message."},
+	"persona.invalid":               {Other: "Sorry, there is no persona %q. Pick one of: %s."},
+	"description.persona.gentle":    {Other: "Listens with empathy and asks how you are feeling"},
+	"description.persona.socratic":  {Other: "Asks open questions that lead you to your own insights"},
+	"description.persona.stoic":     {Other: "Helps you reflect calmly on what is within your control"},
+	"description.persona.brief":     {Other: "Keeps replies short and factual"},
+	"export.empty":                  {Other: "You don't have any journal entries to export yet. Entries are saved at the end of each day you chat with Journie."},
+	"export.usage":                  {Other: "Usage: /export [%s]. Defaults to %s."},
+	"export.caption":                {One: "Your Journie journal, %d entry.", Other: "Your Journie journal, %d entries."},
+	"history.empty":                 {Other: "You don't have any journal entries yet. Entries are saved at the end of each day you chat with Journie."},
+	"history.noNewer":               {Other: "This is your latest entry."},
+	"history.noOlder":               {Other: "This is your first entry."},
+	"history.jumpPrompt":            {Other: "Which date would you like to read? Reply with a date like 2024-05-20."},
+	"history.mood":                  {Other: "Mood: %s"},
+	"history.photos":                {One: "📷 %d photo", Other: "📷 %d photos"},
+	"history.invalidDate":           {Other: "Sorry, %q is not a date I understand. Use the format 2024-05-20."},
+	"history.noneBefore":            {Other: "You don't have any journal entries on or before %s."},
+	"mood.usage":                    {Other: "Usage: /mood [%s]. Defaults to %s."},
+	"mood.empty":                    {Other: "You don't have any journal entries in the past %s yet, so there is nothing to chart."},
+	"mood.caption":                  {One: "Your moods this past %[2]s, from %[1]d entry.", Other: "Your moods this past %[2]s, from %[1]d entries."},
+	"period.week":                   {Other: "week"},
+	"period.month":                  {Other: "month"},
+	"period.year":                   {Other: "year"},
+	"mood.happy":                    {Other: "happy"},
+	"mood.surprise":                 {Other: "surprise"},
+	"mood.neutral":                  {Other: "neutral"},
+	"mood.sad":                      {Other: "sad"},
+	"mood.fear":                     {Other: "fear"},
+	"mood.disgust":                  {Other: "disgust"},
+	"mood.anger":                    {Other: "anger"},
+	"gemini.saved":                  {Other: "Your Gemini API key is saved and encrypted. Journie will use it from now on, just say Hi! To stop using it, send /gemini_key remove."},
+	"gemini.removed":                {Other: "Your Gemini API key has been removed. Journie will use the shared key from now on."},
+	"gemini.invalid":                {Other: "Gemini did not accept that API key. Please check you copied the whole key, or create a new one with /gemini_key."},
+	"gemini.notFound":               {Other: "That doesn't look like a Gemini API key. Keys start with AIza, send /gemini_key to see how to get one."},
+	"gemini.unavailable":            {Other: "Sorry, this Journie doesn't support your own Gemini API key yet. You can keep using the shared key."},
+	"gemini.rejected":               {Other: "Gemini rejected your API key, it may have been deleted or restricted. I've removed it and will use the shared key from your next message. Send a new key anytime with /gemini_key."},
+	"gemini.forbidden":              {Other: "Gemini refused this request with your API key, e.g. because of your region, billing or the API being disabled for your project. Your key is kept, check it in Google AI Studio, or send /gemini_key remove to use the shared key."},
+	"gemini.instructions": {Other: `*Get Your Gemini API Key (Desktop Required for Now)*
+
+*Heads up!* Currently, creating an API key can only be done on a desktop computer using Google AI Studio. Let's get you set up in a few easy steps:
+
+	1. *Head to Google AI Studio:*  Open your favorite web browser on your desktop and visit this link: [https://aistudio.google.com/app/apikey](https://aistudio.google.com/app/apikey)
+
+	2. *Sign in to Google (if needed):* You might be prompted to sign in with your Google account. Go ahead and do that!
+
+	3. *Choose "Get API key":*  Once you're signed in, you might see a screen with options like "New Project" or "Get API key." Choose *Get API key*.
+
+	4. *Create a new project:*  Click on *"Create API key in new project"*. This will set up a new project for your Journie API key.
+
+	5. *Copy your API key:*  A new window will pop up showing your API key. *Copy* this key to your clipboard.
+
+	6. *Send your key and say Hi!:*  Come back to Journie and send the key into the chat. Once that's done, simply say *"Hi"* and we can start your journaling adventure!
+
+`},
+	"gemini.reason": {Other: `*Don't worry, creating an API key is safe and free!*
+
+We understand you might be hesitant to create an API key. Here's why this step is secure and important for Journie:
+
+	· *Security:*  This process happens entirely within Google AI Studio, a secure platform from Google. We never ask for your payment information, and the API key itself doesn't grant access to any sensitive data.
+
+	· *Improved experience:*  The API key helps Journie identify you uniquely and avoid any rate limits. This means you'll get smoother interactions and faster replies without interruption.
+
+	· *Keeps Journie free:*  API keys help us prevent abuse from spammers and bots. These automated programs can send a lot of requests, which can be expensive for us to maintain. By limiting access with API keys, we can keep Journie free for everyone.
+
+Think of the API key as a handshake that allows Journie to leverage Google Gemini's power to offer this personalized journaling experience.
+
+Ready to get started? Let's head to Google AI Studio!
+
+`},
+}
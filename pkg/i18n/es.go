@@ -0,0 +1,139 @@
+package i18n
+
+var es = Catalog{
+	"welcome": {Other: `
+¡Hola, %s\!
+
+Te damos la bienvenida a Journie, tu compañero de diario privado y cercano\.
+
+Aquí puedes charlar con un genio amistoso que recuerda tus entradas anteriores y te ayuda a explorar tus pensamientos y sentimientos\.
+
+¡Solo di *Hola* y empezamos\!
+	`},
+	"reminder":                      {Other: "¡Hola! Tómate 5 minutos para escribir en tu diario."},
+	"video.unsupported":             {Other: "¡Lo siento! Por ahora no puedo procesar vídeos."},
+	"session.deleted":               {Other: "Conversación eliminada"},
+	"button.back":                   {Other: "« Volver"},
+	"button.newer":                  {Other: "« Anterior"},
+	"button.older":                  {Other: "Siguiente »"},
+	"button.jump":                   {Other: "Ir a una fecha"},
+	"button.geminiWhy":              {Other: "¿Por qué lo necesito?"},
+	"quota.shared":                  {Other: "La clave compartida de Gemini de Journie ha llegado a su límite de hoy. Añade tu propia clave gratuita con /gemini_key para seguir escribiendo, o vuelve mañana."},
+	"limit.seconds":                 {One: "Escribes más rápido de lo que puedo seguirte 🙂 Respira, puedes continuar en %d segundo.", Other: "Escribes más rápido de lo que puedo seguirte 🙂 Respira, puedes continuar en %d segundos."},
+	"limit.minutes":                 {One: "Escribes más rápido de lo que puedo seguirte 🙂 Respira, puedes continuar en %d minuto.", Other: "Escribes más rápido de lo que puedo seguirte 🙂 Respira, puedes continuar en %d minutos."},
+	"limit.daily":                   {Other: "Esos son todos los mensajes que puedo recibir hoy 🌙 Puedes continuar a partir de las %s, tu hora."},
+	"limit.dailyOwnKey":             {Other: "Añade tu propia clave gratuita de Gemini con /gemini_key para tener un límite más alto."},
+	"safety.prompt":                 {Other: "Lo siento, no he podido responder a ese mensaje. Parece que trata de algo muy difícil, y quiero que sepas que lo que sientes importa.\n\nSi estás pasando por un mal momento, considera hablar con alguien de confianza o con una línea de ayuda local, pueden apoyarte de formas en que yo no puedo.\n\nCuando quieras, puedes seguir contándome tu día con otras palabras, sigo aquí."},
+	"safety.response":               {Other: "Empecé a responder pero no pude terminar, lo siento. Tu mensaje sigue siendo bienvenido. ¿Me cuentas un poco más, o lo compartes de otra forma?"},
+	"voice.transcript":              {Other: "🎙️ Escuché:\n\n%s"},
+	"voice.empty":                   {Other: "No pude oír ninguna palabra en esa nota de voz. ¿Lo intentas de nuevo o lo escribes?"},
+	"voice.tooLong":                 {Other: "Esa nota de voz es demasiado larga para mí. ¿Puedes dividirla en notas más cortas?"},
+	"voice.unavailable":             {Other: "¡Lo siento! Este Journie todavía no puede escuchar notas de voz, por favor escribe tu entrada."},
+	"error.user":                    {Other: "Error al identificar al usuario"},
+	"error.request":                 {Other: "Error al procesar tu solicitud"},
+	"error.createChat":              {Other: "Error al crear la conversación"},
+	"error.getChat":                 {Other: "Error al recuperar la conversación"},
+	"error.deleteChat":              {Other: "Error al eliminar la conversación"},
+	"error.analysis":                {Other: "Error al procesar el análisis"},
+	"error.entries":                 {Other: "Error al recuperar las entradas del diario"},
+	"error.export":                  {Other: "Error al exportar el diario"},
+	"error.chart":                   {Other: "Error al dibujar el gráfico de ánimo"},
+	"error.photo":                   {Other: "Error al descargar tu foto"},
+	"error.voice":                   {Other: "Error al descargar tu nota de voz"},
+	"error.transcribe":              {Other: "Error al transcribir tu nota de voz"},
+	"error.saveKey":                 {Other: "Error al guardar tu clave de API de Gemini"},
+	"error.removeKey":               {Other: "Error al eliminar tu clave de API de Gemini"},
+	"timezone.prompt":               {Other: "¿En qué zona horaria estás? Journie la usa para recordarte a tu hora de recordatorio, las 22:00 salvo que la cambies en /settings, y para cerrar tu día de diario a las 4:00.\n\nElige una abajo, o envía /timezone seguido de tu zona horaria, p. ej. /timezone Europe/Madrid. Mientras tanto, se usa %s."},
+	"timezone.current":              {Other: "Tu zona horaria es %s.\n\nPara cambiarla, elige una abajo o envía /timezone seguido de tu zona horaria, p. ej. /timezone Europe/Madrid."},
+	"timezone.updated":              {Other: "Zona horaria configurada en %s, donde ahora son las %s."},
+	"timezone.invalid":              {Other: "Lo siento, no reconozco la zona horaria %q. Usa un nombre de https://en.wikipedia.org/wiki/List_of_tz_database_time_zones, p. ej. America/Mexico_City."},
+	"settings.menu":                 {Other: "⚙️ Tus ajustes. Toca uno para cambiarlo."},
+	"settings.options":              {Other: "%s, elige una opción:"},
+	"settings.saved":                {Other: "¡Guardado!"},
+	"settings.invalid":              {Other: "Lo siento, esa opción ya no está disponible. Envía /settings para ver las actuales."},
+	"settings.on":                   {Other: "Sí"},
+	"settings.off":                  {Other: "No"},
+	"setting.reminders":             {Other: "🔔 Recordatorios"},
+	"setting.reminderHour":          {Other: "⏰ Hora del recordatorio"},
+	"setting.language":              {Other: "🌐 Idioma"},
+	"setting.persona":               {Other: "🎭 Personalidad"},
+	"setting.summaryStyle":          {Other: "📝 Estilo del resumen"},
+	"option.language.auto":          {Other: "Automático"},
+	"option.persona.gentle":         {Other: "Oyente amable"},
+	"option.persona.socratic":       {Other: "Coach socrático"},
+	"option.persona.stoic":          {Other: "Mentor estoico"},
+	"option.persona.brief":          {Other: "Registro breve"},
+	"option.summaryStyle.paragraph": {Other: "Párrafo"},
+	"option.summaryStyle.bullets":   {Other: "Viñetas"},
+	"option.summaryStyle.brief":     {Other: "Una o dos frases"},
+	"persona.menu":                  {Other: "🎭 Personaje actual: %s\n\n%s\n\nElige otro abajo, o envía /persona seguido de su nombre."},
+	"persona.updated":               {Other: "Personaje cambiado a %s. Journie hablará así desde tu próximo mensaje."},
+	"persona.invalid":               {Other: "Lo siento, no existe el personaje %q. Elige uno de: %s."},
+	"description.persona.gentle":    {Other: "Escucha con empatía y te pregunta cómo te sientes"},
+	"description.persona.socratic":  {Other: "Hace preguntas abiertas que te llevan a tus propias conclusiones"},
+	"description.persona.stoic":     {Other: "Te ayuda a reflexionar con calma sobre lo que está en tu control"},
+	"description.persona.brief":     {Other: "Responde de forma breve y concreta"},
+	"export.empty":                  {Other: "Todavía no tienes entradas para exportar. Las entradas se guardan al final de cada día en que hablas con Journie."},
+	"export.usage":                  {Other: "Uso: /export [%s]. Por defecto, %s."},
+	"export.caption":                {One: "Tu diario de Journie, %d entrada.", Other: "Tu diario de Journie, %d entradas."},
+	"history.empty":                 {Other: "Todavía no tienes entradas en tu diario. Las entradas se guardan al final de cada día en que hablas con Journie."},
+	"history.noNewer":               {Other: "Esta es tu entrada más reciente."},
+	"history.noOlder":               {Other: "Esta es tu primera entrada."},
+	"history.jumpPrompt":            {Other: "¿Qué fecha quieres leer? Responde con una fecha como 2024-05-20."},
+	"history.mood":                  {Other: "Ánimo: %s"},
+	"history.photos":                {One: "📷 %d foto", Other: "📷 %d fotos"},
+	"history.invalidDate":           {Other: "Lo siento, no entiendo la fecha %q. Usa el formato 2024-05-20."},
+	"history.noneBefore":            {Other: "No tienes entradas en tu diario el %s ni antes."},
+	"mood.usage":                    {Other: "Uso: /mood [%s]. Por defecto, %s."},
+	"mood.empty":                    {Other: "Todavía no tienes entradas en el último %s, así que no hay nada que mostrar."},
+	"mood.caption":                  {One: "Tu ánimo en el último %[2]s, de %[1]d entrada.", Other: "Tu ánimo en el último %[2]s, de %[1]d entradas."},
+	"period.week":                   {Other: "semana"},
+	"period.month":                  {Other: "mes"},
+	"period.year":                   {Other: "año"},
+	"mood.happy":                    {Other: "alegría"},
+	"mood.surprise":                 {Other: "sorpresa"},
+	"mood.neutral":                  {Other: "neutral"},
+	"mood.sad":                      {Other: "tristeza"},
+	"mood.fear":                     {Other: "miedo"},
+	"mood.disgust":                  {Other: "asco"},
+	"mood.anger":                    {Other: "enfado"},
+	"gemini.saved":                  {Other: "Tu clave de API de Gemini está guardada y cifrada. Journie la usará a partir de ahora, ¡solo di Hola! Para dejar de usarla, envía /gemini_key remove."},
+	"gemini.removed":                {Other: "Tu clave de API de Gemini se ha eliminado. Journie usará la clave compartida a partir de ahora."},
+	"gemini.invalid":                {Other: "Gemini no aceptó esa clave de API. Comprueba que copiaste la clave completa, o crea una nueva con /gemini_key."},
+	"gemini.notFound":               {Other: "Eso no parece una clave de API de Gemini. Las claves empiezan por AIza, envía /gemini_key para ver cómo conseguir una."},
+	"gemini.unavailable":            {Other: "Lo siento, este Journie todavía no admite tu propia clave de API de Gemini. Puedes seguir usando la clave compartida."},
+	"gemini.rejected":               {Other: "Gemini rechazó tu clave de API, puede que se haya eliminado o restringido. La he quitado y usaré la clave compartida desde tu próximo mensaje. Envía una nueva clave cuando quieras con /gemini_key."},
+	"gemini.forbidden":              {Other: "Gemini rechazó esta petición con tu clave de API, p. ej. por tu región, la facturación o la API desactivada en tu proyecto. Conservo tu clave; revísala en Google AI Studio o envía /gemini_key remove para usar la clave compartida."},
+	"gemini.instructions": {Other: `*Consigue tu clave de API de Gemini (por ahora, desde un ordenador)*
+
+*¡Atención!* Por ahora, las claves de API solo se pueden crear desde un ordenador con Google AI Studio. Te lo dejamos listo en unos pocos pasos:
+
+	1. *Ve a Google AI Studio:*  Abre tu navegador en el ordenador y visita este enlace: [https://aistudio.google.com/app/apikey](https://aistudio.google.com/app/apikey)
+
+	2. *Inicia sesión en Google (si hace falta):* Puede que te pida iniciar sesión con tu cuenta de Google. ¡Adelante!
+
+	3. *Elige "Get API key":*  Una vez dentro, verás opciones como "New Project" o "Get API key". Elige *Get API key*.
+
+	4. *Crea un proyecto nuevo:*  Haz clic en *"Create API key in new project"*. Así se crea un proyecto nuevo para tu clave de Journie.
+
+	5. *Copia tu clave de API:*  Se abrirá una ventana con tu clave de API. *Cópiala* al portapapeles.
+
+	6. *Envía tu clave y di Hola:*  Vuelve a Journie y envía la clave en el chat. Después, solo di *"Hola"* y empezamos tu aventura de diario.
+
+`},
+	"gemini.reason": {Other: `*No te preocupes, crear una clave de API es seguro y gratis*
+
+Entendemos que quizá dudes en crear una clave de API. Por eso este paso es seguro e importante para Journie:
+
+	· *Seguridad:*  Todo ocurre dentro de Google AI Studio, una plataforma segura de Google. Nunca te pedimos datos de pago, y la clave de API en sí no da acceso a ningún dato sensible.
+
+	· *Mejor experiencia:*  La clave de API ayuda a Journie a identificarte y a evitar límites de uso. Así tendrás conversaciones más fluidas y respuestas más rápidas, sin interrupciones.
+
+	· *Mantiene Journie gratis:*  Las claves de API nos ayudan a evitar abusos de spammers y bots. Estos programas automáticos envían muchísimas solicitudes, que nos saldrían caras. Al limitar el acceso con claves de API, Journie sigue siendo gratis para todos.
+
+Piensa en la clave de API como un apretón de manos que permite a Journie usar la potencia de Google Gemini para ofrecerte esta experiencia de diario personalizada.
+
+¿Listo para empezar? ¡Vamos a Google AI Studio!
+
+`},
+}
@@ -0,0 +1,9 @@
+package i18n
+
+// Catalogs exposes the catalogs to tests
+func Catalogs() map[string]Catalog {
+	return catalogs
+}
+
+// MarkdownSpecial exposes the characters telegram MarkdownV2 reserves to tests
+const MarkdownSpecial = markdownSpecial
@@ -0,0 +1,118 @@
+package i18n
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Default is the language messages fall back to when missing from a catalog
+const Default = "en"
+
+// Message is a translated string in fmt format. Messages taking a count have plural forms,
+// One for a count of 1 in languages that tell it apart, Other for the rest
+type Message struct {
+	One   string
+	Other string
+}
+
+// Catalog holds the messages of a language, keyed by message key
+type Catalog map[string]Message
+
+// catalogs by language code
+var catalogs = map[string]Catalog{
+	"en": en,
+	"es": es,
+	"id": id,
+	"ja": ja,
+	"ms": ms,
+	"zh": zh,
+}
+
+// singular lists languages using One for a count of 1, the rest use Other for every count
+var singular = map[string]bool{
+	"en": true,
+	"es": true,
+}
+
+// Match returns the catalog language of an IETF language tag such as en, pt-BR or zh-hans,
+// empty if there is no catalog for it
+func Match(tag string) string {
+	base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+	base, _, _ = strings.Cut(base, "_")
+	if _, ok := catalogs[base]; ok {
+		return base
+	}
+	return ""
+}
+
+// Find returns the message of key in lang, falling back to Default, and whether it exists
+func Find(lang string, key string) (Message, bool) {
+	if message, ok := catalogs[Match(lang)][key]; ok {
+		return message, true
+	}
+	message, ok := catalogs[Default][key]
+	return message, ok
+}
+
+// T returns the message of key in lang formatted with args
+func T(lang string, key string, args ...any) string {
+	return format(lookup(lang, key).Other, args)
+}
+
+// N returns the plural form of key in lang for count, formatted with count followed by args.
+// Every form refers to count, as %[1]d when the message takes other args
+func N(lang string, key string, count int, args ...any) string {
+	message := lookup(lang, key)
+
+	form := message.Other
+	if count == 1 && singular[Match(lang)] && message.One != "" {
+		form = message.One
+	}
+	return format(form, append([]any{count}, args...))
+}
+
+// Markdown returns the message of key in lang for telegram MarkdownV2, formatted with
+// string args escaped so user input such as names cannot break the markup.
+// Messages are written in MarkdownV2 already
+func Markdown(lang string, key string, args ...any) string {
+	escaped := make([]any, len(args))
+	for i, arg := range args {
+		if s, ok := arg.(string); ok {
+			arg = EscapeMarkdown(s)
+		}
+		escaped[i] = arg
+	}
+	return T(lang, key, escaped...)
+}
+
+// markdownSpecial are the characters telegram MarkdownV2 requires to be escaped outside of entities
+const markdownSpecial = "_*[]()~`>#+-=|{}.!\\"
+
+// EscapeMarkdown escapes s to be shown as is in telegram MarkdownV2
+func EscapeMarkdown(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(markdownSpecial, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func lookup(lang string, key string) Message {
+	message, ok := Find(lang, key)
+	if !ok {
+		log.Printf("Missing message %q", key)
+		return Message{Other: key}
+	}
+	return message
+}
+
+func format(form string, args []any) string {
+	if len(args) == 0 {
+		return form
+	}
+	return fmt.Sprintf(form, args...)
+}
@@ -0,0 +1,145 @@
+package i18n_test
+
+import (
+	"fmt"
+	"journie/pkg/i18n"
+	"strings"
+	"testing"
+)
+
+// TestMatch calls i18n.Match with language tags, checking region and script
+// subtags are dropped and languages without a catalog are empty.
+func TestMatch(t *testing.T) {
+	tests := map[string]string{
+		"en":      "en",
+		"pt-BR":   "",
+		"zh-hans": "zh",
+		"ES_mx":   "es",
+		" id ":    "id",
+		"":        "",
+	}
+	for tag, want := range tests {
+		if got := i18n.Match(tag); got != want {
+			t.Errorf(`Match(%q) = %q, want %q`, tag, got, want)
+		}
+	}
+}
+
+// TestN formats plural messages, checking One is only used for a count of 1
+// in languages that tell it apart, and unknown languages fall back to English.
+func TestN(t *testing.T) {
+	tests := []struct {
+		lang  string
+		count int
+		want  string
+	}{
+		{"en", 1, "Your Journie journal, 1 entry."},
+		{"en", 3, "Your Journie journal, 3 entries."},
+		{"es", 1, "Tu diario de Journie, 1 entrada."},
+		{"id", 1, "Jurnal Journie-mu, 1 catatan."},
+		{"pt", 2, "Your Journie journal, 2 entries."},
+	}
+	for _, test := range tests {
+		if got := i18n.N(test.lang, "export.caption", test.count); got != test.want {
+			t.Errorf(`N(%q, "export.caption", %d) = %q, want %q`, test.lang, test.count, got, test.want)
+		}
+	}
+
+	if got := i18n.N("en", "mood.caption", 2, "week"); got != "Your moods this past week, from 2 entries." {
+		t.Errorf(`N("en", "mood.caption", 2, "week") = %q, want count and period`, got)
+	}
+}
+
+// TestMarkdown formats the welcome message with a username full of MarkdownV2
+// special characters, checking they are escaped.
+func TestMarkdown(t *testing.T) {
+	if got := i18n.EscapeMarkdown("a_b*c.d!"); got != `a\_b\*c\.d\!` {
+		t.Fatalf(`EscapeMarkdown("a_b*c.d!") = %q, want escaped`, got)
+	}
+
+	message := i18n.Markdown("en", "welcome", "jo_hn.doe")
+	if want := `Hi there jo\_hn\.doe\!`; !strings.Contains(message, want) {
+		t.Fatalf(`Markdown("en", "welcome") = %q, want %q`, message, want)
+	}
+}
+
+// TestCatalogs compares every catalog to English, checking no message is missing
+// and each form takes the same arguments.
+func TestCatalogs(t *testing.T) {
+	english := i18n.Catalogs()["en"]
+	for lang, catalog := range i18n.Catalogs() {
+		for key, message := range english {
+			translated, ok := catalog[key]
+			if !ok {
+				t.Errorf(`%s: missing %q`, lang, key)
+				continue
+			}
+			if got, want := verbs(translated.Other), verbs(message.Other); got != want {
+				t.Errorf(`%s: %q takes %d args, want %d`, lang, key, got, want)
+			}
+			if translated.One != "" && verbs(translated.One) != verbs(translated.Other) {
+				t.Errorf(`%s: %q forms take different args`, lang, key)
+			}
+		}
+		for key := range catalog {
+			if _, ok := english[key]; !ok {
+				t.Errorf(`%s: %q is not in the English catalog`, lang, key)
+			}
+		}
+	}
+}
+
+// markdownKeys are the messages sent in telegram MarkdownV2, see i18n.Markdown
+var markdownKeys = []string{"welcome"}
+
+// TestMarkdownCatalogs checks the MarkdownV2 messages of every catalog escape the characters
+// telegram reserves, other than balanced bold, italic and strikethrough markers
+func TestMarkdownCatalogs(t *testing.T) {
+	for lang, catalog := range i18n.Catalogs() {
+		for _, key := range markdownKeys {
+			for _, form := range []string{catalog[key].One, catalog[key].Other} {
+				if err := checkMarkdown(form); err != nil {
+					t.Errorf(`%s: %q %v`, lang, key, err)
+				}
+			}
+		}
+	}
+}
+
+func checkMarkdown(message string) error {
+	markers := map[rune]int{}
+	escaped := false
+	for i, r := range message {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case strings.ContainsRune("*_~", r):
+			markers[r]++
+		case strings.ContainsRune(i18n.MarkdownSpecial, r):
+			return fmt.Errorf("has unescaped %q at byte %d", r, i)
+		}
+	}
+	for marker, count := range markers {
+		if count%2 != 0 {
+			return fmt.Errorf("has unbalanced %q", marker)
+		}
+	}
+	return nil
+}
+
+// verbs counts the fmt verbs of a message
+func verbs(message string) int {
+	count := 0
+	for i := 0; i < len(message)-1; i++ {
+		if message[i] == '%' {
+			if message[i+1] == '%' {
+				i++
+				continue
+			}
+			count++
+		}
+	}
+	return count
+}
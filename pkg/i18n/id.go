@@ -0,0 +1,139 @@
+package i18n
+
+var id = Catalog{
+	"welcome": {Other: `
+Hai %s\!
+
+Selamat datang di Journie, teman menulis jurnal yang pribadi dan seru\.
+
+Di sini, kamu bisa mengobrol dengan jin ramah yang ingat catatan\-catatanmu sebelumnya dan membantumu menjelajahi pikiran serta perasaanmu\.
+
+Cukup sapa *Hai* dan kita mulai\!
+	`},
+	"reminder":                      {Other: "Hai, luangkan 5 menit untuk menulis jurnal hari ini!"},
+	"video.unsupported":             {Other: "Maaf! Saat ini aku belum bisa memproses video."},
+	"session.deleted":               {Other: "Percakapan dihapus"},
+	"button.back":                   {Other: "« Kembali"},
+	"button.newer":                  {Other: "« Sebelumnya"},
+	"button.older":                  {Other: "Berikutnya »"},
+	"button.jump":                   {Other: "Lompat ke tanggal"},
+	"button.geminiWhy":              {Other: "Kenapa aku perlu ini?"},
+	"quota.shared":                  {Other: "Kunci Gemini bersama milik Journie sudah mencapai batas hari ini. Tambahkan kunci gratismu sendiri dengan /gemini_key untuk terus menulis, atau coba lagi besok."},
+	"limit.seconds":                 {Other: "Kamu menulis lebih cepat dari yang bisa kuikuti 🙂 Tarik napas dulu, kamu bisa lanjut dalam %d detik."},
+	"limit.minutes":                 {Other: "Kamu menulis lebih cepat dari yang bisa kuikuti 🙂 Tarik napas dulu, kamu bisa lanjut dalam %d menit."},
+	"limit.daily":                   {Other: "Itu semua pesan yang bisa kuterima hari ini 🌙 Kamu bisa lanjut mulai pukul %s waktumu."},
+	"limit.dailyOwnKey":             {Other: "Tambahkan kunci Gemini gratismu sendiri dengan /gemini_key untuk batas yang lebih tinggi."},
+	"safety.prompt":                 {Other: "Maaf, aku tidak bisa membalas pesan itu. Sepertinya ini tentang sesuatu yang sangat berat, dan aku ingin kamu tahu perasaanmu itu penting.\n\nKalau kamu sedang melalui masa sulit, pertimbangkan untuk menghubungi orang yang kamu percaya atau layanan bantuan setempat, mereka bisa mendukungmu dengan cara yang tidak bisa kulakukan.\n\nKapan pun kamu siap, kamu bisa terus bercerita tentang harimu dengan kata-kata lain, aku masih di sini."},
+	"safety.response":               {Other: "Aku mulai membalas tapi tidak bisa menyelesaikannya, maaf ya. Pesanmu tetap diterima di sini. Maukah kamu cerita sedikit lagi, atau menyampaikannya dengan cara lain?"},
+	"voice.transcript":              {Other: "🎙️ Aku mendengar:\n\n%s"},
+	"voice.empty":                   {Other: "Aku tidak mendengar kata apa pun di pesan suara itu. Bisa coba lagi, atau ketik saja?"},
+	"voice.tooLong":                 {Other: "Pesan suara itu terlalu panjang untukku. Bisa dipecah menjadi beberapa pesan yang lebih pendek?"},
+	"voice.unavailable":             {Other: "Maaf! Journie ini belum bisa mendengarkan pesan suara, silakan ketik catatanmu."},
+	"error.user":                    {Other: "Gagal mengenali pengguna"},
+	"error.request":                 {Other: "Gagal memproses permintaanmu"},
+	"error.createChat":              {Other: "Gagal membuat percakapan"},
+	"error.getChat":                 {Other: "Gagal mengambil percakapan"},
+	"error.deleteChat":              {Other: "Gagal menghapus percakapan"},
+	"error.analysis":                {Other: "Gagal memproses analisis"},
+	"error.entries":                 {Other: "Gagal mengambil catatan jurnal"},
+	"error.export":                  {Other: "Gagal mengekspor jurnal"},
+	"error.chart":                   {Other: "Gagal membuat grafik suasana hati"},
+	"error.photo":                   {Other: "Gagal mengunduh fotomu"},
+	"error.voice":                   {Other: "Gagal mengunduh pesan suaramu"},
+	"error.transcribe":              {Other: "Gagal menuliskan pesan suaramu"},
+	"error.saveKey":                 {Other: "Gagal menyimpan kunci API Gemini-mu"},
+	"error.removeKey":               {Other: "Gagal menghapus kunci API Gemini-mu"},
+	"timezone.prompt":               {Other: "Kamu berada di zona waktu mana? Journie memakainya untuk mengingatkanmu pada jam pengingatmu, pukul 22.00 kecuali kamu mengubahnya di /settings, dan menutup hari jurnalmu pukul 04.00.\n\nPilih salah satu di bawah, atau kirim /timezone diikuti zona waktumu, misalnya /timezone Asia/Jakarta. Sampai saat itu, %s yang dipakai."},
+	"timezone.current":              {Other: "Zona waktumu %s.\n\nUntuk mengubahnya, pilih salah satu di bawah atau kirim /timezone diikuti zona waktumu, misalnya /timezone Asia/Jakarta."},
+	"timezone.updated":              {Other: "Zona waktu diatur ke %s, di sana sekarang pukul %s."},
+	"timezone.invalid":              {Other: "Maaf, aku tidak mengenali zona waktu %q. Gunakan nama dari https://en.wikipedia.org/wiki/List_of_tz_database_time_zones, misalnya Asia/Jakarta."},
+	"settings.menu":                 {Other: "⚙️ Pengaturanmu. Ketuk salah satu untuk mengubahnya."},
+	"settings.options":              {Other: "%s, pilih salah satu:"},
+	"settings.saved":                {Other: "Tersimpan!"},
+	"settings.invalid":              {Other: "Maaf, pilihan itu sudah tidak tersedia. Kirim /settings untuk melihat pilihan yang ada."},
+	"settings.on":                   {Other: "Aktif"},
+	"settings.off":                  {Other: "Mati"},
+	"setting.reminders":             {Other: "🔔 Pengingat"},
+	"setting.reminderHour":          {Other: "⏰ Jam pengingat"},
+	"setting.language":              {Other: "🌐 Bahasa"},
+	"setting.persona":               {Other: "🎭 Persona"},
+	"setting.summaryStyle":          {Other: "📝 Gaya ringkasan"},
+	"option.language.auto":          {Other: "Otomatis"},
+	"option.persona.gentle":         {Other: "Pendengar yang lembut"},
+	"option.persona.socratic":       {Other: "Pelatih Sokratik"},
+	"option.persona.stoic":          {Other: "Mentor Stoik"},
+	"option.persona.brief":          {Other: "Pencatat singkat"},
+	"option.summaryStyle.paragraph": {Other: "Paragraf"},
+	"option.summaryStyle.bullets":   {Other: "Poin-poin"},
+	"option.summaryStyle.brief":     {Other: "Satu atau dua kalimat"},
+	"persona.menu":                  {Other: "🎭 Persona saat ini: %s\n\n%s\n\nPilih yang lain di bawah, atau kirim /persona diikuti namanya."},
+	"persona.updated":               {Other: "Persona diubah ke %s. Journie akan berbicara seperti ini mulai pesanmu berikutnya."},
+	"persona.invalid":               {Other: "Maaf, tidak ada persona %q. Pilih salah satu: %s."},
+	"description.persona.gentle":    {Other: "Mendengarkan dengan empati dan menanyakan perasaanmu"},
+	"description.persona.socratic":  {Other: "Mengajukan pertanyaan terbuka yang menuntunmu ke pemahamanmu sendiri"},
+	"description.persona.stoic":     {Other: "Membantumu merenung dengan tenang tentang hal yang bisa kamu kendalikan"},
+	"description.persona.brief":     {Other: "Membalas dengan singkat dan apa adanya"},
+	"export.empty":                  {Other: "Kamu belum punya catatan jurnal untuk diekspor. Catatan disimpan di akhir setiap hari kamu mengobrol dengan Journie."},
+	"export.usage":                  {Other: "Cara pakai: /export [%s]. Bawaannya %s."},
+	"export.caption":                {Other: "Jurnal Journie-mu, %d catatan."},
+	"history.empty":                 {Other: "Kamu belum punya catatan jurnal. Catatan disimpan di akhir setiap hari kamu mengobrol dengan Journie."},
+	"history.noNewer":               {Other: "Ini catatan terbarumu."},
+	"history.noOlder":               {Other: "Ini catatan pertamamu."},
+	"history.jumpPrompt":            {Other: "Tanggal berapa yang ingin kamu baca? Balas dengan tanggal seperti 2024-05-20."},
+	"history.mood":                  {Other: "Suasana hati: %s"},
+	"history.photos":                {Other: "📷 %d foto"},
+	"history.invalidDate":           {Other: "Maaf, aku tidak mengerti tanggal %q. Gunakan format 2024-05-20."},
+	"history.noneBefore":            {Other: "Kamu tidak punya catatan jurnal pada atau sebelum %s."},
+	"mood.usage":                    {Other: "Cara pakai: /mood [%s]. Bawaannya %s."},
+	"mood.empty":                    {Other: "Kamu belum punya catatan jurnal dalam satu %s terakhir, jadi belum ada yang bisa digambarkan."},
+	"mood.caption":                  {Other: "Suasana hatimu dalam satu %[2]s terakhir, dari %[1]d catatan."},
+	"period.week":                   {Other: "minggu"},
+	"period.month":                  {Other: "bulan"},
+	"period.year":                   {Other: "tahun"},
+	"mood.happy":                    {Other: "senang"},
+	"mood.surprise":                 {Other: "terkejut"},
+	"mood.neutral":                  {Other: "netral"},
+	"mood.sad":                      {Other: "sedih"},
+	"mood.fear":                     {Other: "takut"},
+	"mood.disgust":                  {Other: "jijik"},
+	"mood.anger":                    {Other: "marah"},
+	"gemini.saved":                  {Other: "Kunci API Gemini-mu sudah disimpan dan dienkripsi. Journie akan memakainya mulai sekarang, sapa saja Hai! Untuk berhenti memakainya, kirim /gemini_key remove."},
+	"gemini.removed":                {Other: "Kunci API Gemini-mu sudah dihapus. Journie akan memakai kunci bersama mulai sekarang."},
+	"gemini.invalid":                {Other: "Gemini tidak menerima kunci API itu. Pastikan kamu menyalin seluruh kunci, atau buat yang baru dengan /gemini_key."},
+	"gemini.notFound":               {Other: "Itu sepertinya bukan kunci API Gemini. Kunci diawali dengan AIza, kirim /gemini_key untuk melihat cara mendapatkannya."},
+	"gemini.unavailable":            {Other: "Maaf, Journie ini belum mendukung kunci API Gemini milikmu sendiri. Kamu tetap bisa memakai kunci bersama."},
+	"gemini.rejected":               {Other: "Gemini menolak kunci API-mu, mungkin sudah dihapus atau dibatasi. Aku sudah menghapusnya dan akan memakai kunci bersama mulai pesanmu berikutnya. Kirim kunci baru kapan saja dengan /gemini_key."},
+	"gemini.forbidden":              {Other: "Gemini menolak permintaan ini dengan kunci API-mu, misalnya karena wilayahmu, penagihan, atau API yang dinonaktifkan di proyekmu. Kuncimu tetap disimpan, periksa di Google AI Studio, atau kirim /gemini_key remove untuk memakai kunci bersama."},
+	"gemini.instructions": {Other: `*Dapatkan Kunci API Gemini-mu (Untuk Sekarang Perlu Komputer)*
+
+*Perhatian!* Saat ini, kunci API hanya bisa dibuat di komputer melalui Google AI Studio. Ikuti beberapa langkah mudah ini:
+
+	1. *Buka Google AI Studio:*  Buka browser di komputermu dan kunjungi tautan ini: [https://aistudio.google.com/app/apikey](https://aistudio.google.com/app/apikey)
+
+	2. *Masuk ke Google (jika perlu):* Kamu mungkin diminta masuk dengan akun Google-mu. Silakan masuk!
+
+	3. *Pilih "Get API key":*  Setelah masuk, kamu akan melihat pilihan seperti "New Project" atau "Get API key". Pilih *Get API key*.
+
+	4. *Buat proyek baru:*  Klik *"Create API key in new project"*. Ini akan membuat proyek baru untuk kunci API Journie-mu.
+
+	5. *Salin kunci API-mu:*  Jendela baru akan muncul menampilkan kunci API-mu. *Salin* kunci ini.
+
+	6. *Kirim kuncimu dan sapa Hai:*  Kembali ke Journie dan kirim kuncinya ke obrolan. Setelah itu, cukup sapa *"Hai"* dan petualangan jurnalmu dimulai!
+
+`},
+	"gemini.reason": {Other: `*Tenang, membuat kunci API itu aman dan gratis!*
+
+Kami paham kamu mungkin ragu membuat kunci API. Inilah kenapa langkah ini aman dan penting untuk Journie:
+
+	· *Keamanan:*  Semua proses terjadi di Google AI Studio, platform aman dari Google. Kami tidak pernah meminta info pembayaranmu, dan kunci API itu sendiri tidak memberi akses ke data sensitif apa pun.
+
+	· *Pengalaman lebih baik:*  Kunci API membantu Journie mengenalimu dan menghindari batas penggunaan. Jadi obrolanmu lebih lancar dan balasannya lebih cepat tanpa gangguan.
+
+	· *Menjaga Journie tetap gratis:*  Kunci API membantu kami mencegah penyalahgunaan oleh spammer dan bot. Program otomatis ini bisa mengirim banyak sekali permintaan, yang mahal untuk kami tanggung. Dengan membatasi akses lewat kunci API, Journie tetap gratis untuk semua orang.
+
+Anggap kunci API sebagai jabat tangan yang memungkinkan Journie memakai kemampuan Google Gemini untuk memberimu pengalaman jurnal yang personal.
+
+Siap memulai? Ayo ke Google AI Studio!
+
+`},
+}
@@ -0,0 +1,139 @@
+package i18n
+
+var ja = Catalog{
+	"welcome": {Other: `
+こんにちは、%s さん！
+
+Journie へようこそ。あなただけのプライベートで楽しい日記のパートナーです。
+
+ここでは、あなたの過去の日記を覚えているフレンドリーなジーニーとおしゃべりしながら、自分の考えや気持ちを見つめることができます。
+
+*こんにちは* と送るだけで始められます！
+	`},
+	"reminder":                      {Other: "こんにちは、5 分だけ日記を書いてみませんか？"},
+	"video.unsupported":             {Other: "ごめんなさい！今のところ動画は処理できません。"},
+	"session.deleted":               {Other: "会話を削除しました"},
+	"button.back":                   {Other: "« 戻る"},
+	"button.newer":                  {Other: "« 前へ"},
+	"button.older":                  {Other: "次へ »"},
+	"button.jump":                   {Other: "日付へ移動"},
+	"button.geminiWhy":              {Other: "なぜ必要なの？"},
+	"quota.shared":                  {Other: "Journie の共有 Gemini キーは今日の上限に達しました。/gemini_key で自分の無料キーを追加すると日記を続けられます。または明日また来てください。"},
+	"limit.seconds":                 {Other: "書くのが速すぎて追いつけません 🙂 ひと息ついてください。%d 秒後に続けられます。"},
+	"limit.minutes":                 {Other: "書くのが速すぎて追いつけません 🙂 ひと息ついてください。%d 分後に続けられます。"},
+	"limit.daily":                   {Other: "今日受け取れるメッセージはここまでです 🌙 あなたの時間で %s から続けられます。"},
+	"limit.dailyOwnKey":             {Other: "/gemini_key で自分の無料 Gemini キーを追加すると上限が上がります。"},
+	"safety.prompt":                 {Other: "ごめんなさい、そのメッセージには返信できませんでした。とても重いことについてのお話のようですね。あなたの気持ちは大切です。\n\nつらい時期を過ごしているなら、信頼できる人や地域の相談窓口に連絡することを考えてみてください。私にはできない形で支えてくれます。\n\n準備ができたら、別の言葉で今日のことを話し続けてください。私はここにいます。"},
+	"safety.response":               {Other: "返信を書き始めたのですが、最後まで書けませんでした。ごめんなさい。あなたのメッセージはここでは歓迎されています。もう少し詳しく、または別の形で伝えてもらえますか？"},
+	"voice.transcript":              {Other: "🎙️ 聞き取った内容：\n\n%s"},
+	"voice.empty":                   {Other: "そのボイスメッセージからは言葉が聞き取れませんでした。もう一度試すか、文字で入力してもらえますか？"},
+	"voice.tooLong":                 {Other: "そのボイスメッセージは長すぎて聞けません。短く分けて送ってもらえますか？"},
+	"voice.unavailable":             {Other: "ごめんなさい！この Journie はまだボイスメッセージを聞けません。日記は文字で入力してください。"},
+	"error.user":                    {Other: "ユーザーを特定できませんでした"},
+	"error.request":                 {Other: "リクエストの処理中にエラーが発生しました"},
+	"error.createChat":              {Other: "会話の作成中にエラーが発生しました"},
+	"error.getChat":                 {Other: "会話の取得中にエラーが発生しました"},
+	"error.deleteChat":              {Other: "会話の削除中にエラーが発生しました"},
+	"error.analysis":                {Other: "分析の処理中にエラーが発生しました"},
+	"error.entries":                 {Other: "日記の取得中にエラーが発生しました"},
+	"error.export":                  {Other: "日記のエクスポート中にエラーが発生しました"},
+	"error.chart":                   {Other: "気分グラフの作成中にエラーが発生しました"},
+	"error.photo":                   {Other: "写真のダウンロード中にエラーが発生しました"},
+	"error.voice":                   {Other: "ボイスメッセージのダウンロード中にエラーが発生しました"},
+	"error.transcribe":              {Other: "ボイスメッセージの文字起こし中にエラーが発生しました"},
+	"error.saveKey":                 {Other: "Gemini API キーの保存中にエラーが発生しました"},
+	"error.removeKey":               {Other: "Gemini API キーの削除中にエラーが発生しました"},
+	"timezone.prompt":               {Other: "どのタイムゾーンにいますか？Journie はこれを使ってリマインドの時刻（/settings で変えない限り午後 10 時）にリマインドし、午前 4 時に日記の 1 日を締めくくります。\n\n下から選ぶか、/timezone に続けてタイムゾーンを送ってください（例：/timezone Asia/Tokyo）。それまでは %s を使います。"},
+	"timezone.current":              {Other: "あなたのタイムゾーンは %s です。\n\n変更するには、下から選ぶか、/timezone に続けてタイムゾーンを送ってください（例：/timezone Asia/Tokyo）。"},
+	"timezone.updated":              {Other: "タイムゾーンを %s に設定しました。現地は今 %s です。"},
+	"timezone.invalid":              {Other: "ごめんなさい、タイムゾーン %q がわかりません。https://en.wikipedia.org/wiki/List_of_tz_database_time_zones にある名前を使ってください（例：Asia/Tokyo）。"},
+	"settings.menu":                 {Other: "⚙️ あなたの設定です。変更したい項目をタップしてください。"},
+	"settings.options":              {Other: "%s：ひとつ選んでください"},
+	"settings.saved":                {Other: "保存しました！"},
+	"settings.invalid":              {Other: "ごめんなさい、その選択肢はもう使えません。/settings を送って現在の選択肢を確認してください。"},
+	"settings.on":                   {Other: "オン"},
+	"settings.off":                  {Other: "オフ"},
+	"setting.reminders":             {Other: "🔔 リマインダー"},
+	"setting.reminderHour":          {Other: "⏰ リマインドの時刻"},
+	"setting.language":              {Other: "🌐 言語"},
+	"setting.persona":               {Other: "🎭 キャラクター"},
+	"setting.summaryStyle":          {Other: "📝 まとめのスタイル"},
+	"option.language.auto":          {Other: "自動"},
+	"option.persona.gentle":         {Other: "やさしい聞き手"},
+	"option.persona.socratic":       {Other: "ソクラテス式コーチ"},
+	"option.persona.stoic":          {Other: "ストア派のメンター"},
+	"option.persona.brief":          {Other: "簡潔な記録係"},
+	"option.summaryStyle.paragraph": {Other: "段落"},
+	"option.summaryStyle.bullets":   {Other: "箇条書き"},
+	"option.summaryStyle.brief":     {Other: "1〜2 文"},
+	"persona.menu":                  {Other: "🎭 現在のペルソナ：%s\n\n%s\n\n下から別のペルソナを選ぶか、/persona の後に名前を送ってください。"},
+	"persona.updated":               {Other: "ペルソナを %s に設定しました。次のメッセージから Journie はこの話し方になります。"},
+	"persona.invalid":               {Other: "すみません、ペルソナ %q はありません。次から選んでください：%s。"},
+	"description.persona.gentle":    {Other: "共感しながら耳を傾け、あなたの気持ちを尋ねます"},
+	"description.persona.socratic":  {Other: "問いかけを通して、あなた自身の気づきへと導きます"},
+	"description.persona.stoic":     {Other: "自分でコントロールできることを落ち着いて振り返る手助けをします"},
+	"description.persona.brief":     {Other: "短く事実に沿って返信します"},
+	"export.empty":                  {Other: "エクスポートできる日記はまだありません。日記は Journie とおしゃべりした日の終わりに保存されます。"},
+	"export.usage":                  {Other: "使い方：/export [%s]。既定は %s です。"},
+	"export.caption":                {Other: "あなたの Journie 日記、%d 件。"},
+	"history.empty":                 {Other: "日記はまだありません。日記は Journie とおしゃべりした日の終わりに保存されます。"},
+	"history.noNewer":               {Other: "これが最新の日記です。"},
+	"history.noOlder":               {Other: "これが最初の日記です。"},
+	"history.jumpPrompt":            {Other: "どの日付の日記を読みますか？2024-05-20 のような日付で返信してください。"},
+	"history.mood":                  {Other: "気分：%s"},
+	"history.photos":                {Other: "📷 写真 %d 枚"},
+	"history.invalidDate":           {Other: "ごめんなさい、%q という日付がわかりません。2024-05-20 の形式で入力してください。"},
+	"history.noneBefore":            {Other: "%s 以前の日記はありません。"},
+	"mood.usage":                    {Other: "使い方：/mood [%s]。既定は %s です。"},
+	"mood.empty":                    {Other: "この 1 %s の日記がまだないので、グラフにするものがありません。"},
+	"mood.caption":                  {Other: "この 1 %[2]s の気分、%[1]d 件の日記より。"},
+	"period.week":                   {Other: "週間"},
+	"period.month":                  {Other: "か月"},
+	"period.year":                   {Other: "年"},
+	"mood.happy":                    {Other: "うれしい"},
+	"mood.surprise":                 {Other: "おどろき"},
+	"mood.neutral":                  {Other: "ふつう"},
+	"mood.sad":                      {Other: "かなしい"},
+	"mood.fear":                     {Other: "こわい"},
+	"mood.disgust":                  {Other: "いや"},
+	"mood.anger":                    {Other: "いかり"},
+	"gemini.saved":                  {Other: "Gemini API キーを暗号化して保存しました。これからは Journie がこのキーを使います。こんにちはと送ってください！使用をやめるには /gemini_key remove を送ってください。"},
+	"gemini.removed":                {Other: "Gemini API キーを削除しました。これからは Journie が共有キーを使います。"},
+	"gemini.invalid":                {Other: "Gemini がその API キーを受け付けませんでした。キー全体をコピーしたか確認するか、/gemini_key で新しく作成してください。"},
+	"gemini.notFound":               {Other: "Gemini API キーではないようです。キーは AIza で始まります。取得方法は /gemini_key を送って確認してください。"},
+	"gemini.unavailable":            {Other: "ごめんなさい、この Journie はまだ自分の Gemini API キーに対応していません。共有キーをそのまま使えます。"},
+	"gemini.rejected":               {Other: "Gemini があなたの API キーを拒否しました。削除または制限された可能性があります。キーを削除し、次のメッセージから共有キーを使います。新しいキーはいつでも /gemini_key で送れます。"},
+	"gemini.forbidden":              {Other: "Gemini があなたの API キーでのこのリクエストを拒否しました。地域、請求、またはプロジェクトで API が無効になっていることなどが原因です。キーはそのまま保存しています。Google AI Studio で確認するか、/gemini_key remove を送って共有キーを使ってください。"},
+	"gemini.instructions": {Other: `*Gemini API キーを取得しましょう（今のところパソコンが必要です）*
+
+*ご注意！* 現在、API キーはパソコンの Google AI Studio でしか作成できません。いくつかの簡単な手順で準備しましょう：
+
+	1. *Google AI Studio を開く：*  パソコンのブラウザで次のリンクにアクセスします：[https://aistudio.google.com/app/apikey](https://aistudio.google.com/app/apikey)
+
+	2. *Google にログイン（必要な場合）：* Google アカウントでのログインを求められたら、ログインしてください！
+
+	3. *"Get API key" を選ぶ：*  ログインすると "New Project" や "Get API key" などの選択肢が表示されます。*Get API key* を選びます。
+
+	4. *新しいプロジェクトを作成：*  *"Create API key in new project"* をクリックします。Journie 用の API キーのプロジェクトが作成されます。
+
+	5. *API キーをコピー：*  API キーが表示された新しいウィンドウが開きます。このキーを *コピー* します。
+
+	6. *キーを送ってあいさつ：*  Journie に戻ってキーをチャットに送ります。そのあと *"こんにちは"* と送れば、日記の冒険が始まります！
+
+`},
+	"gemini.reason": {Other: `*ご安心ください。API キーの作成は安全で無料です！*
+
+API キーの作成をためらう気持ちはわかります。このステップが安全で、Journie にとって大切な理由はこちらです：
+
+	· *安全性：*  手続きはすべて Google の安全なプラットフォーム、Google AI Studio の中で行われます。支払い情報をお聞きすることはなく、API キー自体も機密データへのアクセス権を与えません。
+
+	· *より快適に：*  API キーがあると Journie があなたを識別でき、利用上限を避けられます。やり取りがスムーズになり、返信も途切れず速くなります。
+
+	· *Journie を無料のままに：*  API キーはスパムやボットによる悪用を防ぐのに役立ちます。こうした自動プログラムは大量のリクエストを送り、運営コストがかさみます。API キーでアクセスを制限することで、Journie を誰でも無料で使い続けられます。
+
+API キーは、Journie が Google Gemini の力を借りてあなただけの日記体験を届けるための握手のようなものです。
+
+準備はできましたか？Google AI Studio へ行きましょう！
+
+`},
+}
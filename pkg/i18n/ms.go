@@ -0,0 +1,139 @@
+package i18n
+
+var ms = Catalog{
+	"welcome": {Other: `
+Hai %s\!
+
+Selamat datang ke Journie, teman menulis jurnal yang peribadi dan menyeronokkan\.
+
+Di sini, anda boleh berbual dengan jin yang mesra yang ingat catatan lama anda dan membantu anda meneroka fikiran dan perasaan anda\.
+
+Cuma sapa *Hai* dan kita boleh mula\!
+	`},
+	"reminder":                      {Other: "Hai, luangkan 5 minit untuk menulis jurnal hari ini!"},
+	"video.unsupported":             {Other: "Maaf! Buat masa ini saya belum boleh memproses video."},
+	"session.deleted":               {Other: "Perbualan dipadam"},
+	"button.back":                   {Other: "« Kembali"},
+	"button.newer":                  {Other: "« Sebelum"},
+	"button.older":                  {Other: "Seterusnya »"},
+	"button.jump":                   {Other: "Pergi ke tarikh"},
+	"button.geminiWhy":              {Other: "Kenapa saya perlukan ini?"},
+	"quota.shared":                  {Other: "Kunci Gemini kongsi Journie telah mencapai had untuk hari ini. Tambah kunci percuma anda sendiri dengan /gemini_key untuk terus menulis, atau cuba lagi esok."},
+	"limit.seconds":                 {Other: "Anda menulis lebih laju daripada yang saya mampu ikut 🙂 Tarik nafas, anda boleh sambung dalam %d saat."},
+	"limit.minutes":                 {Other: "Anda menulis lebih laju daripada yang saya mampu ikut 🙂 Tarik nafas, anda boleh sambung dalam %d minit."},
+	"limit.daily":                   {Other: "Itu sahaja mesej yang boleh saya terima hari ini 🌙 Anda boleh sambung mulai pukul %s waktu anda."},
+	"limit.dailyOwnKey":             {Other: "Tambah kunci Gemini percuma anda sendiri dengan /gemini_key untuk had yang lebih tinggi."},
+	"safety.prompt":                 {Other: "Maaf, saya tidak dapat membalas mesej itu. Nampaknya ia tentang sesuatu yang sangat berat, dan saya mahu anda tahu perasaan anda penting.\n\nJika anda sedang melalui masa yang sukar, cubalah menghubungi seseorang yang anda percayai atau talian bantuan tempatan, mereka boleh menyokong anda dengan cara yang saya tidak mampu.\n\nBila-bila anda bersedia, anda boleh terus bercerita tentang hari anda dengan perkataan lain, saya masih di sini."},
+	"safety.response":               {Other: "Saya mula membalas tetapi tidak dapat menghabiskannya, maaf ya. Mesej anda tetap dialu-alukan di sini. Boleh ceritakan sedikit lagi, atau kongsikan dengan cara lain?"},
+	"voice.transcript":              {Other: "🎙️ Saya dengar:\n\n%s"},
+	"voice.empty":                   {Other: "Saya tidak dapat mendengar sebarang perkataan dalam nota suara itu. Boleh cuba lagi, atau taip sahaja?"},
+	"voice.tooLong":                 {Other: "Nota suara itu terlalu panjang untuk saya dengar. Boleh pecahkan kepada nota yang lebih pendek?"},
+	"voice.unavailable":             {Other: "Maaf! Journie ini belum boleh mendengar nota suara, sila taip catatan anda."},
+	"error.user":                    {Other: "Ralat mengenal pasti pengguna"},
+	"error.request":                 {Other: "Ralat memproses permintaan anda"},
+	"error.createChat":              {Other: "Ralat mencipta perbualan"},
+	"error.getChat":                 {Other: "Ralat mendapatkan perbualan"},
+	"error.deleteChat":              {Other: "Ralat memadam perbualan"},
+	"error.analysis":                {Other: "Ralat memproses analisis"},
+	"error.entries":                 {Other: "Ralat mendapatkan catatan jurnal"},
+	"error.export":                  {Other: "Ralat mengeksport jurnal"},
+	"error.chart":                   {Other: "Ralat melukis carta emosi"},
+	"error.photo":                   {Other: "Ralat memuat turun foto anda"},
+	"error.voice":                   {Other: "Ralat memuat turun nota suara anda"},
+	"error.transcribe":              {Other: "Ralat menyalin nota suara anda"},
+	"error.saveKey":                 {Other: "Ralat menyimpan kunci API Gemini anda"},
+	"error.removeKey":               {Other: "Ralat membuang kunci API Gemini anda"},
+	"timezone.prompt":               {Other: "Anda berada di zon waktu mana? Journie menggunakannya untuk mengingatkan anda pada masa peringatan anda, pukul 10 malam melainkan anda menukarnya dalam /settings, dan menutup hari jurnal anda pada pukul 4 pagi.\n\nPilih satu di bawah, atau hantar /timezone diikuti zon waktu anda, contohnya /timezone Asia/Kuala_Lumpur. Sehingga itu, %s digunakan."},
+	"timezone.current":              {Other: "Zon waktu anda ialah %s.\n\nUntuk menukarnya, pilih satu di bawah atau hantar /timezone diikuti zon waktu anda, contohnya /timezone Asia/Kuala_Lumpur."},
+	"timezone.updated":              {Other: "Zon waktu ditetapkan kepada %s, di sana sekarang pukul %s."},
+	"timezone.invalid":              {Other: "Maaf, saya tidak mengenali zon waktu %q. Gunakan nama daripada https://en.wikipedia.org/wiki/List_of_tz_database_time_zones, contohnya Asia/Kuala_Lumpur."},
+	"settings.menu":                 {Other: "⚙️ Tetapan anda. Ketik satu untuk menukarnya."},
+	"settings.options":              {Other: "%s, pilih satu:"},
+	"settings.saved":                {Other: "Disimpan!"},
+	"settings.invalid":              {Other: "Maaf, pilihan itu tidak lagi tersedia. Hantar /settings untuk melihat pilihan semasa."},
+	"settings.on":                   {Other: "Hidup"},
+	"settings.off":                  {Other: "Mati"},
+	"setting.reminders":             {Other: "🔔 Peringatan"},
+	"setting.reminderHour":          {Other: "⏰ Masa peringatan"},
+	"setting.language":              {Other: "🌐 Bahasa"},
+	"setting.persona":               {Other: "🎭 Persona"},
+	"setting.summaryStyle":          {Other: "📝 Gaya ringkasan"},
+	"option.language.auto":          {Other: "Automatik"},
+	"option.persona.gentle":         {Other: "Pendengar yang lembut"},
+	"option.persona.socratic":       {Other: "Jurulatih Sokratik"},
+	"option.persona.stoic":          {Other: "Mentor Stoik"},
+	"option.persona.brief":          {Other: "Pencatat ringkas"},
+	"option.summaryStyle.paragraph": {Other: "Perenggan"},
+	"option.summaryStyle.bullets":   {Other: "Senarai titik"},
+	"option.summaryStyle.brief":     {Other: "Satu atau dua ayat"},
+	"persona.menu":                  {Other: "🎭 Persona semasa: %s\n\n%s\n\nPilih yang lain di bawah, atau hantar /persona diikuti namanya."},
+	"persona.updated":               {Other: "Persona ditukar kepada %s. Journie akan bercakap begini mulai mesej anda yang seterusnya."},
+	"persona.invalid":               {Other: "Maaf, tiada persona %q. Pilih salah satu: %s."},
+	"description.persona.gentle":    {Other: "Mendengar dengan empati dan bertanya tentang perasaan anda"},
+	"description.persona.socratic":  {Other: "Bertanya soalan terbuka yang membawa anda kepada kefahaman sendiri"},
+	"description.persona.stoic":     {Other: "Membantu anda merenung dengan tenang tentang perkara dalam kawalan anda"},
+	"description.persona.brief":     {Other: "Membalas dengan ringkas dan berfakta"},
+	"export.empty":                  {Other: "Anda belum ada catatan jurnal untuk dieksport. Catatan disimpan pada akhir setiap hari anda berbual dengan Journie."},
+	"export.usage":                  {Other: "Cara guna: /export [%s]. Lalainya %s."},
+	"export.caption":                {Other: "Jurnal Journie anda, %d catatan."},
+	"history.empty":                 {Other: "Anda belum ada catatan jurnal. Catatan disimpan pada akhir setiap hari anda berbual dengan Journie."},
+	"history.noNewer":               {Other: "Ini catatan terbaru anda."},
+	"history.noOlder":               {Other: "Ini catatan pertama anda."},
+	"history.jumpPrompt":            {Other: "Tarikh mana yang anda mahu baca? Balas dengan tarikh seperti 2024-05-20."},
+	"history.mood":                  {Other: "Emosi: %s"},
+	"history.photos":                {Other: "📷 %d foto"},
+	"history.invalidDate":           {Other: "Maaf, saya tidak faham tarikh %q. Gunakan format 2024-05-20."},
+	"history.noneBefore":            {Other: "Anda tiada catatan jurnal pada atau sebelum %s."},
+	"mood.usage":                    {Other: "Cara guna: /mood [%s]. Lalainya %s."},
+	"mood.empty":                    {Other: "Anda belum ada catatan jurnal dalam satu %s yang lalu, jadi tiada apa untuk dilukis."},
+	"mood.caption":                  {Other: "Emosi anda dalam satu %[2]s yang lalu, daripada %[1]d catatan."},
+	"period.week":                   {Other: "minggu"},
+	"period.month":                  {Other: "bulan"},
+	"period.year":                   {Other: "tahun"},
+	"mood.happy":                    {Other: "gembira"},
+	"mood.surprise":                 {Other: "terkejut"},
+	"mood.neutral":                  {Other: "neutral"},
+	"mood.sad":                      {Other: "sedih"},
+	"mood.fear":                     {Other: "takut"},
+	"mood.disgust":                  {Other: "jijik"},
+	"mood.anger":                    {Other: "marah"},
+	"gemini.saved":                  {Other: "Kunci API Gemini anda telah disimpan dan disulitkan. Journie akan menggunakannya mulai sekarang, sapa sahaja Hai! Untuk berhenti menggunakannya, hantar /gemini_key remove."},
+	"gemini.removed":                {Other: "Kunci API Gemini anda telah dibuang. Journie akan menggunakan kunci kongsi mulai sekarang."},
+	"gemini.invalid":                {Other: "Gemini tidak menerima kunci API itu. Pastikan anda menyalin keseluruhan kunci, atau cipta yang baru dengan /gemini_key."},
+	"gemini.notFound":               {Other: "Itu nampaknya bukan kunci API Gemini. Kunci bermula dengan AIza, hantar /gemini_key untuk melihat cara mendapatkannya."},
+	"gemini.unavailable":            {Other: "Maaf, Journie ini belum menyokong kunci API Gemini anda sendiri. Anda masih boleh menggunakan kunci kongsi."},
+	"gemini.rejected":               {Other: "Gemini menolak kunci API anda, mungkin ia telah dipadam atau dihadkan. Saya telah membuangnya dan akan menggunakan kunci kongsi mulai mesej anda yang seterusnya. Hantar kunci baharu bila-bila masa dengan /gemini_key."},
+	"gemini.forbidden":              {Other: "Gemini menolak permintaan ini dengan kunci API anda, contohnya kerana wilayah anda, pengebilan atau API yang dilumpuhkan dalam projek anda. Kunci anda disimpan, semaknya dalam Google AI Studio, atau hantar /gemini_key remove untuk menggunakan kunci kongsi."},
+	"gemini.instructions": {Other: `*Dapatkan Kunci API Gemini Anda (Buat Masa Ini Perlu Komputer)*
+
+*Perhatian!* Buat masa ini, kunci API hanya boleh dicipta di komputer melalui Google AI Studio. Ikuti beberapa langkah mudah ini:
+
+	1. *Pergi ke Google AI Studio:*  Buka pelayar di komputer anda dan layari pautan ini: [https://aistudio.google.com/app/apikey](https://aistudio.google.com/app/apikey)
+
+	2. *Log masuk ke Google (jika perlu):* Anda mungkin diminta log masuk dengan akaun Google anda. Teruskan!
+
+	3. *Pilih "Get API key":*  Selepas log masuk, anda akan melihat pilihan seperti "New Project" atau "Get API key". Pilih *Get API key*.
+
+	4. *Cipta projek baharu:*  Klik *"Create API key in new project"*. Ini akan menyediakan projek baharu untuk kunci API Journie anda.
+
+	5. *Salin kunci API anda:*  Tetingkap baharu akan muncul menunjukkan kunci API anda. *Salin* kunci ini.
+
+	6. *Hantar kunci anda dan sapa Hai:*  Kembali ke Journie dan hantar kunci itu dalam perbualan. Selepas itu, cuma sapa *"Hai"* dan pengembaraan jurnal anda bermula!
+
+`},
+	"gemini.reason": {Other: `*Jangan risau, mencipta kunci API adalah selamat dan percuma!*
+
+Kami faham anda mungkin ragu-ragu untuk mencipta kunci API. Inilah sebabnya langkah ini selamat dan penting untuk Journie:
+
+	· *Keselamatan:*  Proses ini berlaku sepenuhnya dalam Google AI Studio, platform selamat daripada Google. Kami tidak pernah meminta maklumat pembayaran anda, dan kunci API itu sendiri tidak memberi akses kepada sebarang data sensitif.
+
+	· *Pengalaman lebih baik:*  Kunci API membantu Journie mengenal pasti anda dan mengelakkan had penggunaan. Ini bermakna perbualan lebih lancar dan balasan lebih pantas tanpa gangguan.
+
+	· *Journie kekal percuma:*  Kunci API membantu kami mencegah penyalahgunaan oleh spammer dan bot. Program automatik ini boleh menghantar banyak permintaan, yang mahal untuk kami tanggung. Dengan mengehadkan akses melalui kunci API, Journie kekal percuma untuk semua.
+
+Anggaplah kunci API sebagai jabat tangan yang membolehkan Journie menggunakan keupayaan Google Gemini untuk memberi anda pengalaman jurnal yang diperibadikan.
+
+Bersedia untuk mula? Jom ke Google AI Studio!
+
+`},
+}
@@ -0,0 +1,139 @@
+package i18n
+
+var zh = Catalog{
+	"welcome": {Other: `
+你好，%s！
+
+欢迎来到 Journie，你私密又贴心的日记伙伴。
+
+在这里，你可以和一位友善的精灵聊天，它记得你以前写下的日记，并帮助你梳理自己的想法和感受。
+
+只要说一声 *你好*，我们就开始吧！
+	`},
+	"reminder":                      {Other: "你好，花 5 分钟写一篇日记吧！"},
+	"video.unsupported":             {Other: "抱歉！我暂时无法处理视频。"},
+	"session.deleted":               {Other: "对话已删除"},
+	"button.back":                   {Other: "« 返回"},
+	"button.newer":                  {Other: "« 上一篇"},
+	"button.older":                  {Other: "下一篇 »"},
+	"button.jump":                   {Other: "跳到日期"},
+	"button.geminiWhy":              {Other: "为什么需要这个？"},
+	"quota.shared":                  {Other: "Journie 的共享 Gemini 密钥今天已达到上限。用 /gemini_key 添加你自己的免费密钥即可继续写日记，或者明天再来。"},
+	"limit.seconds":                 {Other: "你写得太快啦，我有点跟不上 🙂 先深呼吸一下，%d 秒后可以继续。"},
+	"limit.minutes":                 {Other: "你写得太快啦，我有点跟不上 🙂 先深呼吸一下，%d 分钟后可以继续。"},
+	"limit.daily":                   {Other: "今天我能接收的消息就这么多了 🌙 你可以在你当地时间 %s 之后继续。"},
+	"limit.dailyOwnKey":             {Other: "用 /gemini_key 添加你自己的免费 Gemini 密钥即可获得更高的上限。"},
+	"safety.prompt":                 {Other: "抱歉，我无法回复这条消息。这听起来可能是一件非常沉重的事，我想让你知道，你的感受很重要。\n\n如果你正在经历困难，请考虑联系你信任的人或当地的求助热线，他们能以我做不到的方式支持你。\n\n等你准备好了，可以换一种说法继续告诉我你的一天，我一直都在。"},
+	"safety.response":               {Other: "我开始回复了，但没能把话说完，抱歉。这里依然欢迎你的消息。能再多说一点，或者换一种方式分享吗？"},
+	"voice.transcript":              {Other: "🎙️ 我听到的是：\n\n%s"},
+	"voice.empty":                   {Other: "我在那条语音里没有听到任何内容。可以再试一次，或者直接打字吗？"},
+	"voice.tooLong":                 {Other: "那条语音太长了，我听不完。可以拆成几条短一点的语音吗？"},
+	"voice.unavailable":             {Other: "抱歉！这个 Journie 还不能收听语音，请用文字写下你的日记。"},
+	"error.user":                    {Other: "无法识别用户"},
+	"error.request":                 {Other: "处理你的请求时出错"},
+	"error.createChat":              {Other: "创建对话时出错"},
+	"error.getChat":                 {Other: "获取对话时出错"},
+	"error.deleteChat":              {Other: "删除对话时出错"},
+	"error.analysis":                {Other: "处理分析结果时出错"},
+	"error.entries":                 {Other: "获取日记时出错"},
+	"error.export":                  {Other: "导出日记时出错"},
+	"error.chart":                   {Other: "绘制心情图表时出错"},
+	"error.photo":                   {Other: "下载你的照片时出错"},
+	"error.voice":                   {Other: "下载你的语音时出错"},
+	"error.transcribe":              {Other: "转写你的语音时出错"},
+	"error.saveKey":                 {Other: "保存你的 Gemini API 密钥时出错"},
+	"error.removeKey":               {Other: "移除你的 Gemini API 密钥时出错"},
+	"timezone.prompt":               {Other: "你在哪个时区？Journie 会用它在你的提醒时间（除非在 /settings 中更改，否则为晚上 10 点）提醒你，并在凌晨 4 点结束你一天的日记。\n\n在下面选一个，或者发送 /timezone 加上你的时区，例如 /timezone Asia/Shanghai。在此之前，将使用 %s。"},
+	"timezone.current":              {Other: "你的时区是 %s。\n\n要更改，请在下面选一个，或者发送 /timezone 加上你的时区，例如 /timezone Asia/Shanghai。"},
+	"timezone.updated":              {Other: "时区已设为 %s，那里现在是 %s。"},
+	"timezone.invalid":              {Other: "抱歉，我不认识时区 %q。请使用 https://en.wikipedia.org/wiki/List_of_tz_database_time_zones 中的名称，例如 Asia/Shanghai。"},
+	"settings.menu":                 {Other: "⚙️ 你的设置。点一项即可更改。"},
+	"settings.options":              {Other: "%s，请选择："},
+	"settings.saved":                {Other: "已保存！"},
+	"settings.invalid":              {Other: "抱歉，该选项已不可用。发送 /settings 查看当前选项。"},
+	"settings.on":                   {Other: "开"},
+	"settings.off":                  {Other: "关"},
+	"setting.reminders":             {Other: "🔔 提醒"},
+	"setting.reminderHour":          {Other: "⏰ 提醒时间"},
+	"setting.language":              {Other: "🌐 语言"},
+	"setting.persona":               {Other: "🎭 角色"},
+	"setting.summaryStyle":          {Other: "📝 摘要风格"},
+	"option.language.auto":          {Other: "自动"},
+	"option.persona.gentle":         {Other: "温柔的倾听者"},
+	"option.persona.socratic":       {Other: "苏格拉底式教练"},
+	"option.persona.stoic":          {Other: "斯多葛导师"},
+	"option.persona.brief":          {Other: "简短记录员"},
+	"option.summaryStyle.paragraph": {Other: "段落"},
+	"option.summaryStyle.bullets":   {Other: "要点列表"},
+	"option.summaryStyle.brief":     {Other: "一两句话"},
+	"persona.menu":                  {Other: "🎭 当前角色：%s\n\n%s\n\n在下面选择其他角色，或者发送 /persona 加上角色名。"},
+	"persona.updated":               {Other: "角色已设为 %s。从你的下一条消息起，Journie 会以这种方式和你交谈。"},
+	"persona.invalid":               {Other: "抱歉，没有角色 %q。请从以下选择：%s。"},
+	"description.persona.gentle":    {Other: "带着同理心倾听，关心你的感受"},
+	"description.persona.socratic":  {Other: "用开放式问题引导你找到自己的答案"},
+	"description.persona.stoic":     {Other: "帮助你冷静反思哪些事在你的掌控之中"},
+	"description.persona.brief":     {Other: "回复简短、实事求是"},
+	"export.empty":                  {Other: "你还没有可以导出的日记。每天和 Journie 聊天结束时，日记就会被保存。"},
+	"export.usage":                  {Other: "用法：/export [%s]。默认为 %s。"},
+	"export.caption":                {Other: "你的 Journie 日记，共 %d 篇。"},
+	"history.empty":                 {Other: "你还没有日记。每天和 Journie 聊天结束时，日记就会被保存。"},
+	"history.noNewer":               {Other: "这是你最新的一篇日记。"},
+	"history.noOlder":               {Other: "这是你的第一篇日记。"},
+	"history.jumpPrompt":            {Other: "你想看哪一天的日记？请回复一个日期，例如 2024-05-20。"},
+	"history.mood":                  {Other: "心情：%s"},
+	"history.photos":                {Other: "📷 %d 张照片"},
+	"history.invalidDate":           {Other: "抱歉，我看不懂日期 %q。请使用 2024-05-20 这样的格式。"},
+	"history.noneBefore":            {Other: "你在 %s 及之前没有日记。"},
+	"mood.usage":                    {Other: "用法：/mood [%s]。默认为 %s。"},
+	"mood.empty":                    {Other: "你在过去一%s里还没有日记，所以没有可以绘制的内容。"},
+	"mood.caption":                  {Other: "你过去一%[2]s的心情，来自 %[1]d 篇日记。"},
+	"period.week":                   {Other: "周"},
+	"period.month":                  {Other: "个月"},
+	"period.year":                   {Other: "年"},
+	"mood.happy":                    {Other: "开心"},
+	"mood.surprise":                 {Other: "惊讶"},
+	"mood.neutral":                  {Other: "平静"},
+	"mood.sad":                      {Other: "难过"},
+	"mood.fear":                     {Other: "害怕"},
+	"mood.disgust":                  {Other: "厌恶"},
+	"mood.anger":                    {Other: "生气"},
+	"gemini.saved":                  {Other: "你的 Gemini API 密钥已保存并加密。从现在起 Journie 会使用它，说声你好就行！如需停用，请发送 /gemini_key remove。"},
+	"gemini.removed":                {Other: "你的 Gemini API 密钥已移除。从现在起 Journie 会使用共享密钥。"},
+	"gemini.invalid":                {Other: "Gemini 不接受这个 API 密钥。请确认你复制了完整的密钥，或用 /gemini_key 创建一个新的。"},
+	"gemini.notFound":               {Other: "这看起来不像 Gemini API 密钥。密钥以 AIza 开头，发送 /gemini_key 查看获取方法。"},
+	"gemini.unavailable":            {Other: "抱歉，这个 Journie 暂不支持使用你自己的 Gemini API 密钥。你可以继续使用共享密钥。"},
+	"gemini.rejected":               {Other: "Gemini 拒绝了你的 API 密钥，它可能已被删除或受到限制。我已将其移除，从你的下一条消息起使用共享密钥。随时可以用 /gemini_key 发送新的密钥。"},
+	"gemini.forbidden":              {Other: "Gemini 拒绝了使用你的 API 密钥的这次请求，例如因为你所在的地区、结算或项目中停用了该 API。你的密钥仍然保留，请在 Google AI Studio 中检查，或发送 /gemini_key remove 改用共享密钥。"},
+	"gemini.instructions": {Other: `*获取你的 Gemini API 密钥（目前需要电脑）*
+
+*注意！* 目前只能在电脑上通过 Google AI Studio 创建 API 密钥。按照下面几个简单步骤操作即可：
+
+	1. *打开 Google AI Studio：*  在电脑上打开浏览器，访问这个链接：[https://aistudio.google.com/app/apikey](https://aistudio.google.com/app/apikey)
+
+	2. *登录 Google（如有需要）：* 可能会要求你用 Google 账号登录，登录即可！
+
+	3. *选择 "Get API key"：*  登录后，你会看到 "New Project" 或 "Get API key" 等选项。选择 *Get API key*。
+
+	4. *创建新项目：*  点击 *"Create API key in new project"*，这会为你的 Journie API 密钥创建一个新项目。
+
+	5. *复制 API 密钥：*  弹出的新窗口会显示你的 API 密钥，把它 *复制* 到剪贴板。
+
+	6. *发送密钥并打个招呼：*  回到 Journie，把密钥发到聊天中。完成后，只要说一声 *"你好"*，我们就开始你的日记之旅！
+
+`},
+	"gemini.reason": {Other: `*别担心，创建 API 密钥既安全又免费！*
+
+我们理解你可能对创建 API 密钥有所顾虑。以下是这一步安全且对 Journie 很重要的原因：
+
+	· *安全：*  整个过程都在 Google 的安全平台 Google AI Studio 内完成。我们从不索取你的付款信息，API 密钥本身也无法访问任何敏感数据。
+
+	· *更好的体验：*  API 密钥帮助 Journie 识别你，并避免触发使用上限。这样对话更顺畅，回复更快，不会被打断。
+
+	· *让 Journie 保持免费：*  API 密钥帮助我们防止垃圾信息发送者和机器人滥用。这些自动程序会发送大量请求，维护成本很高。通过 API 密钥限制访问，我们就能让 Journie 对所有人保持免费。
+
+把 API 密钥想象成一次握手，让 Journie 借助 Google Gemini 的能力，为你提供个性化的日记体验。
+
+准备好了吗？我们去 Google AI Studio 吧！
+
+`},
+}
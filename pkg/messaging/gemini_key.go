@@ -0,0 +1,119 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	chatsession "journie/pkg/chat-session"
+	"journie/pkg/generative"
+	"journie/pkg/secrets"
+	"journie/pkg/templates"
+	"journie/pkg/users"
+	"log"
+	"strings"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+var btnWhy = selector.Data("", "gemini-reason")
+
+func registerGeminiKeyHandlers(bot *tele.Bot) {
+
+	// handle bring your own key, /gemini_key [remove|{key}]
+	bot.Handle("/gemini_key", func(c tele.Context) error {
+		var userId = int(c.Sender().ID)
+		platformUserId, err := GetPlatformUserId(fmt.Sprint(userId))
+		if err != nil {
+			log.Printf("Error handling user id %d: %v", userId, err)
+			return c.Send(templates.Error(languageOf(c), templates.ErrorUser))
+		}
+
+		payload := strings.TrimSpace(c.Message().Payload)
+		switch {
+		case payload == "":
+			return c.Send(templates.GeminiKeyInstructions(languageOf(c)), &tele.SendOptions{ParseMode: tele.ModeMarkdown, ReplyMarkup: geminiKeyMarkup(languageOf(c))})
+		case strings.EqualFold(payload, "remove"):
+			return removeGeminiKey(c, platformUserId)
+		}
+
+		key := generative.FindGeminiKey(payload)
+		if key == "" {
+			return c.Send(templates.GeminiKeyNotFound(languageOf(c)))
+		}
+
+		return saveGeminiKey(c, platformUserId, key)
+	})
+
+	// On inline button pressed (callback)
+	bot.Handle(&btnWhy, func(c tele.Context) error {
+		if err := c.Respond(); err != nil {
+			log.Printf("Error responding to callback for user %d: %v", c.Sender().ID, err)
+		}
+		return c.Send(templates.GeminiKeyReason(languageOf(c)), &tele.SendOptions{ParseMode: tele.ModeMarkdown})
+	})
+}
+
+func geminiKeyMarkup(lang string) *tele.ReplyMarkup {
+	markup := &tele.ReplyMarkup{}
+	markup.Inline(markup.Row(markup.Data(templates.GeminiKeyWhyButton(lang), btnWhy.Unique)))
+
+	return markup
+}
+
+// saveGeminiKey validates and stores key of user, removing the message containing it from the chat
+func saveGeminiKey(c tele.Context, platformUserId string, key string) error {
+	ctx := context.Background()
+
+	// do not leave the key lying around in chat history
+	if err := c.Delete(); err != nil {
+		log.Printf("Error deleting message with gemini key of user %s: %v", platformUserId, err)
+	}
+
+	if _, err := secrets.MasterKeyring(); err != nil {
+		log.Printf("Unable to store gemini key of user %s: %v", platformUserId, err)
+		return c.Send(templates.GeminiKeyUnavailable(languageOf(c)))
+	}
+
+	c.Bot().Notify(c.Sender(), tele.Typing)
+
+	if err := generative.ValidateKey(ctx, key); err != nil {
+		log.Printf("Gemini key of user %s failed validation: %v", platformUserId, err)
+		return c.Send(templates.GeminiKeyInvalid(languageOf(c)))
+	}
+
+	if err := users.SetGeminiKey(ctx, platformUserId, key); err != nil {
+		log.Printf("Error storing gemini key of user %s: %v", platformUserId, err)
+		return c.Send(templates.Error(languageOf(c), templates.ErrorSaveKey))
+	}
+
+	// continue today's conversation with the new key
+	chatsession.ChatSessionClient.EvictChatSession(platformUserId)
+
+	return c.Send(templates.GeminiKeySaved(languageOf(c)))
+}
+
+func removeGeminiKey(c tele.Context, platformUserId string) error {
+	if err := users.RemoveGeminiKey(context.Background(), platformUserId); err != nil {
+		log.Printf("Error removing gemini key of user %s: %v", platformUserId, err)
+		return c.Send(templates.Error(languageOf(c), templates.ErrorRemoveKey))
+	}
+
+	generative.GenAiClient.RemoveUserProvider(platformUserId)
+	chatsession.ChatSessionClient.EvictChatSession(platformUserId)
+
+	return c.Send(templates.GeminiKeyRemoved(languageOf(c)))
+}
+
+// fallbackToSharedKey removes a user's own key after Gemini rejected it, so the next message uses the shared key
+func fallbackToSharedKey(platform Platform, userId string) error {
+	platformUserId := PlatformUserId(platform.Name(), userId)
+	lang := users.GetLanguage(context.Background(), platformUserId)
+	if err := users.RemoveGeminiKey(context.Background(), platformUserId); err != nil {
+		log.Printf("Error removing rejected gemini key of user %s: %v", platformUserId, err)
+		return platform.SendText(userId, templates.Error(lang, templates.ErrorRequest))
+	}
+
+	generative.GenAiClient.RemoveUserProvider(platformUserId)
+	chatsession.ChatSessionClient.EvictChatSession(platformUserId)
+
+	return platform.SendText(userId, templates.GeminiKeyRejected(lang))
+}
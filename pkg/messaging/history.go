@@ -0,0 +1,142 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"journie/pkg/storage"
+	"journie/pkg/templates"
+	"log"
+	"strings"
+	"time"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+// history browser buttons, data carries the entry id (date) currently shown
+var (
+	btnHistoryNewer = selector.Data("", "history-newer")
+	btnHistoryOlder = selector.Data("", "history-older")
+	btnHistoryJump  = selector.Data("", "history-jump")
+)
+
+const pendingHistoryDate = "history-date"
+
+// historyDateLayout of dates to jump to, as entry ids
+const historyDateLayout = "2006-01-02"
+
+func registerHistoryHandlers(bot *tele.Bot) {
+	// handle history browser, /history [YYYY-MM-DD]
+	bot.Handle("/history", func(c tele.Context) error {
+		var userId = int(c.Sender().ID)
+		platformUserId, err := GetPlatformUserId(fmt.Sprint(userId))
+		if err != nil {
+			log.Printf("Error handling user id %d: %v", userId, err)
+			return c.Send(templates.Error(languageOf(c), templates.ErrorUser))
+		}
+
+		if payload := strings.TrimSpace(c.Message().Payload); payload != "" {
+			return sendHistoryAtDate(c, platformUserId, payload)
+		}
+
+		entries, err := storage.StoreClient.QueryEntries(context.Background(), platformUserId, storage.EntryQuery{Descending: true, Limit: 1})
+		if err != nil {
+			log.Printf("Error retrieving entries for user %d: %v", userId, err)
+			return c.Send(templates.Error(languageOf(c), templates.ErrorEntries))
+		}
+
+		if len(entries) == 0 {
+			return c.Send(templates.HistoryEmpty(languageOf(c)))
+		}
+
+		return c.Send(templates.HistoryEntry(languageOf(c), &entries[0]), historyMarkup(languageOf(c), entries[0].Id))
+	})
+
+	// On prev pressed, show the next newer entry
+	bot.Handle(&btnHistoryNewer, func(c tele.Context) error {
+		return editHistory(c, storage.EntryQuery{After: c.Data(), Limit: 1}, templates.HistoryNoNewer(languageOf(c)))
+	})
+
+	// On next pressed, show the next older entry
+	bot.Handle(&btnHistoryOlder, func(c tele.Context) error {
+		return editHistory(c, storage.EntryQuery{Before: c.Data(), Descending: true, Limit: 1}, templates.HistoryNoOlder(languageOf(c)))
+	})
+
+	// On jump pressed, wait for user to send a date
+	bot.Handle(&btnHistoryJump, func(c tele.Context) error {
+		pending.Set(c.Sender().ID, pendingHistoryDate)
+
+		if err := c.Respond(); err != nil {
+			log.Printf("Error responding to callback for user %d: %v", c.Sender().ID, err)
+		}
+
+		return c.Send(templates.HistoryJumpPrompt(languageOf(c)))
+	})
+}
+
+func historyMarkup(lang string, entryId string) *tele.ReplyMarkup {
+	markup := &tele.ReplyMarkup{}
+	markup.Inline(
+		markup.Row(
+			markup.Data(templates.HistoryNewerButton(lang), btnHistoryNewer.Unique, entryId),
+			markup.Data(templates.HistoryOlderButton(lang), btnHistoryOlder.Unique, entryId),
+		),
+		markup.Row(
+			markup.Data(templates.HistoryJumpButton(lang), btnHistoryJump.Unique, entryId),
+		),
+	)
+
+	return markup
+}
+
+// editHistory replaces the message of the pressed button with the first entry matching query
+func editHistory(c tele.Context, query storage.EntryQuery, notFound string) error {
+	var userId = int(c.Sender().ID)
+	platformUserId, err := GetPlatformUserId(fmt.Sprint(userId))
+	if err != nil {
+		log.Printf("Error handling user id %d: %v", userId, err)
+		return c.Respond(&tele.CallbackResponse{Text: templates.Error(languageOf(c), templates.ErrorUser)})
+	}
+
+	entries, err := storage.StoreClient.QueryEntries(context.Background(), platformUserId, query)
+	if err != nil {
+		log.Printf("Error retrieving entries for user %d: %v", userId, err)
+		return c.Respond(&tele.CallbackResponse{Text: templates.Error(languageOf(c), templates.ErrorEntries)})
+	}
+
+	if len(entries) == 0 {
+		return c.Respond(&tele.CallbackResponse{Text: notFound})
+	}
+
+	if err := c.Respond(); err != nil {
+		log.Printf("Error responding to callback for user %d: %v", userId, err)
+	}
+
+	return c.Edit(templates.HistoryEntry(languageOf(c), &entries[0]), historyMarkup(languageOf(c), entries[0].Id))
+}
+
+// isHistoryDate reports whether text is a date sendHistoryAtDate can jump to
+func isHistoryDate(text string) bool {
+	_, err := time.Parse(historyDateLayout, text)
+	return err == nil
+}
+
+// sendHistoryAtDate sends the entry of date, or the closest one before it
+func sendHistoryAtDate(c tele.Context, platformUserId string, date string) error {
+	day, err := time.Parse(historyDateLayout, date)
+	if err != nil {
+		return c.Send(templates.HistoryInvalidDate(languageOf(c), date))
+	}
+
+	nextDay := day.AddDate(0, 0, 1).Format("2006-01-02")
+	entries, err := storage.StoreClient.QueryEntries(context.Background(), platformUserId, storage.EntryQuery{Before: nextDay, Descending: true, Limit: 1})
+	if err != nil {
+		log.Printf("Error retrieving entries for user %s: %v", platformUserId, err)
+		return c.Send(templates.Error(languageOf(c), templates.ErrorEntries))
+	}
+
+	if len(entries) == 0 {
+		return c.Send(templates.HistoryNoneBefore(languageOf(c), date))
+	}
+
+	return c.Send(templates.HistoryEntry(languageOf(c), &entries[0]), historyMarkup(languageOf(c), entries[0].Id))
+}
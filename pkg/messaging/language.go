@@ -0,0 +1,48 @@
+package messaging
+
+import (
+	"sync"
+	"time"
+)
+
+// languageTTL is how long the language of a user is cached, so a change made on another instance is picked up
+const languageTTL = time.Hour
+
+// languages caches the language of telegram users, so storage is read once per user rather than on every update
+var languages = newLanguageCache()
+
+type languageCache struct {
+	entries map[int64]cachedLanguage // Map of telegram user IDs to their language
+	mu      sync.Mutex
+}
+
+func newLanguageCache() *languageCache {
+	return &languageCache{entries: make(map[int64]cachedLanguage)}
+}
+
+type cachedLanguage struct {
+	code    string // language code of the telegram app, as stored
+	lang    string // language to reply in, see users.Language
+	expires time.Time
+}
+
+// Get returns the language to reply to user in if cached for their app's language code
+func (l *languageCache) Get(userId int64, code string) (string, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	cached, ok := l.entries[userId]
+	if !ok || cached.code != code || time.Now().After(cached.expires) {
+		return "", false
+	}
+
+	return cached.lang, true
+}
+
+// Set caches the language to reply to user in, resolved for their app's language code
+func (l *languageCache) Set(userId int64, code string, lang string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.entries[userId] = cachedLanguage{code: code, lang: lang, expires: time.Now().Add(languageTTL)}
+}
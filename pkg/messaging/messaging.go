@@ -2,36 +2,29 @@ package messaging
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	chatsession "journie/pkg/chat-session"
 	"journie/pkg/generative"
+	"journie/pkg/ratelimit"
+	"journie/pkg/scheduler"
+	"journie/pkg/storage"
 	"journie/pkg/templates"
 	"journie/pkg/users"
 	"journie/pkg/utility"
 	"log"
 	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/google/generative-ai-go/genai"
+	"github.com/gin-gonic/gin"
 	tele "gopkg.in/telebot.v3"
 )
 
-var TeleBot *tele.Bot
-
-var (
-	// Universal markup builders.
-	// menu     = &tele.ReplyMarkup{ResizeKeyboard: true} // located on keyboard
-	selector = &tele.ReplyMarkup{} // located with message bubble
-
-	// Reply buttons.
-	// btnHelp = menu.Text("Help")
-	// btnSettings = menu.Text("Settings")
-
-	// Inline buttons.
-	// btnWhy = selector.Data("Why do I need this?", "gemini-reason")
-)
+// ReminderHour is the local hour users without a session for the day get reminded, unless they picked another in /settings
+const ReminderHour = users.DefaultReminderHour
 
 type UserModel struct {
 	Platform string `json:"platform"`
@@ -40,153 +33,218 @@ type UserModel struct {
 
 const (
 	Telegram string = "telegram"
+	Webhook  string = "webhook"
 )
 
-func Init() error {
-	pref := tele.Settings{
-		Token:  os.Getenv("TELEGRAM_TOKEN"),
-		Poller: &tele.LongPoller{Timeout: 10 * time.Second},
-	}
+// Platform is a chat platform users journal on, its name prefixes ids of its users
+type Platform interface {
+	Name() string
+	// Start begins receiving messages from users, passing journaling messages to Journal. It does not block
+	Start() error
+	SendText(userId string, text string) error
+	Typing(userId string) error
+	SendReminder(userId string, text string) error
+}
+
+// Streamer is implemented by platforms able to show a reply while it is being generated
+type Streamer interface {
+	StartStream(userId string) (Stream, error)
+}
 
-	TeleBot, err := tele.NewBot(pref)
+// Stream is a reply shown progressively, Finish shows the complete text and Cancel withdraws it
+type Stream interface {
+	Append(chunk string)
+	Finish(text string) error
+	Cancel()
+}
 
-	if err != nil {
-		log.Fatal(err)
-		return err
+// Platforms enabled by env config, keyed by name
+var Platforms = map[string]Platform{}
+
+// Init enables telegram when TELEGRAM_TOKEN is set and the webhook platform when WEBHOOK_SECRET is set,
+// mounting webhook routes on r, then starts receiving messages on all of them
+func Init(r *gin.Engine) error {
+	if token := os.Getenv("TELEGRAM_TOKEN"); token != "" {
+		telegram, err := NewTelegramPlatform(tele.Settings{Token: token})
+		if err != nil {
+			return err
+		}
+
+		switch mode := os.Getenv("TELEGRAM_MODE"); mode {
+		case "", "polling":
+		case "webhook":
+			if err := telegram.UseWebhook(os.Getenv("TELEGRAM_WEBHOOK_URL"), os.Getenv("TELEGRAM_WEBHOOK_SECRET")); err != nil {
+				return err
+			}
+			telegram.Register(r)
+		default:
+			return fmt.Errorf("unsupported TELEGRAM_MODE %q, use polling or webhook", mode)
+		}
+		Platforms[Telegram] = telegram
 	}
 
-	TeleBot.Handle("/gemini_key", func(c tele.Context) error {
-		return c.Send(templates.GeminiKeyInstructions, &tele.SendOptions{ParseMode: tele.ModeMarkdownV2, ReplyMarkup: selector})
-	})
+	if secret := os.Getenv("WEBHOOK_SECRET"); secret != "" {
+		webhook := NewWebhookPlatform(secret, os.Getenv("WEBHOOK_OUTBOUND_URL"))
+		webhook.Register(r)
+		Platforms[Webhook] = webhook
+	}
 
-	// // On reply button pressed (message)
-	// TeleBot.Handle(&btnHelp, func(c tele.Context) error {
-	// 	return c.Send("Here is some help: ...!")
-	// })
+	if len(Platforms) == 0 {
+		return errors.New("no messaging platform configured, set TELEGRAM_TOKEN or WEBHOOK_SECRET")
+	}
 
-	// // On inline button pressed (callback)
-	// TeleBot.Handle(&btnWhy, func(c tele.Context) error {
-	// 	return c.Send(templates.GeminiKeyReason, &tele.SendOptions{ParseMode: tele.ModeMarkdownV2})
-	// })
+	for name, platform := range Platforms {
+		if err := platform.Start(); err != nil {
+			return fmt.Errorf("starting %s: %w", name, err)
+		}
+		log.Printf("%s platform started", name)
+	}
 
-	// handle default /start command from telegram
-	TeleBot.Handle("/start", func(c tele.Context) error {
-		var username = c.Sender().Username
+	return nil
+}
 
-		message := templates.WelcomeMessageSharedApiKey(username)
+// Journal sends parts from userId of platform to their chat session for the day and replies with Journie's response
+func Journal(ctx context.Context, platform Platform, userId string, parts ...generative.Part) error {
+	lang, ok := admit(ctx, platform, userId)
+	if !ok {
+		return nil
+	}
+	return journal(ctx, platform, userId, lang, parts...)
+}
 
-		return c.Send(message, &tele.SendOptions{ParseMode: tele.ModeMarkdownV2})
-	})
+// admit checks the rate limit and the shared key quota of userId of platform before any work for a message
+// is done, e.g. downloading or transcribing it, and tells the user when their message is refused.
+// Admitted messages are passed to journal with the language of the user
+func admit(ctx context.Context, platform Platform, userId string) (string, bool) {
+	platformUserId := PlatformUserId(platform.Name(), userId)
 
-	// handle manual summarize
-	TeleBot.Handle("/summarize", func(c tele.Context) error {
-		var userId = int(c.Sender().ID)
-		platformUserId, err := GetPlatformUserId(fmt.Sprint(userId))
-		if err != nil {
-			log.Printf("Error handling user id %d: %v", userId, err)
-			return c.Send("Error handling user id")
-		}
+	user, err := storage.StoreClient.GetUser(ctx, platformUserId)
+	if err != nil && err != storage.ErrNotFound {
+		log.Printf("Error retrieving user %s: %v", platformUserId, err)
+	}
+	lang := users.Language(user)
+	ownKey := user != nil && user.GeminiKey != ""
 
-		cs, err := chatsession.ChatSessionClient.GetOrCreateChatSession(platformUserId)
-		if err != nil {
-			log.Printf("Error retrieving or creating chat session: %v", err)
-			return c.Send("Error retrieving chat session")
+	refuse := func(text string) (string, bool) {
+		if err := platform.SendText(userId, text); err != nil {
+			log.Printf("Error refusing message of %s user %s: %v", platform.Name(), userId, err)
 		}
+		return lang, false
+	}
 
-		analysis, err := chatsession.IngestChatSession(cs, platformUserId)
-		if err != nil {
-			log.Printf("Error ingesting chat session: %v", err)
-			return c.Send("Error retrieving chat session")
+	// users with their own key have their own tier
+	if ratelimit.LimiterClient != nil {
+		tier := ratelimit.Shared
+		if ownKey {
+			tier = ratelimit.OwnKey
 		}
 
-		out, err := json.Marshal(analysis)
-		if err != nil {
-			log.Printf("Error marshalling analysis: %v", err)
-			return c.Send("Error processing analysis")
+		now := time.Now().In(users.Location(user))
+		if decision := ratelimit.LimiterClient.Allow(platformUserId, tier, now); !decision.Allowed {
+			log.Printf("User %s over %s rate limit until %s", platformUserId, tier, decision.RetryAt.Format(time.RFC3339))
+			if decision.Daily {
+				return refuse(templates.DailyLimitReached(lang, decision.RetryAt, ownKey))
+			}
+			return refuse(templates.RateLimited(lang, decision.RetryAt.Sub(now)))
 		}
+	}
 
-		return c.Send(string(out))
-	})
+	if !ownKey && !generative.GenAiClient.SharedQuota.Allow(time.Now()) {
+		return refuse(templates.SharedQuotaExhausted(lang))
+	}
 
-	// handle manual command to clear session
-	TeleBot.Handle("/clear", func(c tele.Context) error {
-		var userId = int(c.Sender().ID)
-		platformUserId, err := GetPlatformUserId(fmt.Sprint(userId))
-		if err != nil {
-			log.Printf("Error handling user id %d: %v", userId, err)
-			return c.Send("Error handling user id")
-		}
+	return lang, true
+}
 
-		err = chatsession.ChatSessionClient.DeleteChatSession(platformUserId)
-		if err != nil {
-			log.Printf("Error deleting chat session: %v", err)
-			return c.Send("Error deleting chat session")
-		}
+// journal is Journal for a message admit let through
+func journal(ctx context.Context, platform Platform, userId string, lang string, parts ...generative.Part) error {
+	platformUserId := PlatformUserId(platform.Name(), userId)
 
-		return c.Send("Chat session deleted")
-	})
-
-	// All other text messages to be handled by Journie
-	TeleBot.Handle(tele.OnText, func(c tele.Context) error {
-		ctx := context.Background()
-		var (
-			sender = c.Sender()
-			text   = c.Text()
-		)
-		userId := int(sender.ID)
-		platformUserId, err := GetPlatformUserId(fmt.Sprint(userId))
-		if err != nil {
-			log.Printf("Error handling user id %d: %v", userId, err)
-			return c.Send("Error handling user id")
+	// Initialize chat session
+	var query []string
+	for _, part := range parts {
+		if part.MIMEType == "" && part.Text != "" {
+			query = append(query, part.Text)
 		}
+	}
 
-		// Initialize chat session
-		cs, err := chatsession.ChatSessionClient.GetOrCreateChatSession(platformUserId)
-		if err != nil {
-			log.Printf("Error creating chat session for user %d: %v", userId, err)
-			return c.Send("Error creating chat session")
-		}
+	cs, err := chatsession.ChatSessionClient.GetOrCreateChatSession(platformUserId, strings.Join(query, "\n"))
+	if err != nil {
+		log.Printf("Error creating chat session for user %s: %v", platformUserId, err)
+		return platform.SendText(userId, templates.Error(lang, templates.ErrorCreateChat))
+	}
 
-		TeleBot.Notify(sender, tele.Typing)
+	// stream the reply where the platform can show it being written
+	stream, err := startStream(platform, userId)
+	if err != nil {
+		log.Printf("Error starting reply to user %s: %v", platformUserId, err)
+		return err
+	}
 
-		resp, err := cs.SendMessage(ctx, genai.Text(text))
-		if err != nil {
-			log.Printf("Error sending message to chat session for user %d: %v", userId, err)
-			// @todo, if err occurs due to safety, reflect in message, and recover the history by creating a new session
-			return c.Send("Error processing your request")
+	resp, err := cs.SendMessageStream(ctx, stream.Append, parts...)
+	if err != nil {
+		log.Printf("Error sending message to chat session for user %s: %v", platformUserId, err)
+		stream.Cancel()
+
+		// history is unchanged, so the conversation carries on from before the blocked message
+		var promptBlocked *generative.PromptBlockedError
+		var responseBlocked *generative.ResponseBlockedError
+		switch {
+		case errors.As(err, &promptBlocked):
+			return platform.SendText(userId, templates.SafetyPromptBlocked(lang))
+		case errors.As(err, &responseBlocked):
+			return platform.SendText(userId, templates.SafetyResponseBlocked(lang))
+		case cs.OwnKey && generative.IsInvalidKeyError(err):
+			return fallbackToSharedKey(platform, userId)
+		case cs.OwnKey && generative.IsForbiddenError(err):
+			return platform.SendText(userId, templates.GeminiKeyForbidden(lang))
 		}
+		return platform.SendText(userId, templates.Error(lang, templates.ErrorRequest))
+	}
 
-		out, err := generative.ResponseToString(resp)
-		if err != nil {
-			log.Printf("Error generating chat response for user %d: %v", userId, err)
-			return c.Send("Error generating chat response")
-		}
+	// write through so the conversation survives a restart
+	if err := chatsession.ChatSessionClient.SaveChatSession(platformUserId); err != nil {
+		log.Printf("Error saving chat session for user %s: %v", platformUserId, err)
+	}
 
-		return c.Send(out)
-	})
+	return stream.Finish(resp.Text)
+}
 
-	TeleBot.Handle(tele.OnPhoto, func(c tele.Context) error {
-		return c.Send(string("Sorry! I am unable to process images as of now!"))
-	})
+func startStream(platform Platform, userId string) (Stream, error) {
+	if streamer, ok := platform.(Streamer); ok {
+		return streamer.StartStream(userId)
+	}
 
-	TeleBot.Handle(tele.OnVideo, func(c tele.Context) error {
-		return c.Send(string("Sorry! I am unable to process videos as of now!"))
-	})
+	if err := platform.Typing(userId); err != nil {
+		log.Printf("Error sending typing indicator to %s user %s: %v", platform.Name(), userId, err)
+	}
+	return &textStream{platform: platform, userId: userId}, nil
+}
 
-	TeleBot.Handle(tele.OnVoice, func(c tele.Context) error {
-		return c.Send(string("Sorry! I am unable to process voice messages as of now!"))
-	})
+// textStream sends the complete reply once, for platforms that cannot edit messages
+type textStream struct {
+	platform Platform
+	userId   string
+}
 
-	TeleBot.Start()
+func (s *textStream) Append(chunk string) {}
 
-	return nil
+func (s *textStream) Finish(text string) error {
+	return s.platform.SendText(s.userId, text)
+}
+
+func (s *textStream) Cancel() {}
+
+// PlatformUserId prefixes userId of platform, e.g. telegram-123
+func PlatformUserId(platform string, userId string) string {
+	return fmt.Sprintf("%s-%s", platform, userId)
 }
 
 func GetPlatformUserId(userId string) (string, error) {
 	if userId == "" {
 		return "", fmt.Errorf("invalid user ID: expected non empty string")
 	}
-	return fmt.Sprintf("%s-%s", Telegram, userId), nil
+	return PlatformUserId(Telegram, userId), nil
 }
 
 func ParsePlatformUserId(platformUserId string) (*UserModel, error) {
@@ -205,76 +263,185 @@ func ParsePlatformUserId(platformUserId string) (*UserModel, error) {
 	}, nil
 }
 
-// RemindDaily triggered to send reminder messsage to users
-// @todo handle timezone
-func RemindDaily() {
-	targetDate := time.Now().Format("2006-01-02")
-	inactiveUsers := users.GetUsersWithoutSession(targetDate)
+// RemindDaily triggered hourly to send reminder messsage to users
+// whose local time is their reminder hour and have not journaled today, on the platform they journal on and in their language.
+// Users who turned reminders off in /settings are left alone.
+// Users already reminded by an earlier attempt of run are skipped
+func RemindDaily(run *scheduler.Run) error {
+	inactiveUsers := users.GetUsersToRemind(run.At)
 
-	// throttle, telegram rate limits ~30 per second
+	// throttle per platform, telegram rate limits ~30 per second
 	throttleDuration := 100 * time.Millisecond
-	throttle := utility.NewThrottle(throttleDuration)
+	throttles := make(map[string]*utility.Throttle)
 
+	var (
+		wg     sync.WaitGroup
+		failed atomic.Int32
+	)
 	for _, userId := range inactiveUsers {
-		teleUserId, err := ParsePlatformUserId(userId)
+		if run.Done(userId) {
+			continue
+		}
+
+		user, err := ParsePlatformUserId(userId)
 		if err != nil {
 			log.Printf("Error parsing user ID %s: %v", userId, err)
 			continue
 		}
 
-		var userIntValue int64
-		_, converr := fmt.Sscan(teleUserId.UserId, &userIntValue) // Handle potential error with _ (blank identifier)
-		if converr != nil {
-			log.Printf("Error converting teleUserId.UserId %s to int: %v", userId, converr)
+		platform, ok := Platforms[user.Platform]
+		if !ok {
+			log.Printf("Platform %s of user %s not enabled, skipping reminder", user.Platform, userId)
 			continue
 		}
 
-		u := &tele.User{ID: userIntValue}
+		throttle, ok := throttles[user.Platform]
+		if !ok {
+			throttle = utility.NewThrottle(throttleDuration)
+			throttles[user.Platform] = throttle
+		}
 
-		go func() {
+		wg.Add(1)
+		go func(platformUserId string, user *UserModel) {
+			defer wg.Done()
 			throttle.Process()
-			_, err := TeleBot.Send(u, "Hi, take 5 minutes to write a journal entry!")
-			if err != nil {
-				log.Printf("Error sending reminder to user %d: %v", u.ID, err)
+			lang := users.GetLanguage(context.Background(), platformUserId)
+			if err := platform.SendReminder(user.UserId, templates.Reminder(lang)); err != nil {
+				log.Printf("Error sending reminder to %s user %s: %v", user.Platform, user.UserId, err)
+				failed.Add(1)
+				return
 			}
-		}()
+			run.MarkDone(platformUserId)
+		}(userId, user)
 	}
+	wg.Wait()
+
+	if n := failed.Load(); n > 0 {
+		return fmt.Errorf("%d of %d reminders failed", n, len(inactiveUsers))
+	}
+	return nil
 }
 
-// SummarizeDaily:
-// 1. get users with last chat session for the day
+// SummarizeDaily triggered hourly:
+// 1. get users whose local day just ended with last chat session for the day
 // 2. summarize chat sessions (handle gemini rate limits @ ~60 per minute)
 // 3. delete chat session once summarized
-func SummarizeDaily() {
-	// assuming day "ends" at 4am
-	// floor hour and minutes, get date and minus 1 day
-	now := time.Now()
-	yesterday := now.AddDate(0, 0, -1)
-	users := users.GetUsersWithSession(time.Date(yesterday.Year(), yesterday.Month(), yesterday.Day(), 0, 0, 0, 0, now.Location()))
+// Users already summarized by an earlier attempt of run are skipped
+func SummarizeDaily(run *scheduler.Run) error {
+	// assuming day "ends" at 4am local time
+	users := users.GetUsersWithSession(run.At, chatsession.DayBoundaryHour)
 
 	// generate content. debounce and rate limit
 	throttleDuration := 1000 * time.Millisecond
 	throttle := utility.NewThrottle(throttleDuration)
 
+	var (
+		wg     sync.WaitGroup
+		failed atomic.Int32
+	)
 	for _, userId := range users {
+		if run.Done(userId) {
+			continue
+		}
+
 		chatSession := chatsession.ChatSessionClient.GetChatSession(userId)
 		if chatSession == nil {
 			log.Printf("Chat session not found for user %s", userId)
 			continue
 		}
 
+		wg.Add(1)
 		go func(platformUserId string) {
+			defer wg.Done()
 			throttle.Process()
 			// Call the original function with converted arguments
 			if _, err := chatsession.IngestChatSession(chatSession, platformUserId); err != nil {
 				log.Printf("Error summarizing chat session for user %s: %v", platformUserId, err)
+				// keep the raw history so the day is not lost, retries happen in RetrySummaries
+				if err := chatsession.QueueSummaryRetry(chatSession, platformUserId, err); err != nil {
+					log.Printf("Error queueing summary retry for user %s: %v", platformUserId, err)
+					failed.Add(1)
+					return
+				}
+				run.MarkDone(platformUserId)
 				return
 			}
 			if err := chatsession.ChatSessionClient.DeleteChatSession(platformUserId); err != nil {
 				log.Printf("Error deleting chat session for user %s: %v", platformUserId, err)
 			}
+			run.MarkDone(platformUserId)
 		}(userId)
 	}
+	wg.Wait()
+
+	if n := failed.Load(); n > 0 {
+		return fmt.Errorf("%d of %d summaries failed", n, len(users))
+	}
+	return nil
+}
+
+// RetrySummaries triggered every few minutes, summarizes failed summaries whose backoff is over.
+// Those failing again are queued with a longer backoff or parked as dead letters
+func RetrySummaries(run *scheduler.Run) error {
+	failedSummaries, err := storage.StoreClient.ListFailedSummaries(context.Background(), false)
+	if err != nil {
+		return err
+	}
+
+	// same rate limit as SummarizeDaily, retried one by one
+	throttle := utility.NewThrottle(1000 * time.Millisecond)
+
+	for _, failed := range failedSummaries {
+		if failed.NextAttempt.After(run.At) || run.Done(failed.Id) {
+			continue
+		}
+
+		throttle.Process()
+		if _, err := chatsession.RetrySummary(&failed); err != nil {
+			log.Printf("Error retrying summary of %s for user %s, attempt %d: %v", failed.Day, failed.UserId, failed.Attempts, err)
+		} else {
+			log.Printf("Summary of %s for user %s succeeded on attempt %d", failed.Day, failed.UserId, failed.Attempts+1)
+		}
+		run.MarkDone(failed.Id)
+	}
+
+	return nil
+}
+
+// RollupDigests triggered daily, rolls up each user's journal of past weeks, months and years into digests.
+// Users whose rollup fails are retried when the run resumes, periods already rolled up are skipped
+func RollupDigests(run *scheduler.Run) error {
+	all, err := storage.StoreClient.ListUsers(context.Background())
+	if err != nil {
+		return err
+	}
+
+	// same rate limit as SummarizeDaily, rolled up one by one
+	throttle := utility.NewThrottle(1000 * time.Millisecond)
+
+	failed := 0
+	for _, user := range all {
+		if run.Done(user.Id) {
+			continue
+		}
+
+		throttle.Process()
+		created, err := chatsession.RollupDigests(user.Id, run.At)
+		if err != nil {
+			log.Printf("Error rolling up digests for user %s: %v", user.Id, err)
+			failed++
+			continue
+		}
+		if created > 0 {
+			log.Printf("Rolled up %d digests for user %s", created, user.Id)
+		}
+		run.MarkDone(user.Id)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d digest rollups failed", failed, len(all))
+	}
+	return nil
 }
 
 func testLog(userId string) error {
@@ -285,8 +452,7 @@ func testLog(userId string) error {
 }
 
 func TestLoop() {
-	targetDate := time.Now().Format("2006-01-02")
-	inactiveUsers := users.GetUsersWithoutSession(targetDate)
+	inactiveUsers := users.GetUsersWithoutSession(time.Now(), ReminderHour)
 
 	throttleDuration := 5000 * time.Millisecond
 	throttle := utility.NewThrottle(throttleDuration)
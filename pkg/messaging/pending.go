@@ -0,0 +1,56 @@
+package messaging
+
+import (
+	"sync"
+	"time"
+)
+
+// pendingTTL is how long the bot waits for a reply, later messages are journaled as usual
+const pendingTTL = 10 * time.Minute
+
+// pending tracks users the bot is waiting on for a free text reply, e.g. a date after "Jump to date"
+var pending = &pendingInputs{inputs: make(map[int64]pendingInput)}
+
+type pendingInputs struct {
+	inputs map[int64]pendingInput // Map of telegram user IDs to the input expected
+	mu     sync.Mutex
+}
+
+type pendingInput struct {
+	kind    string
+	expires time.Time
+}
+
+// Set marks user as expected to reply with kind of input within pendingTTL, replacing any previous one
+func (p *pendingInputs) Set(userId int64, kind string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.inputs[userId] = pendingInput{kind: kind, expires: time.Now().Add(pendingTTL)}
+}
+
+// Get returns the kind of input expected from user, if any and not expired. It stays
+// expected until Clear, so messages not answering it do not consume it
+func (p *pendingInputs) Get(userId int64) (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	input, ok := p.inputs[userId]
+	if !ok {
+		return "", false
+	}
+	if time.Now().After(input.expires) {
+		delete(p.inputs, userId)
+		return "", false
+	}
+
+	return input.kind, true
+}
+
+// Clear forgets the input expected from user, once answered
+func (p *pendingInputs) Clear(userId int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.inputs, userId)
+}
@@ -0,0 +1,113 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	chatsession "journie/pkg/chat-session"
+	"journie/pkg/personas"
+	"journie/pkg/templates"
+	"journie/pkg/users"
+	"log"
+	"strings"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+// persona button, data carries the persona key
+var btnPersona = selector.Data("", "persona")
+
+func registerPersonaHandlers(bot *tele.Bot) {
+	// handle persona selection, /persona {name}
+	bot.Handle("/persona", func(c tele.Context) error {
+		var userId = int(c.Sender().ID)
+		platformUserId, err := GetPlatformUserId(fmt.Sprint(userId))
+		if err != nil {
+			log.Printf("Error handling user id %d: %v", userId, err)
+			return c.Send(templates.Error(languageOf(c), templates.ErrorUser))
+		}
+
+		lang := languageOf(c)
+		name := strings.TrimSpace(c.Message().Payload)
+		if name == "" {
+			settings := users.GetSettings(context.Background(), platformUserId)
+			current, ok := personas.Get(settings.Persona)
+			if !ok {
+				current = personas.Default()
+			}
+			return c.Send(templates.PersonaMenu(lang, current, personas.All()), personaMarkup(lang, current.Key))
+		}
+
+		persona, ok := findPersona(lang, name)
+		if !ok {
+			return c.Send(templates.PersonaInvalid(lang, name, personas.All()))
+		}
+		if err := setPersona(platformUserId, persona); err != nil {
+			log.Printf("Error setting persona %s of user %d: %v", persona.Key, userId, err)
+			return c.Send(templates.Error(lang, templates.ErrorRequest))
+		}
+
+		return c.Send(templates.PersonaUpdated(lang, persona))
+	})
+
+	// On a persona pressed, save it and show the menu with it checked
+	bot.Handle(&btnPersona, func(c tele.Context) error {
+		var userId = int(c.Sender().ID)
+		platformUserId, err := GetPlatformUserId(fmt.Sprint(userId))
+		if err != nil {
+			log.Printf("Error handling user id %d: %v", userId, err)
+			return c.Respond(&tele.CallbackResponse{Text: templates.Error(languageOf(c), templates.ErrorUser)})
+		}
+
+		lang := languageOf(c)
+		persona, ok := personas.Get(c.Data())
+		if !ok {
+			return c.Respond(&tele.CallbackResponse{Text: templates.SettingInvalid(lang)})
+		}
+		if err := setPersona(platformUserId, persona); err != nil {
+			log.Printf("Error setting persona %s of user %d: %v", persona.Key, userId, err)
+			return c.Respond(&tele.CallbackResponse{Text: templates.Error(lang, templates.ErrorRequest)})
+		}
+
+		if err := c.Respond(&tele.CallbackResponse{Text: templates.PersonaUpdated(lang, persona)}); err != nil {
+			log.Printf("Error responding to callback for user %d: %v", userId, err)
+		}
+
+		return c.Edit(templates.PersonaMenu(lang, persona, personas.All()), personaMarkup(lang, persona.Key))
+	})
+}
+
+// setPersona stores persona for the user, today's conversation continues in it
+func setPersona(platformUserId string, persona personas.Persona) error {
+	if _, err := users.UpdateSetting(context.Background(), platformUserId, users.SettingPersona, persona.Key); err != nil {
+		return err
+	}
+	chatsession.ChatSessionClient.EvictChatSession(platformUserId)
+	return nil
+}
+
+// findPersona returns the persona whose key or name, in English or lang, is name
+func findPersona(lang string, name string) (personas.Persona, bool) {
+	for _, persona := range personas.All() {
+		if strings.EqualFold(name, persona.Key) || strings.EqualFold(name, persona.Name) || strings.EqualFold(name, templates.PersonaName(lang, persona)) {
+			return persona, true
+		}
+	}
+	return personas.Persona{}, false
+}
+
+// personaMarkup lists personas one per row, the current one checked
+func personaMarkup(lang string, current string) *tele.ReplyMarkup {
+	markup := &tele.ReplyMarkup{}
+
+	var rows []tele.Row
+	for _, persona := range personas.All() {
+		label := templates.PersonaName(lang, persona)
+		if persona.Key == current {
+			label = "✓ " + label
+		}
+		rows = append(rows, markup.Row(markup.Data(label, btnPersona.Unique, persona.Key)))
+	}
+	markup.Inline(rows...)
+
+	return markup
+}
@@ -0,0 +1,44 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"journie/pkg/generative"
+	"journie/pkg/templates"
+	"log"
+	"strings"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+func registerPhotoHandlers(bot *tele.Bot) {
+	// journal photos with their caption, if any, so Journie can talk about them
+	bot.Handle(tele.OnPhoto, func(c tele.Context) error {
+		ctx := context.Background()
+		var userId = int(c.Sender().ID)
+
+		lang, ok := admit(ctx, Platforms[Telegram], fmt.Sprint(userId))
+		if !ok {
+			return nil
+		}
+
+		// telebot picks the largest size telegram offers
+		photo := c.Message().Photo
+
+		bot.Notify(c.Sender(), tele.Typing)
+
+		data, err := downloadFile(bot, &photo.File)
+		if err != nil {
+			log.Printf("Error downloading photo of user %d: %v", userId, err)
+			return c.Send(templates.Error(languageOf(c), templates.ErrorPhoto))
+		}
+
+		// telegram re-encodes photos as jpeg, the file id is kept as reference instead of the image
+		parts := []generative.Part{{MIMEType: "image/jpeg", Data: data, Ref: photo.FileID}}
+		if caption := strings.TrimSpace(c.Message().Caption); caption != "" {
+			parts = append(parts, generative.Text(caption))
+		}
+
+		return journal(ctx, Platforms[Telegram], fmt.Sprint(userId), lang, parts...)
+	})
+}
@@ -0,0 +1,166 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	chatsession "journie/pkg/chat-session"
+	"journie/pkg/storage"
+	"journie/pkg/templates"
+	"journie/pkg/users"
+	"log"
+	"strconv"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+// settings menu buttons, open carries the setting to show options of, empty for the menu,
+// and set carries the setting and its new value
+var (
+	btnSettingsOpen = selector.Data("", "settings-open")
+	btnSettingsSet  = selector.Data("", "settings-set")
+)
+
+func registerSettingsHandlers(bot *tele.Bot) {
+	// handle settings menu, /settings
+	bot.Handle("/settings", func(c tele.Context) error {
+		var userId = int(c.Sender().ID)
+		platformUserId, err := GetPlatformUserId(fmt.Sprint(userId))
+		if err != nil {
+			log.Printf("Error handling user id %d: %v", userId, err)
+			return c.Send(templates.Error(languageOf(c), templates.ErrorUser))
+		}
+
+		settings := users.GetSettings(context.Background(), platformUserId)
+		return c.Send(templates.SettingsMenu(languageOf(c)), settingsMarkup(languageOf(c), &settings))
+	})
+
+	// On a setting pressed, show its options in place of the menu, or the menu again on back
+	bot.Handle(&btnSettingsOpen, func(c tele.Context) error {
+		var userId = int(c.Sender().ID)
+		platformUserId, err := GetPlatformUserId(fmt.Sprint(userId))
+		if err != nil {
+			log.Printf("Error handling user id %d: %v", userId, err)
+			return c.Respond(&tele.CallbackResponse{Text: templates.Error(languageOf(c), templates.ErrorUser)})
+		}
+
+		if err := c.Respond(); err != nil {
+			log.Printf("Error responding to callback for user %d: %v", userId, err)
+		}
+
+		settings := users.GetSettings(context.Background(), platformUserId)
+		lang := languageOf(c)
+		if c.Data() == "" {
+			return c.Edit(templates.SettingsMenu(lang), settingsMarkup(lang, &settings))
+		}
+
+		return c.Edit(templates.SettingOptions(lang, templates.SettingLabel(lang, c.Data())), settingOptionsMarkup(lang, c.Data(), &settings))
+	})
+
+	// On an option pressed, save it and show the menu with the new value
+	bot.Handle(&btnSettingsSet, func(c tele.Context) error {
+		var userId = int(c.Sender().ID)
+		platformUserId, err := GetPlatformUserId(fmt.Sprint(userId))
+		if err != nil {
+			log.Printf("Error handling user id %d: %v", userId, err)
+			return c.Respond(&tele.CallbackResponse{Text: templates.Error(languageOf(c), templates.ErrorUser)})
+		}
+
+		args := c.Args()
+		if len(args) != 2 {
+			return c.Respond(&tele.CallbackResponse{Text: templates.SettingInvalid(languageOf(c))})
+		}
+
+		settings, err := users.UpdateSetting(context.Background(), platformUserId, args[0], args[1])
+		if err != nil {
+			log.Printf("Error updating setting %s of user %d: %v", args[0], userId, err)
+			return c.Respond(&tele.CallbackResponse{Text: templates.SettingInvalid(languageOf(c))})
+		}
+
+		// reply in the new language right away, and continue today's conversation in it and the new persona
+		lang := users.Language(&storage.User{Settings: settings, LanguageCode: c.Sender().LanguageCode})
+		if args[0] == users.SettingLanguage || args[0] == users.SettingPersona {
+			chatsession.ChatSessionClient.EvictChatSession(platformUserId)
+		}
+		if args[0] == users.SettingLanguage {
+			languages.Set(c.Sender().ID, c.Sender().LanguageCode, lang)
+		}
+
+		if err := c.Respond(&tele.CallbackResponse{Text: templates.SettingSaved(lang)}); err != nil {
+			log.Printf("Error responding to callback for user %d: %v", userId, err)
+		}
+
+		return c.Edit(templates.SettingsMenu(lang), settingsMarkup(lang, settings))
+	})
+}
+
+// settingsMarkup lists settings with their current value, reminders toggle in place
+func settingsMarkup(lang string, settings *storage.Settings) *tele.ReplyMarkup {
+	option := func(key string, options []users.Option, value string) string {
+		for _, option := range options {
+			if option.Value == value {
+				return templates.OptionLabel(lang, key, option)
+			}
+		}
+		return value
+	}
+
+	markup := &tele.ReplyMarkup{}
+	button := func(key string, value string) tele.Row {
+		return markup.Row(markup.Data(fmt.Sprintf("%s: %s", templates.SettingLabel(lang, key), value), btnSettingsOpen.Unique, key))
+	}
+	markup.Inline(
+		markup.Row(markup.Data(fmt.Sprintf("%s: %s", templates.SettingLabel(lang, users.SettingReminders), templates.SettingToggle(lang, settings.Reminders)), btnSettingsSet.Unique, users.SettingReminders, strconv.FormatBool(!settings.Reminders))),
+		button(users.SettingReminderHour, reminderHourLabel(settings.ReminderHour)),
+		button(users.SettingLanguage, option(users.SettingLanguage, users.Languages, settings.Language)),
+		button(users.SettingPersona, option(users.SettingPersona, users.Personas(), settings.Persona)),
+		button(users.SettingSummaryStyle, option(users.SettingSummaryStyle, users.SummaryStyles, settings.SummaryStyle)),
+	)
+
+	return markup
+}
+
+// settingOptionsMarkup lists the options of setting key, the current one checked, and a back button
+func settingOptionsMarkup(lang string, key string, settings *storage.Settings) *tele.ReplyMarkup {
+	var (
+		options []users.Option
+		current string
+		perRow  = 2
+	)
+	switch key {
+	case users.SettingReminderHour:
+		for hour := 0; hour < 24; hour++ {
+			options = append(options, users.Option{Value: strconv.Itoa(hour), Label: reminderHourLabel(hour)})
+		}
+		current = strconv.Itoa(settings.ReminderHour)
+		perRow = 4
+	case users.SettingLanguage:
+		options, current = users.Languages, settings.Language
+	case users.SettingPersona:
+		options, current = users.Personas(), settings.Persona
+	case users.SettingSummaryStyle:
+		options, current = users.SummaryStyles, settings.SummaryStyle
+	}
+
+	markup := &tele.ReplyMarkup{}
+
+	var rows []tele.Row
+	for i := 0; i < len(options); i += perRow {
+		var buttons []tele.Btn
+		for _, option := range options[i:min(i+perRow, len(options))] {
+			label := templates.OptionLabel(lang, key, option)
+			if option.Value == current {
+				label = "✓ " + label
+			}
+			buttons = append(buttons, markup.Data(label, btnSettingsSet.Unique, key, option.Value))
+		}
+		rows = append(rows, markup.Row(buttons...))
+	}
+	rows = append(rows, markup.Row(markup.Data(templates.BackButton(lang), btnSettingsOpen.Unique)))
+	markup.Inline(rows...)
+
+	return markup
+}
+
+func reminderHourLabel(hour int) string {
+	return fmt.Sprintf("%02d:00", hour)
+}
@@ -0,0 +1,114 @@
+package messaging
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+// streamEditInterval between edits of a streamed reply, telegram allows about one edit per second per chat
+const streamEditInterval = 1200 * time.Millisecond
+
+// maxMessageLength of a telegram message in characters
+const maxMessageLength = 4096
+
+// streamEditor progressively edits a placeholder telegram message as chunks of a reply arrive
+type streamEditor struct {
+	bot       *tele.Bot
+	recipient tele.Recipient
+	message   *tele.Message
+	text      strings.Builder
+	shown     string
+	last      time.Time
+	mu        sync.Mutex
+}
+
+func newStreamEditor(bot *tele.Bot, recipient tele.Recipient, placeholder *tele.Message) *streamEditor {
+	return &streamEditor{bot: bot, recipient: recipient, message: placeholder, last: time.Now()}
+}
+
+// Append adds chunk to the reply, editing the message if the last edit was long enough ago
+func (e *streamEditor) Append(chunk string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.text.WriteString(chunk)
+	if time.Since(e.last) < streamEditInterval {
+		return
+	}
+
+	if err := e.edit(truncate(e.text.String(), maxMessageLength)); err != nil {
+		log.Printf("Error editing streamed message %d: %v", e.message.ID, err)
+	}
+}
+
+// Finish shows the complete reply, continuing in new messages past telegram's length limit.
+// If the placeholder cannot be edited, e.g. rate limited, the first page is sent in its place
+func (e *streamEditor) Finish(text string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	pages := split(text, maxMessageLength)
+	if err := e.edit(pages[0]); err != nil {
+		log.Printf("Error editing streamed message %d, sending the reply instead: %v", e.message.ID, err)
+		if _, err := e.bot.Send(e.recipient, pages[0]); err != nil {
+			return err
+		}
+		e.Cancel()
+	}
+
+	for _, page := range pages[1:] {
+		if _, err := e.bot.Send(e.recipient, page); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Cancel removes the placeholder, e.g. when the reply failed
+func (e *streamEditor) Cancel() {
+	if err := e.bot.Delete(e.message); err != nil {
+		log.Printf("Error deleting placeholder message %d: %v", e.message.ID, err)
+	}
+}
+
+func (e *streamEditor) edit(text string) error {
+	e.last = time.Now()
+	if text == e.shown || strings.TrimSpace(text) == "" {
+		return nil
+	}
+
+	if _, err := e.bot.Edit(e.message, text); err != nil {
+		return err
+	}
+	e.shown = text
+	return nil
+}
+
+func truncate(text string, length int) string {
+	if utf8.RuneCountInString(text) <= length {
+		return text
+	}
+	return string([]rune(text)[:length])
+}
+
+// split text into pages of at most length characters, breaking on the last newline of a page if any
+func split(text string, length int) []string {
+	var pages []string
+	runes := []rune(text)
+	for len(runes) > length {
+		cut := length
+		if i := strings.LastIndex(string(runes[:length]), "\n"); i > 0 {
+			cut = utf8.RuneCountInString(string(runes[:length])[:i])
+		}
+		pages = append(pages, string(runes[:cut]))
+		runes = []rune(strings.TrimLeft(string(runes[cut:]), "\n"))
+	}
+
+	return append(pages, string(runes))
+}
@@ -0,0 +1,536 @@
+package messaging
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"journie/pkg/charts"
+	chatsession "journie/pkg/chat-session"
+	"journie/pkg/export"
+	"journie/pkg/generative"
+	"journie/pkg/i18n"
+	"journie/pkg/storage"
+	"journie/pkg/templates"
+	"journie/pkg/users"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	tele "gopkg.in/telebot.v3"
+)
+
+var TeleBot *tele.Bot
+
+var (
+	// Universal markup builders.
+	// menu     = &tele.ReplyMarkup{ResizeKeyboard: true} // located on keyboard
+	selector = &tele.ReplyMarkup{} // located with message bubble
+
+	// Reply buttons.
+	// btnHelp = menu.Text("Help")
+	// btnSettings = menu.Text("Settings")
+
+	// Inline buttons.
+	btnTimezone = selector.Data("", "timezone") // data carries IANA timezone
+)
+
+// onboardingTimezones offered as buttons on /start, anything else can be set with /timezone
+var onboardingTimezones = [][]string{
+	{"Singapore", "Asia/Singapore"},
+	{"Jakarta", "Asia/Jakarta"},
+	{"Tokyo", "Asia/Tokyo"},
+	{"London", "Europe/London"},
+	{"New York", "America/New_York"},
+	{"Los Angeles", "America/Los_Angeles"},
+}
+
+// telegramWebhookPath receives updates from telegram in webhook mode
+const telegramWebhookPath = "/telegram/webhook"
+
+// telegramSecretHeader carries the secret token given to telegram when registering the webhook
+const telegramSecretHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// telegramSecretToken is the charset and length telegram accepts for webhook secret tokens
+var telegramSecretToken = regexp.MustCompile(`^[A-Za-z0-9_-]{1,256}$`)
+
+// TelegramPlatform receives messages and commands from a telegram bot,
+// by long polling or, once UseWebhook is called, by webhook on the gin server
+type TelegramPlatform struct {
+	bot      *tele.Bot
+	settings tele.Settings
+	webhook  *tele.Webhook // nil when long polling
+}
+
+func NewTelegramPlatform(pref tele.Settings) (*TelegramPlatform, error) {
+	if pref.Poller == nil {
+		pref.Poller = &tele.LongPoller{Timeout: 10 * time.Second}
+	}
+
+	var err error
+	TeleBot, err = tele.NewBot(pref)
+	if err != nil {
+		return nil, err
+	}
+
+	// users are cached per bot
+	languages = newLanguageCache()
+
+	registerTelegramHandlers(TeleBot)
+
+	return &TelegramPlatform{bot: TeleBot, settings: pref}, nil
+}
+
+// UseWebhook switches to webhook mode, telegram posts updates to publicURL followed by
+// /telegram/webhook, and each post must carry secret in the secret token header
+func (p *TelegramPlatform) UseWebhook(publicURL string, secret string) error {
+	if !strings.HasPrefix(publicURL, "https://") {
+		return errors.New("TELEGRAM_WEBHOOK_URL must be an https URL")
+	}
+	if !telegramSecretToken.MatchString(secret) {
+		return errors.New("TELEGRAM_WEBHOOK_SECRET must be 1-256 characters of A-Z, a-z, 0-9, _ and -")
+	}
+
+	p.webhook = &tele.Webhook{
+		SecretToken:    secret,
+		AllowedUpdates: []string{"message", "callback_query"},
+		Endpoint:       &tele.WebhookEndpoint{PublicURL: strings.TrimSuffix(publicURL, "/") + telegramWebhookPath},
+	}
+	return nil
+}
+
+func (p *TelegramPlatform) Name() string {
+	return Telegram
+}
+
+// Register mounts the update route on r in webhook mode
+func (p *TelegramPlatform) Register(r *gin.Engine) {
+	if p.webhook == nil {
+		return
+	}
+	r.POST(telegramWebhookPath, p.receive)
+}
+
+// Start registers the webhook with telegram in webhook mode, or removes it and polls telegram for updates in the background
+func (p *TelegramPlatform) Start() error {
+	if p.webhook != nil {
+		if err := p.bot.SetWebhook(p.webhook); err != nil {
+			return fmt.Errorf("registering telegram webhook: %w", err)
+		}
+		log.Printf("Telegram webhook registered at %s", p.webhook.Endpoint.PublicURL)
+		return nil
+	}
+
+	// telegram refuses getUpdates while a webhook is set, e.g. after switching back from webhook mode
+	if err := p.removeWebhook(); err != nil {
+		return fmt.Errorf("removing telegram webhook: %w", err)
+	}
+
+	go p.bot.Start()
+	return nil
+}
+
+// removeWebhook removes the webhook with a bot of its own, as requests of the polling bot
+// read state its Start writes
+func (p *TelegramPlatform) removeWebhook() error {
+	bot, err := tele.NewBot(tele.Settings{URL: p.settings.URL, Token: p.settings.Token, Client: p.settings.Client, Offline: true})
+	if err != nil {
+		return err
+	}
+
+	return bot.RemoveWebhook(false)
+}
+
+func (p *TelegramPlatform) receive(c *gin.Context) {
+	if subtle.ConstantTimeCompare([]byte(c.GetHeader(telegramSecretHeader)), []byte(p.webhook.SecretToken)) != 1 {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	var update tele.Update
+	if err := json.NewDecoder(io.LimitReader(c.Request.Body, 1<<20)).Decode(&update); err != nil {
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	// handlers run in their own goroutines unless the bot is synchronous, so telegram gets a quick answer
+	p.bot.ProcessUpdate(update)
+
+	c.Status(http.StatusOK)
+}
+
+func (p *TelegramPlatform) SendText(userId string, text string) error {
+	recipient, err := telegramRecipient(userId)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.bot.Send(recipient, text)
+	return err
+}
+
+func (p *TelegramPlatform) Typing(userId string) error {
+	recipient, err := telegramRecipient(userId)
+	if err != nil {
+		return err
+	}
+
+	return p.bot.Notify(recipient, tele.Typing)
+}
+
+func (p *TelegramPlatform) SendReminder(userId string, text string) error {
+	return p.SendText(userId, text)
+}
+
+// StartStream sends a placeholder message that is edited as the reply streams in
+func (p *TelegramPlatform) StartStream(userId string) (Stream, error) {
+	recipient, err := telegramRecipient(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	placeholder, err := p.bot.Send(recipient, templates.ReplyPlaceholder)
+	if err != nil {
+		return nil, err
+	}
+
+	return newStreamEditor(p.bot, recipient, placeholder), nil
+}
+
+func telegramRecipient(userId string) (*tele.User, error) {
+	id, err := strconv.ParseInt(userId, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid telegram user id %q: %w", userId, err)
+	}
+	return &tele.User{ID: id}, nil
+}
+
+// registerTelegramHandlers registers commands, buttons and journaling of text, photos and voice notes
+func registerTelegramHandlers(bot *tele.Bot) {
+
+	bot.Use(rememberLanguage)
+
+	registerGeminiKeyHandlers(bot)
+
+	// // On reply button pressed (message)
+	// bot.Handle(&btnHelp, func(c tele.Context) error {
+	// 	return c.Send("Here is some help: ...!")
+	// })
+
+	// handle default /start command from telegram
+	bot.Handle("/start", func(c tele.Context) error {
+		var username = c.Sender().Username
+
+		message := templates.WelcomeMessageSharedApiKey(languageOf(c), username)
+
+		if err := c.Send(message, &tele.SendOptions{ParseMode: tele.ModeMarkdownV2}); err != nil {
+			return err
+		}
+
+		return c.Send(templates.TimezonePrompt(languageOf(c), users.DefaultTimezone()), &tele.SendOptions{ReplyMarkup: timezoneMarkup()})
+	})
+
+	// handle timezone selection, /timezone {Area/City}
+	bot.Handle("/timezone", func(c tele.Context) error {
+		var userId = int(c.Sender().ID)
+		platformUserId, err := GetPlatformUserId(fmt.Sprint(userId))
+		if err != nil {
+			log.Printf("Error handling user id %d: %v", userId, err)
+			return c.Send(templates.Error(languageOf(c), templates.ErrorUser))
+		}
+
+		if c.Message().Payload == "" {
+			loc := users.GetUserLocation(context.Background(), platformUserId)
+			return c.Send(templates.TimezoneCurrent(languageOf(c), loc.String()), &tele.SendOptions{ReplyMarkup: timezoneMarkup()})
+		}
+
+		return setTimezone(c, platformUserId, strings.TrimSpace(c.Message().Payload))
+	})
+
+	// On timezone button pressed during onboarding
+	bot.Handle(&btnTimezone, func(c tele.Context) error {
+		var userId = int(c.Sender().ID)
+		platformUserId, err := GetPlatformUserId(fmt.Sprint(userId))
+		if err != nil {
+			log.Printf("Error handling user id %d: %v", userId, err)
+			return c.Respond(&tele.CallbackResponse{Text: templates.Error(languageOf(c), templates.ErrorUser)})
+		}
+
+		if err := c.Respond(); err != nil {
+			log.Printf("Error responding to callback for user %d: %v", userId, err)
+		}
+
+		return setTimezone(c, platformUserId, c.Data())
+	})
+
+	// handle manual summarize
+	bot.Handle("/summarize", func(c tele.Context) error {
+		var userId = int(c.Sender().ID)
+		platformUserId, err := GetPlatformUserId(fmt.Sprint(userId))
+		if err != nil {
+			log.Printf("Error handling user id %d: %v", userId, err)
+			return c.Send(templates.Error(languageOf(c), templates.ErrorUser))
+		}
+
+		cs, err := chatsession.ChatSessionClient.GetOrCreateChatSession(platformUserId, "")
+		if err != nil {
+			log.Printf("Error retrieving or creating chat session: %v", err)
+			return c.Send(templates.Error(languageOf(c), templates.ErrorGetChat))
+		}
+
+		analysis, err := chatsession.IngestChatSession(cs, platformUserId)
+		if err != nil {
+			log.Printf("Error ingesting chat session: %v", err)
+			return c.Send(templates.Error(languageOf(c), templates.ErrorGetChat))
+		}
+
+		out, err := json.Marshal(analysis)
+		if err != nil {
+			log.Printf("Error marshalling analysis: %v", err)
+			return c.Send(templates.Error(languageOf(c), templates.ErrorAnalysis))
+		}
+
+		return c.Send(string(out))
+	})
+
+	// handle export of full journal, /export [markdown|json|csv]
+	bot.Handle("/export", func(c tele.Context) error {
+		ctx := context.Background()
+		var userId = int(c.Sender().ID)
+		platformUserId, err := GetPlatformUserId(fmt.Sprint(userId))
+		if err != nil {
+			log.Printf("Error handling user id %d: %v", userId, err)
+			return c.Send(templates.Error(languageOf(c), templates.ErrorUser))
+		}
+
+		format, err := export.ParseFormat(c.Message().Payload)
+		if err != nil {
+			return c.Send(templates.ExportUsage(languageOf(c), export.Formats))
+		}
+
+		entries, err := storage.StoreClient.ListEntries(ctx, platformUserId, 0)
+		if err != nil {
+			log.Printf("Error retrieving entries for user %d: %v", userId, err)
+			return c.Send(templates.Error(languageOf(c), templates.ErrorEntries))
+		}
+
+		if len(entries) == 0 {
+			return c.Send(templates.ExportEmpty(languageOf(c)))
+		}
+
+		bot.Notify(c.Sender(), tele.UploadingDocument)
+
+		name := fmt.Sprintf("journie-%s", time.Now().In(users.GetUserLocation(ctx, platformUserId)).Format("2006-01-02"))
+		doc, err := export.Render(format, name, entries)
+		if err != nil {
+			log.Printf("Error rendering %s export for user %d: %v", format, userId, err)
+			return c.Send(templates.Error(languageOf(c), templates.ErrorExport))
+		}
+
+		return c.Send(&tele.Document{
+			File:     tele.FromReader(bytes.NewReader(doc.Data)),
+			FileName: doc.FileName,
+			MIME:     doc.MIME,
+			Caption:  templates.ExportCaption(languageOf(c), len(entries)),
+		})
+	})
+
+	registerHistoryHandlers(bot)
+
+	registerSettingsHandlers(bot)
+
+	registerPersonaHandlers(bot)
+
+	// handle mood trend chart, /mood [week|month|year]
+	bot.Handle("/mood", func(c tele.Context) error {
+		ctx := context.Background()
+		var userId = int(c.Sender().ID)
+		platformUserId, err := GetPlatformUserId(fmt.Sprint(userId))
+		if err != nil {
+			log.Printf("Error handling user id %d: %v", userId, err)
+			return c.Send(templates.Error(languageOf(c), templates.ErrorUser))
+		}
+
+		period, err := charts.ParsePeriod(c.Message().Payload)
+		if err != nil {
+			return c.Send(templates.MoodUsage(languageOf(c), charts.Periods))
+		}
+
+		today := time.Now().In(users.GetUserLocation(ctx, platformUserId))
+		from, _, err := charts.Range(period, today)
+		if err != nil {
+			return c.Send(templates.MoodUsage(languageOf(c), charts.Periods))
+		}
+
+		entries, err := storage.StoreClient.QueryEntries(ctx, platformUserId, storage.EntryQuery{
+			After: from.AddDate(0, 0, -1).Format("2006-01-02"),
+		})
+		if err != nil {
+			log.Printf("Error retrieving entries for user %d: %v", userId, err)
+			return c.Send(templates.Error(languageOf(c), templates.ErrorEntries))
+		}
+
+		if len(entries) == 0 {
+			return c.Send(templates.MoodEmpty(languageOf(c), period))
+		}
+
+		bot.Notify(c.Sender(), tele.UploadingPhoto)
+
+		chart, err := charts.MoodChart(templates.MoodChartTitle(period, today.Format("2006-01-02")), period, today, entries)
+		if err != nil {
+			log.Printf("Error rendering mood chart for user %d: %v", userId, err)
+			return c.Send(templates.Error(languageOf(c), templates.ErrorChart))
+		}
+
+		return c.Send(&tele.Photo{
+			File:    tele.FromReader(bytes.NewReader(chart)),
+			Caption: templates.MoodCaption(languageOf(c), period, len(entries)),
+		})
+	})
+
+	// handle manual command to clear session
+	bot.Handle("/clear", func(c tele.Context) error {
+		var userId = int(c.Sender().ID)
+		platformUserId, err := GetPlatformUserId(fmt.Sprint(userId))
+		if err != nil {
+			log.Printf("Error handling user id %d: %v", userId, err)
+			return c.Send(templates.Error(languageOf(c), templates.ErrorUser))
+		}
+
+		err = chatsession.ChatSessionClient.DeleteChatSession(platformUserId)
+		if err != nil {
+			log.Printf("Error deleting chat session: %v", err)
+			return c.Send(templates.Error(languageOf(c), templates.ErrorDeleteChat))
+		}
+
+		return c.Send(templates.ChatSessionDeleted(languageOf(c)))
+	})
+
+	// All other text messages to be handled by Journie
+	bot.Handle(tele.OnText, func(c tele.Context) error {
+		var (
+			sender = c.Sender()
+			text   = c.Text()
+		)
+		userId := int(sender.ID)
+		platformUserId, err := GetPlatformUserId(fmt.Sprint(userId))
+		if err != nil {
+			log.Printf("Error handling user id %d: %v", userId, err)
+			return c.Send(templates.Error(languageOf(c), templates.ErrorUser))
+		}
+
+		// reply to a question from the bot, e.g. date to jump to, instead of journaling.
+		// Anything not answering it is journaled, so the user's words are never lost
+		if kind, ok := pending.Get(sender.ID); ok {
+			switch kind {
+			case pendingHistoryDate:
+				if date := strings.TrimSpace(text); isHistoryDate(date) {
+					pending.Clear(sender.ID)
+					return sendHistoryAtDate(c, platformUserId, date)
+				}
+			}
+		}
+
+		// pasted gemini key, store it instead of sending it to the model
+		if key := generative.FindGeminiKey(text); key != "" {
+			return saveGeminiKey(c, platformUserId, key)
+		}
+
+		return Journal(context.Background(), Platforms[Telegram], fmt.Sprint(sender.ID), generative.Text(text))
+	})
+
+	registerPhotoHandlers(bot)
+
+	bot.Handle(tele.OnVideo, func(c tele.Context) error {
+		return c.Send(templates.VideoUnsupported(languageOf(c)))
+	})
+
+	registerVoiceHandlers(bot)
+}
+
+// languageKey keeps the language to reply in on telegram contexts, see languageOf
+const languageKey = "language"
+
+// rememberLanguage keeps the language to reply in on the context, cached per user. The language code of
+// the sender's telegram app is stored for existing users so reminders and summaries follow it, people who
+// never used the bot are not stored, e.g. to be reminded
+func rememberLanguage(next tele.HandlerFunc) tele.HandlerFunc {
+	return func(c tele.Context) error {
+		sender := c.Sender()
+		if sender == nil {
+			return next(c)
+		}
+
+		if lang, ok := languages.Get(sender.ID, sender.LanguageCode); ok {
+			c.Set(languageKey, lang)
+			return next(c)
+		}
+
+		ctx := context.Background()
+		platformUserId := PlatformUserId(Telegram, fmt.Sprint(sender.ID))
+		user, err := storage.StoreClient.GetUser(ctx, platformUserId)
+		switch {
+		case err == storage.ErrNotFound:
+			// not cached, so their language code is stored once they become a user
+			c.Set(languageKey, users.Language(&storage.User{LanguageCode: sender.LanguageCode}))
+			return next(c)
+		case err != nil:
+			log.Printf("Error retrieving user %s: %v", platformUserId, err)
+			c.Set(languageKey, users.Language(&storage.User{LanguageCode: sender.LanguageCode}))
+			return next(c)
+		}
+
+		if sender.LanguageCode != "" && user.LanguageCode != sender.LanguageCode {
+			if err := storage.StoreClient.UpsertUserLanguageCode(ctx, platformUserId, sender.LanguageCode); err != nil {
+				log.Printf("Error storing language of user %s: %v", platformUserId, err)
+			}
+			user.LanguageCode = sender.LanguageCode
+		}
+
+		lang := users.Language(user)
+		languages.Set(sender.ID, sender.LanguageCode, lang)
+		c.Set(languageKey, lang)
+		return next(c)
+	}
+}
+
+// languageOf returns the language to reply to the sender of c in, from their settings or telegram app
+func languageOf(c tele.Context) string {
+	if lang, ok := c.Get(languageKey).(string); ok {
+		return lang
+	}
+	return i18n.Match(c.Sender().LanguageCode)
+}
+
+func timezoneMarkup() *tele.ReplyMarkup {
+	markup := &tele.ReplyMarkup{}
+
+	var rows []tele.Row
+	for i := 0; i < len(onboardingTimezones); i += 2 {
+		var buttons []tele.Btn
+		for _, tz := range onboardingTimezones[i:min(i+2, len(onboardingTimezones))] {
+			buttons = append(buttons, markup.Data(tz[0], btnTimezone.Unique, tz[1]))
+		}
+		rows = append(rows, markup.Row(buttons...))
+	}
+	markup.Inline(rows...)
+
+	return markup
+}
+
+func setTimezone(c tele.Context, platformUserId string, timezone string) error {
+	loc, err := users.SetTimezone(context.Background(), platformUserId, timezone)
+	if err != nil {
+		log.Printf("Error setting timezone %q for user %s: %v", timezone, platformUserId, err)
+		return c.Send(templates.TimezoneInvalid(languageOf(c), timezone))
+	}
+
+	return c.Send(templates.TimezoneUpdated(languageOf(c), loc.String(), time.Now().In(loc).Format("15:04")))
+}
@@ -0,0 +1,267 @@
+package messaging_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	chatsession "journie/pkg/chat-session"
+	"journie/pkg/generative"
+	"journie/pkg/messaging"
+	"journie/pkg/storage"
+	"journie/pkg/templates"
+	"journie/pkg/users"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	tele "gopkg.in/telebot.v3"
+)
+
+// TestTelegramWebhook posts updates to the telegram webhook route, checking
+// posts without telegram's secret token header are refused.
+func TestTelegramWebhook(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	telegram, err := messaging.NewTelegramPlatform(tele.Settings{Offline: true, Synchronous: true})
+	if err != nil {
+		t.Fatalf(`NewTelegramPlatform() = %v, want nil`, err)
+	}
+
+	if err := telegram.UseWebhook("http://journie.example.com", "s3cret"); err == nil {
+		t.Fatalf(`UseWebhook() with http URL = nil, want error`)
+	}
+	if err := telegram.UseWebhook("https://journie.example.com", "not a token!"); err == nil {
+		t.Fatalf(`UseWebhook() with invalid secret = nil, want error`)
+	}
+	if err := telegram.UseWebhook("https://journie.example.com/", "s3cret"); err != nil {
+		t.Fatalf(`UseWebhook() = %v, want nil`, err)
+	}
+
+	r := gin.New()
+	telegram.Register(r)
+
+	tests := []struct {
+		secret string
+		want   int
+	}{
+		{"", http.StatusUnauthorized},
+		{"wrong", http.StatusUnauthorized},
+		{"s3cret", http.StatusOK},
+	}
+
+	for _, test := range tests {
+		request := httptest.NewRequest(http.MethodPost, "/telegram/webhook", bytes.NewReader([]byte(`{"update_id": 1}`)))
+		if test.secret != "" {
+			request.Header.Set("X-Telegram-Bot-Api-Secret-Token", test.secret)
+		}
+
+		recorder := httptest.NewRecorder()
+		r.ServeHTTP(recorder, request)
+		if recorder.Code != test.want {
+			t.Errorf(`POST /telegram/webhook with secret %q = %d, want %d`, test.secret, recorder.Code, test.want)
+		}
+	}
+}
+
+// botCall is a call received by botAPI, Text is the text sent or edited, if any
+type botCall struct {
+	Method string
+	Text   string
+}
+
+// botAPI is a fake telegram bot API recording calls, edits fail with 429 when failEdits is set
+type botAPI struct {
+	*httptest.Server
+	calls     []botCall
+	failEdits bool
+	mu        sync.Mutex
+}
+
+func newBotAPI(t *testing.T) *botAPI {
+	api := &botAPI{}
+	api.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Text string `json:"text"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		api.mu.Lock()
+		defer api.mu.Unlock()
+
+		method := path.Base(r.URL.Path)
+		api.calls = append(api.calls, botCall{Method: method, Text: body.Text})
+
+		switch {
+		case method == "sendMessage" || method == "editMessageText" && !api.failEdits:
+			fmt.Fprintf(w, `{"ok": true, "result": {"message_id": %d, "chat": {"id": 1}}}`, len(api.calls))
+		case method == "editMessageText":
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"ok": false, "error_code": 429, "description": "Too Many Requests: retry after 5", "parameters": {"retry_after": 5}}`)
+		default:
+			fmt.Fprint(w, `{"ok": true, "result": true}`)
+		}
+	}))
+	t.Cleanup(api.Close)
+
+	return api
+}
+
+// sent returns the texts sent or edited since the last call
+func (api *botAPI) sent() []botCall {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	var calls []botCall
+	for _, call := range api.calls {
+		if call.Text != "" {
+			calls = append(calls, call)
+		}
+	}
+	api.calls = nil
+
+	return calls
+}
+
+// idlePoller receives no updates until the bot stops, closing started once polling
+type idlePoller struct {
+	started chan struct{}
+}
+
+func (p idlePoller) Poll(b *tele.Bot, updates chan tele.Update, stop chan struct{}) {
+	close(p.started)
+	<-stop
+}
+
+// TestStartPolling starts the telegram platform without a webhook, checking a webhook left
+// from webhook mode is removed before polling, or telegram would refuse getUpdates.
+func TestStartPolling(t *testing.T) {
+	api := newBotAPI(t)
+	poller := idlePoller{started: make(chan struct{})}
+	telegram, err := messaging.NewTelegramPlatform(tele.Settings{URL: api.URL, Token: "token", Offline: true, Poller: poller})
+	if err != nil {
+		t.Fatalf(`NewTelegramPlatform() = %v, want nil`, err)
+	}
+
+	if err := telegram.Start(); err != nil {
+		t.Fatalf(`Start() = %v, want nil`, err)
+	}
+	<-poller.started
+	defer messaging.TeleBot.Stop()
+
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	if len(api.calls) != 1 || api.calls[0].Method != "deleteWebhook" {
+		t.Fatalf(`calls = %v, want deleteWebhook`, api.calls)
+	}
+}
+
+// TestStreamFinishFallback finishes a streamed reply while telegram refuses edits, checking
+// the complete reply is sent as a new message and the placeholder is deleted.
+func TestStreamFinishFallback(t *testing.T) {
+	api := newBotAPI(t)
+	api.failEdits = true
+
+	telegram, err := messaging.NewTelegramPlatform(tele.Settings{URL: api.URL, Token: "token", Offline: true, Synchronous: true})
+	if err != nil {
+		t.Fatalf(`NewTelegramPlatform() = %v, want nil`, err)
+	}
+
+	stream, err := telegram.StartStream("1")
+	if err != nil {
+		t.Fatalf(`StartStream() = %v, want nil`, err)
+	}
+	api.sent()
+	if err := stream.Finish("Thanks for sharing your day."); err != nil {
+		t.Fatalf(`Finish() = %v, want nil`, err)
+	}
+
+	api.mu.Lock()
+	calls := append([]botCall(nil), api.calls...)
+	api.mu.Unlock()
+	want := []botCall{{"editMessageText", "Thanks for sharing your day."}, {"sendMessage", "Thanks for sharing your day."}, {"deleteMessage", ""}}
+	if !reflect.DeepEqual(calls, want) {
+		t.Fatalf(`calls = %v, want the reply sent in place of the placeholder %v`, calls, want)
+	}
+}
+
+// TestJumpToDateJournalsOtherText presses "Jump to date" then sends journal text, checking
+// the text is journaled rather than refused as a date, and a date sent next still jumps.
+func TestJumpToDateJournalsOtherText(t *testing.T) {
+	storage.StoreClient = storage.NewMemoryStore()
+	generative.GenAiClient = generative.NewGenAiManager(generative.NewFakeProvider("How was your day?"))
+	chatsession.Init()
+
+	api := newBotAPI(t)
+	telegram, err := messaging.NewTelegramPlatform(tele.Settings{URL: api.URL, Token: "token", Offline: true, Synchronous: true})
+	if err != nil {
+		t.Fatalf(`NewTelegramPlatform() = %v, want nil`, err)
+	}
+	messaging.Platforms[messaging.Telegram] = telegram
+	defer delete(messaging.Platforms, messaging.Telegram)
+
+	sender := &tele.User{ID: 1}
+	chat := &tele.Chat{ID: 1}
+	message := func(text string) tele.Update {
+		return tele.Update{Message: &tele.Message{ID: 2, Sender: sender, Chat: chat, Text: text}}
+	}
+
+	messaging.TeleBot.ProcessUpdate(tele.Update{Callback: &tele.Callback{ID: "1", Sender: sender, Data: "\fhistory-jump|2024-05-20", Message: &tele.Message{ID: 1, Chat: chat}}})
+	if sent := api.sent(); len(sent) != 1 || sent[0].Text != templates.HistoryJumpPrompt("") {
+		t.Fatalf(`sent on jump = %v, want the date prompt`, sent)
+	}
+
+	messaging.TeleBot.ProcessUpdate(message("Had a long day at work"))
+	if sent := api.sent(); len(sent) == 0 || sent[len(sent)-1].Text != "How was your day?" {
+		t.Fatalf(`sent on journal text = %v, want Journie's reply`, sent)
+	}
+
+	messaging.TeleBot.ProcessUpdate(message("2024-05-20"))
+	if sent := api.sent(); len(sent) != 1 || sent[0].Text != templates.HistoryNoneBefore("", "2024-05-20") {
+		t.Fatalf(`sent on date = %v, want the entry at the date`, sent)
+	}
+}
+
+// TestRememberLanguage sends updates from a Spanish telegram app, checking people who never
+// used the bot are replied to in Spanish without being stored, and users get their language
+// code stored once, then cached.
+func TestRememberLanguage(t *testing.T) {
+	ctx := context.Background()
+	storage.StoreClient = storage.NewMemoryStore()
+
+	api := newBotAPI(t)
+	if _, err := messaging.NewTelegramPlatform(tele.Settings{URL: api.URL, Token: "token", Offline: true, Synchronous: true}); err != nil {
+		t.Fatalf(`NewTelegramPlatform() = %v, want nil`, err)
+	}
+
+	timezone := func(userId int64) tele.Update {
+		sender := &tele.User{ID: userId, LanguageCode: "es"}
+		return tele.Update{Message: &tele.Message{ID: 1, Sender: sender, Chat: &tele.Chat{ID: userId}, Text: "/timezone"}}
+	}
+
+	messaging.TeleBot.ProcessUpdate(timezone(1))
+	if sent := api.sent(); len(sent) != 1 || sent[0].Text != templates.TimezoneCurrent("es", users.DefaultTimezone()) {
+		t.Fatalf(`sent to a stranger = %v, want the timezone in Spanish`, sent)
+	}
+	if _, err := storage.StoreClient.GetUser(ctx, "telegram-1"); err != storage.ErrNotFound {
+		t.Fatalf(`GetUser() of a stranger = %v, want ErrNotFound`, err)
+	}
+
+	storage.StoreClient.UpsertUserTimezone(ctx, "telegram-2", "Europe/Madrid")
+	messaging.TeleBot.ProcessUpdate(timezone(2))
+	if user, err := storage.StoreClient.GetUser(ctx, "telegram-2"); err != nil || user.LanguageCode != "es" {
+		t.Fatalf(`GetUser() of a user = %+v, %v, want language code stored`, user, err)
+	}
+
+	// cached, a language picked on another instance is only followed once the cache expires
+	storage.StoreClient.UpsertUserSettings(ctx, "telegram-2", &storage.Settings{Language: "ja"})
+	api.sent()
+	messaging.TeleBot.ProcessUpdate(timezone(2))
+	if sent := api.sent(); len(sent) != 1 || sent[0].Text != templates.TimezoneCurrent("es", "Europe/Madrid") {
+		t.Fatalf(`sent to a cached user = %v, want the timezone in Spanish`, sent)
+	}
+}
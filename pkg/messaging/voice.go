@@ -0,0 +1,79 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"journie/pkg/generative"
+	"journie/pkg/templates"
+	"journie/pkg/transcribe"
+	"log"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+// maxDownloadBytes is the largest file downloaded, Telegram bots can download up to 20MB
+const maxDownloadBytes = 20 << 20
+
+func registerVoiceHandlers(bot *tele.Bot) {
+	// transcribe voice notes and journal them as if typed
+	bot.Handle(tele.OnVoice, func(c tele.Context) error {
+		ctx := context.Background()
+		var userId = int(c.Sender().ID)
+
+		if transcribe.TranscriberClient == nil {
+			return c.Send(templates.VoiceUnavailable(languageOf(c)))
+		}
+
+		voice := c.Message().Voice
+		if voice.FileSize > maxDownloadBytes {
+			return c.Send(templates.VoiceTooLong(languageOf(c)))
+		}
+
+		// refuse before the download and the transcription, which costs a request too
+		lang, ok := admit(ctx, Platforms[Telegram], fmt.Sprint(userId))
+		if !ok {
+			return nil
+		}
+
+		bot.Notify(c.Sender(), tele.Typing)
+
+		audio, err := downloadFile(bot, &voice.File)
+		if err != nil {
+			log.Printf("Error downloading voice note of user %d: %v", userId, err)
+			return c.Send(templates.Error(languageOf(c), templates.ErrorVoice))
+		}
+
+		mimeType := voice.MIME
+		if mimeType == "" {
+			mimeType = "audio/ogg"
+		}
+
+		transcript, err := transcribe.TranscriberClient.Transcribe(ctx, audio, mimeType)
+		if errors.Is(err, transcribe.ErrEmptyTranscript) {
+			return c.Send(templates.VoiceEmpty(languageOf(c)))
+		}
+		if err != nil {
+			log.Printf("Error transcribing voice note of user %d: %v", userId, err)
+			return c.Send(templates.Error(languageOf(c), templates.ErrorTranscribe))
+		}
+
+		// echo so the user can check what Journie heard
+		if err := c.Reply(templates.VoiceTranscript(languageOf(c), transcript)); err != nil {
+			log.Printf("Error echoing transcript to user %d: %v", userId, err)
+		}
+
+		return journal(ctx, Platforms[Telegram], fmt.Sprint(userId), lang, generative.Text(transcript))
+	})
+}
+
+func downloadFile(bot *tele.Bot, file *tele.File) ([]byte, error) {
+	reader, err := bot.File(file)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return io.ReadAll(io.LimitReader(reader, maxDownloadBytes))
+}
@@ -0,0 +1,168 @@
+package messaging
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"journie/pkg/generative"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// signatureHeader carries the hex HMAC-SHA256 of the timestamp, a dot and the body keyed with WEBHOOK_SECRET, in both directions
+const signatureHeader = "X-Journie-Signature"
+
+// timestampHeader carries the unix time in seconds a request was signed at, so captured requests cannot be replayed later
+const timestampHeader = "X-Journie-Timestamp"
+
+// signatureTolerance is how far the timestamp of a message may be from now
+const signatureTolerance = 5 * time.Minute
+
+// WebhookPlatform is a generic chat integration over HTTP. Chat services post user messages to
+// /webhook/messages, and Journie posts replies, typing indicators and reminders to WEBHOOK_OUTBOUND_URL
+type WebhookPlatform struct {
+	secret      []byte
+	outboundURL string
+	client      *http.Client
+}
+
+// WebhookMessage is a message from a user, posted to /webhook/messages
+type WebhookMessage struct {
+	UserId string `json:"userId"`
+	Text   string `json:"text"`
+}
+
+// WebhookEvent is posted to the outbound URL, Type is message, typing or reminder
+type WebhookEvent struct {
+	Type   string `json:"type"`
+	UserId string `json:"userId"`
+	Text   string `json:"text,omitempty"`
+}
+
+func NewWebhookPlatform(secret string, outboundURL string) *WebhookPlatform {
+	return &WebhookPlatform{
+		secret:      []byte(secret),
+		outboundURL: outboundURL,
+		client:      &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (p *WebhookPlatform) Name() string {
+	return Webhook
+}
+
+// Register mounts the inbound message route on r
+func (p *WebhookPlatform) Register(r *gin.Engine) {
+	r.POST("/webhook/messages", p.receive)
+}
+
+// Start checks replies can be delivered, messages are received by the route mounted with Register
+func (p *WebhookPlatform) Start() error {
+	if p.outboundURL == "" {
+		return errors.New("WEBHOOK_OUTBOUND_URL not set")
+	}
+	return nil
+}
+
+func (p *WebhookPlatform) SendText(userId string, text string) error {
+	return p.post(WebhookEvent{Type: "message", UserId: userId, Text: text})
+}
+
+func (p *WebhookPlatform) Typing(userId string) error {
+	return p.post(WebhookEvent{Type: "typing", UserId: userId})
+}
+
+func (p *WebhookPlatform) SendReminder(userId string, text string) error {
+	return p.post(WebhookEvent{Type: "reminder", UserId: userId, Text: text})
+}
+
+func (p *WebhookPlatform) receive(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, 64*1024))
+	if err != nil {
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	timestamp := c.GetHeader(timestampHeader)
+	if !hmac.Equal([]byte(c.GetHeader(signatureHeader)), []byte(p.sign(timestamp, body))) {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid signature"})
+		return
+	}
+	if !fresh(timestamp, time.Now()) {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "stale timestamp"})
+		return
+	}
+
+	var message WebhookMessage
+	if err := json.Unmarshal(body, &message); err != nil || strings.TrimSpace(message.UserId) == "" || strings.TrimSpace(message.Text) == "" {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "userId and text are required"})
+		return
+	}
+
+	// replies take a while, they are posted to the outbound URL when ready
+	go func() {
+		if err := Journal(context.Background(), p, message.UserId, generative.Text(message.Text)); err != nil {
+			log.Printf("Error journaling webhook message of user %s: %v", message.UserId, err)
+		}
+	}()
+
+	c.JSON(http.StatusAccepted, gin.H{"status": "accepted"})
+}
+
+func (p *WebhookPlatform) post(event WebhookEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, p.outboundURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set(timestampHeader, timestamp)
+	req.Header.Set(signatureHeader, p.sign(timestamp, body))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook outbound: status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// sign returns the signature of timestamp and body in the format of signatureHeader, sha256={hex}
+func (p *WebhookPlatform) sign(timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// fresh reports whether timestamp, in unix seconds, is within signatureTolerance of now
+func fresh(timestamp string, now time.Time) bool {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+
+	age := now.Sub(time.Unix(seconds, 0))
+	return age > -signatureTolerance && age < signatureTolerance
+}
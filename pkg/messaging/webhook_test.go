@@ -0,0 +1,185 @@
+package messaging_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	chatsession "journie/pkg/chat-session"
+	"journie/pkg/generative"
+	"journie/pkg/messaging"
+	"journie/pkg/ratelimit"
+	"journie/pkg/scheduler"
+	"journie/pkg/storage"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// signedRequest posts body to the webhook platform, signed at the given time
+func signedRequest(secret string, at time.Time, body []byte) *http.Request {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	req := httptest.NewRequest(http.MethodPost, "/webhook/messages", bytes.NewReader(body))
+	req.Header.Set("X-Journie-Timestamp", timestamp)
+	req.Header.Set("X-Journie-Signature", sign(secret, timestamp, body))
+	return req
+}
+
+// newOutbound starts a server collecting events posted by the webhook platform
+func newOutbound(t *testing.T, secret string) (*httptest.Server, chan messaging.WebhookEvent) {
+	events := make(chan messaging.WebhookEvent, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body bytes.Buffer
+		body.ReadFrom(r.Body)
+		if r.Header.Get("X-Journie-Signature") != sign(secret, r.Header.Get("X-Journie-Timestamp"), body.Bytes()) {
+			t.Errorf(`outbound signature = %q, want signed timestamp and body`, r.Header.Get("X-Journie-Signature"))
+		}
+		if timestamp, _ := strconv.ParseInt(r.Header.Get("X-Journie-Timestamp"), 10, 64); time.Since(time.Unix(timestamp, 0)) > time.Minute {
+			t.Errorf(`outbound timestamp = %q, want now`, r.Header.Get("X-Journie-Timestamp"))
+		}
+
+		var event messaging.WebhookEvent
+		json.Unmarshal(body.Bytes(), &event)
+		events <- event
+	}))
+	t.Cleanup(server.Close)
+
+	return server, events
+}
+
+func nextEvent(t *testing.T, events chan messaging.WebhookEvent, eventType string) messaging.WebhookEvent {
+	for {
+		select {
+		case event := <-events:
+			if event.Type == eventType {
+				return event
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf(`no %s event posted to outbound URL`, eventType)
+		}
+	}
+}
+
+// TestWebhookJournal posts a signed message to the webhook platform, checking
+// unsigned and stale messages are refused and the reply is posted to the outbound URL.
+func TestWebhookJournal(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	storage.StoreClient = storage.NewMemoryStore()
+	generative.GenAiClient = generative.NewGenAiManager(generative.NewFakeProvider("How was your day?"))
+	chatsession.Init()
+
+	outbound, events := newOutbound(t, "s3cret")
+	webhook := messaging.NewWebhookPlatform("s3cret", outbound.URL)
+	r := gin.New()
+	webhook.Register(r)
+
+	body := []byte(`{"userId": "alice", "text": "hi journie"}`)
+
+	unsigned := httptest.NewRecorder()
+	r.ServeHTTP(unsigned, httptest.NewRequest(http.MethodPost, "/webhook/messages", bytes.NewReader(body)))
+	if unsigned.Code != http.StatusUnauthorized {
+		t.Fatalf(`unsigned POST /webhook/messages = %d, want 401`, unsigned.Code)
+	}
+
+	// a captured message replayed later is refused, as is a signature with its timestamp changed
+	stale := httptest.NewRecorder()
+	r.ServeHTTP(stale, signedRequest("s3cret", time.Now().Add(-10*time.Minute), body))
+	if stale.Code != http.StatusUnauthorized {
+		t.Fatalf(`stale POST /webhook/messages = %d, want 401`, stale.Code)
+	}
+	tampered := signedRequest("s3cret", time.Now().Add(-10*time.Minute), body)
+	tampered.Header.Set("X-Journie-Timestamp", strconv.FormatInt(time.Now().Unix(), 10))
+	forged := httptest.NewRecorder()
+	r.ServeHTTP(forged, tampered)
+	if forged.Code != http.StatusUnauthorized {
+		t.Fatalf(`POST /webhook/messages with a changed timestamp = %d, want 401`, forged.Code)
+	}
+
+	signed := httptest.NewRecorder()
+	r.ServeHTTP(signed, signedRequest("s3cret", time.Now(), body))
+	if signed.Code != http.StatusAccepted {
+		t.Fatalf(`signed POST /webhook/messages = %d, want 202`, signed.Code)
+	}
+
+	if event := nextEvent(t, events, "message"); event.UserId != "alice" || event.Text != "How was your day?" {
+		t.Fatalf(`outbound event = %v, want reply to alice`, event)
+	}
+
+	if _, err := storage.StoreClient.GetSession(context.Background(), "webhook-alice"); err != nil {
+		t.Fatalf(`GetSession("webhook-alice") = %v, want session saved`, err)
+	}
+}
+
+// TestRemindDailyDispatch reminds a webhook user at their reminder hour,
+// checking the reminder goes out through the user's platform.
+func TestRemindDailyDispatch(t *testing.T) {
+	storage.StoreClient = storage.NewMemoryStore()
+	storage.StoreClient.UpsertUserTimezone(context.Background(), "webhook-bob", "UTC")
+
+	outbound, events := newOutbound(t, "s3cret")
+	messaging.Platforms[messaging.Webhook] = messaging.NewWebhookPlatform("s3cret", outbound.URL)
+	defer delete(messaging.Platforms, messaging.Webhook)
+
+	run := scheduler.NewRun("remind", time.Date(2024, 5, 20, messaging.ReminderHour, 0, 0, 0, time.UTC))
+	if err := messaging.RemindDaily(run); err != nil {
+		t.Fatalf(`RemindDaily() = %v, want nil`, err)
+	}
+
+	if event := nextEvent(t, events, "reminder"); event.UserId != "bob" {
+		t.Fatalf(`reminder event = %v, want reminder to bob`, event)
+	}
+
+	// a resumed run skips users already reminded
+	if err := messaging.RemindDaily(run); err != nil {
+		t.Fatalf(`RemindDaily() resumed = %v, want nil`, err)
+	}
+	select {
+	case event := <-events:
+		t.Fatalf(`resumed RemindDaily() posted %v, want nothing`, event)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// TestJournalRateLimited journals twice with a burst of one message, checking the
+// second message is answered with when the user can continue instead of a reply.
+func TestJournalRateLimited(t *testing.T) {
+	storage.StoreClient = storage.NewMemoryStore()
+	fake := generative.NewFakeProvider("How was your day?")
+	generative.GenAiClient = generative.NewGenAiManager(fake)
+	chatsession.Init()
+
+	ratelimit.LimiterClient = ratelimit.NewLimiter(map[string]ratelimit.Limits{
+		ratelimit.Shared: {PerMinute: 1, Burst: 1},
+	})
+	defer func() { ratelimit.LimiterClient = nil }()
+
+	outbound, events := newOutbound(t, "s3cret")
+	webhook := messaging.NewWebhookPlatform("s3cret", outbound.URL)
+
+	messaging.Journal(context.Background(), webhook, "carol", generative.Text("hi journie"))
+	if event := nextEvent(t, events, "message"); event.Text != "How was your day?" {
+		t.Fatalf(`first reply = %v, want model reply`, event)
+	}
+
+	messaging.Journal(context.Background(), webhook, "carol", generative.Text("hello again"))
+	if event := nextEvent(t, events, "message"); !strings.Contains(event.Text, "continue in") {
+		t.Fatalf(`second reply = %v, want rate limited message`, event)
+	}
+	if len(fake.Calls) != 1 {
+		t.Fatalf(`provider calls = %d, want 1`, len(fake.Calls))
+	}
+}
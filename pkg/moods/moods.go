@@ -0,0 +1,30 @@
+package moods
+
+import "strings"
+
+const (
+	Happy    string = "happy"
+	Sad      string = "sad"
+	Fear     string = "fear"
+	Disgust  string = "disgust"
+	Anger    string = "anger"
+	Surprise string = "surprise"
+	Neutral  string = "neutral"
+)
+
+// All moods an entry can be tagged with, in the order they are shown to users
+var All = []string{Happy, Surprise, Neutral, Sad, Fear, Disgust, Anger}
+
+// MaxPerEntry is the most moods a single entry can have
+const MaxPerEntry = 2
+
+// Normalize lowercases and trims mood, returning false if it is not one of All
+func Normalize(mood string) (string, bool) {
+	mood = strings.ToLower(strings.TrimSpace(mood))
+	for _, m := range All {
+		if m == mood {
+			return m, true
+		}
+	}
+	return "", false
+}
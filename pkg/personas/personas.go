@@ -0,0 +1,124 @@
+package personas
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+)
+
+// Persona is a way Journie talks to users, picked with /persona or /settings
+type Persona struct {
+	Key          string   `json:"key"`          // stored in user settings, keep it once users picked it
+	Name         string   `json:"name"`         // shown to users, unless the message catalogs name Key
+	Description  string   `json:"description"`  // shown under the name in /persona
+	Instructions []string `json:"instructions"` // open the system instruction of every new chat
+}
+
+// builtin personas, the first is the default
+//
+//go:embed personas.json
+var builtin []byte
+
+// personas in the order they are offered, the built-in ones until Init reads PERSONAS_FILE
+var personas = mustParse(builtin)
+
+var validKey = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+// Init adds the personas of the JSON file at PERSONAS_FILE, if set, to the built-in ones.
+// A persona with the key of a built-in one replaces it, others are offered after them
+func Init() error {
+	all := mustParse(builtin)
+
+	if path := os.Getenv("PERSONAS_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading PERSONAS_FILE: %v", err)
+		}
+		custom, err := Parse(data)
+		if err != nil {
+			return fmt.Errorf("invalid PERSONAS_FILE %s: %v", path, err)
+		}
+		all = merge(all, custom)
+	}
+
+	personas = all
+
+	keys := make([]string, len(personas))
+	for i, persona := range personas {
+		keys[i] = persona.Key
+	}
+	log.Printf("Personas: %v", keys)
+
+	return nil
+}
+
+// Parse reads a JSON array of personas, each with a unique key, a name and instructions
+func Parse(data []byte) ([]Persona, error) {
+	var parsed []Persona
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	for _, persona := range parsed {
+		switch {
+		case !validKey.MatchString(persona.Key):
+			return nil, fmt.Errorf("invalid key %q, use up to 32 lowercase letters, digits, - or _", persona.Key)
+		case seen[persona.Key]:
+			return nil, fmt.Errorf("duplicate key %q", persona.Key)
+		case persona.Name == "":
+			return nil, fmt.Errorf("persona %q has no name", persona.Key)
+		case len(persona.Instructions) == 0:
+			return nil, fmt.Errorf("persona %q has no instructions", persona.Key)
+		}
+		seen[persona.Key] = true
+	}
+
+	return parsed, nil
+}
+
+// All returns the personas in the order they are offered
+func All() []Persona {
+	return append([]Persona(nil), personas...)
+}
+
+// Get returns the persona of key, and whether it exists
+func Get(key string) (Persona, bool) {
+	for _, persona := range personas {
+		if persona.Key == key {
+			return persona, true
+		}
+	}
+	return Persona{}, false
+}
+
+// Default is the persona of users who never picked one, or whose persona was removed
+func Default() Persona {
+	return personas[0]
+}
+
+func merge(base []Persona, custom []Persona) []Persona {
+	for _, persona := range custom {
+		replaced := false
+		for i := range base {
+			if base[i].Key == persona.Key {
+				base[i], replaced = persona, true
+			}
+		}
+		if !replaced {
+			base = append(base, persona)
+		}
+	}
+	return base
+}
+
+func mustParse(data []byte) []Persona {
+	parsed, err := Parse(data)
+	if err != nil || len(parsed) == 0 {
+		panic(fmt.Sprintf("invalid built-in personas: %v", err))
+	}
+	return parsed
+}
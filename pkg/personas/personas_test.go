@@ -0,0 +1,51 @@
+package personas_test
+
+import (
+	"journie/pkg/personas"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestInit loads a PERSONAS_FILE, checking its personas replace built-in ones of the same key
+// and are offered after the others, and that invalid files are refused.
+func TestInit(t *testing.T) {
+	t.Cleanup(func() { personas.Init() })
+
+	path := filepath.Join(t.TempDir(), "personas.json")
+	os.WriteFile(path, []byte(`[
+		{"key": "brief", "name": "Terse logger", "instructions": ["Reply in five words."]},
+		{"key": "coach", "name": "Running coach", "instructions": ["Ask about training."]}
+	]`), 0o600)
+	t.Setenv("PERSONAS_FILE", path)
+
+	if err := personas.Init(); err != nil {
+		t.Fatalf(`Init() = %v, want nil`, err)
+	}
+	all := personas.All()
+	if last := all[len(all)-1]; last.Key != "coach" {
+		t.Errorf(`All() ends with %q, want coach`, last.Key)
+	}
+	if brief, ok := personas.Get("brief"); !ok || brief.Name != "Terse logger" {
+		t.Errorf(`Get("brief") = %+v, %v, want the one of the file`, brief, ok)
+	}
+	if got := personas.Default().Key; got != "gentle" {
+		t.Errorf(`Default() = %q, want gentle`, got)
+	}
+
+	invalid := []string{
+		`{"key": "coach"}`,
+		`[{"key": "Coach!", "name": "Coach", "instructions": ["Ask."]}]`,
+		`[{"key": "coach", "name": "Coach"}]`,
+		`[{"key": "coach", "name": "Coach", "instructions": ["Ask."]}, {"key": "coach", "name": "Coach", "instructions": ["Ask."]}]`,
+	}
+	for _, data := range invalid {
+		os.WriteFile(path, []byte(data), 0o600)
+		if err := personas.Init(); err == nil {
+			t.Errorf(`Init() of %s = nil, want error`, data)
+		}
+	}
+	if _, ok := personas.Get("coach"); !ok {
+		t.Errorf(`Get("coach") after invalid files = false, want personas kept`)
+	}
+}
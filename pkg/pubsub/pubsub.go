@@ -3,7 +3,7 @@ package pubsub
 import (
 	"context"
 	"fmt"
-	"journie/pkg/messaging"
+	"journie/pkg/scheduler"
 	"log"
 	"os"
 
@@ -12,6 +12,7 @@ import (
 )
 
 // SubscribeToTopic subscribes to a Pub/Sub topic and receives messages.
+// Each message ticks the scheduler, as a trigger source next to or instead of the in-process cron
 func SubscribeToTopic(ctx context.Context, projectID, topicName string, subscriptionName string) error {
 	opt := option.WithCredentialsJSON([]byte(os.Getenv("FIREBASE_CREDENTIALS")))
 	client, err := pubsub.NewClient(ctx, projectID, opt)
@@ -28,18 +29,8 @@ func SubscribeToTopic(ctx context.Context, projectID, topicName string, subscrip
 		err = sub.Receive(ctx, func(ctx context.Context, msg *pubsub.Message) {
 			log.Println("Received message at:", msg.PublishTime)
 
-			// hourly handler
-			var now = msg.PublishTime
-
-			// go messaging.TestLoop()
-
-			if now.UTC().Hour() == 14 { // sg 10pm
-				go messaging.RemindDaily()
-			}
-
-			if now.UTC().Hour() == 20 { // sg 4am
-				go messaging.SummarizeDaily()
-			}
+			// hourly tick, runs jobs due by the publish time that have not run yet
+			scheduler.SchedulerClient.Tick(msg.PublishTime)
 
 			msg.Ack()
 		})
@@ -0,0 +1,187 @@
+package ratelimit
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Tiers of users, limited separately
+const (
+	Shared string = "shared"  // messages use the shared provider key
+	OwnKey string = "own-key" // messages use the user's own Gemini key
+)
+
+// LimiterClient is nil when messages are not limited, e.g. before Init
+var LimiterClient *Limiter
+
+// Limits of a tier. Messages refill a token bucket of Burst tokens at PerMinute, and
+// at most Daily messages are allowed per local day. Zero PerMinute or Daily means unlimited
+type Limits struct {
+	PerMinute float64
+	Burst     int
+	Daily     int
+}
+
+// Decision is the outcome of Allow, RetryAt is when the user can continue if not Allowed
+type Decision struct {
+	Allowed bool
+	Daily   bool // the daily cap was reached rather than the rate
+	RetryAt time.Time
+}
+
+// Limiter keeps a token bucket and daily count per user in memory, forgetting
+// users idle long enough that their state is back to that of a new user
+type Limiter struct {
+	tiers map[string]Limits
+	users map[string]*userState
+	idle  time.Duration
+	swept time.Time
+	mu    sync.Mutex
+}
+
+type userState struct {
+	tokens float64
+	last   time.Time
+	seen   time.Time
+	day    string
+	count  int
+}
+
+func NewLimiter(tiers map[string]Limits) *Limiter {
+	return &Limiter{tiers: tiers, users: make(map[string]*userState), idle: idleWindow(tiers)}
+}
+
+// idleWindow is how long until a user's bucket is full again in every tier,
+// and a day if any tier has a daily cap, so their local day has changed
+func idleWindow(tiers map[string]Limits) time.Duration {
+	var idle time.Duration
+	for _, limits := range tiers {
+		if limits.PerMinute > 0 {
+			idle = max(idle, time.Duration(float64(limits.Burst)/limits.PerMinute*float64(time.Minute)))
+		}
+		if limits.Daily > 0 {
+			idle = max(idle, 24*time.Hour)
+		}
+	}
+	return idle
+}
+
+// Init reads limits of each tier from env, RATE_LIMIT_{SHARED|OWN_KEY}_PER_MINUTE, _BURST and _DAILY
+func Init() error {
+	shared, err := limitsFromEnv("SHARED", Limits{PerMinute: 10, Burst: 5, Daily: 100})
+	if err != nil {
+		return err
+	}
+	ownKey, err := limitsFromEnv("OWN_KEY", Limits{PerMinute: 30, Burst: 10})
+	if err != nil {
+		return err
+	}
+
+	LimiterClient = NewLimiter(map[string]Limits{Shared: shared, OwnKey: ownKey})
+	log.Printf("Rate limits: shared %+v, own key %+v", shared, ownKey)
+
+	return nil
+}
+
+func limitsFromEnv(tier string, defaults Limits) (Limits, error) {
+	limits := defaults
+
+	if value := os.Getenv("RATE_LIMIT_" + tier + "_PER_MINUTE"); value != "" {
+		perMinute, err := strconv.ParseFloat(value, 64)
+		if err != nil || perMinute < 0 {
+			return limits, fmt.Errorf("invalid RATE_LIMIT_%s_PER_MINUTE %q", tier, value)
+		}
+		limits.PerMinute = perMinute
+	}
+	if value := os.Getenv("RATE_LIMIT_" + tier + "_BURST"); value != "" {
+		burst, err := strconv.Atoi(value)
+		if err != nil || burst < 1 {
+			return limits, fmt.Errorf("invalid RATE_LIMIT_%s_BURST %q", tier, value)
+		}
+		limits.Burst = burst
+	}
+	if value := os.Getenv("RATE_LIMIT_" + tier + "_DAILY"); value != "" {
+		daily, err := strconv.Atoi(value)
+		if err != nil || daily < 0 {
+			return limits, fmt.Errorf("invalid RATE_LIMIT_%s_DAILY %q", tier, value)
+		}
+		limits.Daily = daily
+	}
+
+	return limits, nil
+}
+
+// Allow consumes one message of userId in tier. now should be in the user's location,
+// the daily count resets at their local midnight
+func (l *Limiter) Allow(userId string, tier string, now time.Time) Decision {
+	limits, ok := l.tiers[tier]
+	if !ok {
+		return Decision{Allowed: true}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	state, ok := l.users[userId]
+	if !ok {
+		state = &userState{tokens: float64(limits.Burst), last: now}
+		l.users[userId] = state
+	}
+	state.seen = now
+
+	day := now.Format("2006-01-02")
+	if day != state.day {
+		state.day = day
+		state.count = 0
+	}
+
+	if limits.Daily > 0 && state.count >= limits.Daily {
+		midnight := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+		return Decision{Daily: true, RetryAt: midnight}
+	}
+
+	if limits.PerMinute > 0 {
+		perSecond := limits.PerMinute / 60
+		if elapsed := now.Sub(state.last).Seconds(); elapsed > 0 {
+			state.tokens = min(float64(limits.Burst), state.tokens+elapsed*perSecond)
+		}
+		state.last = now
+
+		if state.tokens < 1 {
+			wait := time.Duration((1 - state.tokens) / perSecond * float64(time.Second))
+			return Decision{RetryAt: now.Add(wait)}
+		}
+		state.tokens--
+	}
+
+	state.count++
+	return Decision{Allowed: true}
+}
+
+// sweep forgets users idle longer than the idle window, at most once per window
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < l.idle {
+		return
+	}
+	l.swept = now
+
+	for userId, state := range l.users {
+		if now.Sub(state.seen) > l.idle {
+			delete(l.users, userId)
+		}
+	}
+}
+
+// Users is how many users the limiter currently remembers
+func (l *Limiter) Users() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return len(l.users)
+}
@@ -0,0 +1,95 @@
+package ratelimit_test
+
+import (
+	"fmt"
+	"journie/pkg/ratelimit"
+	"testing"
+	"time"
+)
+
+// TestAllowRate sends a burst of messages, checking the bucket refills at the
+// tier's rate and tells when the user can continue.
+func TestAllowRate(t *testing.T) {
+	limiter := ratelimit.NewLimiter(map[string]ratelimit.Limits{
+		ratelimit.Shared: {PerMinute: 6, Burst: 2},
+	})
+	now := time.Date(2024, 5, 20, 10, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 2; i++ {
+		if decision := limiter.Allow("telegram-1", ratelimit.Shared, now); !decision.Allowed {
+			t.Fatalf(`Allow() #%d = %+v, want allowed within burst`, i+1, decision)
+		}
+	}
+
+	decision := limiter.Allow("telegram-1", ratelimit.Shared, now)
+	if decision.Allowed || decision.Daily || !decision.RetryAt.Equal(now.Add(10*time.Second)) {
+		t.Fatalf(`Allow() over burst = %+v, want retry in 10s`, decision)
+	}
+
+	// other users have their own bucket
+	if decision := limiter.Allow("telegram-2", ratelimit.Shared, now); !decision.Allowed {
+		t.Fatalf(`Allow() of other user = %+v, want allowed`, decision)
+	}
+
+	if decision := limiter.Allow("telegram-1", ratelimit.Shared, now.Add(10*time.Second)); !decision.Allowed {
+		t.Fatalf(`Allow() after refill = %+v, want allowed`, decision)
+	}
+}
+
+// TestAllowDaily sends messages up to the daily cap, checking the user can
+// continue from their local midnight and other tiers are not capped.
+func TestAllowDaily(t *testing.T) {
+	limiter := ratelimit.NewLimiter(map[string]ratelimit.Limits{
+		ratelimit.Shared: {Daily: 3},
+		ratelimit.OwnKey: {},
+	})
+	loc, _ := time.LoadLocation("Asia/Singapore")
+	now := time.Date(2024, 5, 20, 22, 0, 0, 0, loc)
+
+	for i := 0; i < 3; i++ {
+		limiter.Allow("telegram-1", ratelimit.Shared, now)
+	}
+
+	decision := limiter.Allow("telegram-1", ratelimit.Shared, now)
+	if decision.Allowed || !decision.Daily || !decision.RetryAt.Equal(time.Date(2024, 5, 21, 0, 0, 0, 0, loc)) {
+		t.Fatalf(`Allow() over daily cap = %+v, want retry at local midnight`, decision)
+	}
+
+	if decision := limiter.Allow("telegram-1", ratelimit.Shared, now.Add(2*time.Hour)); !decision.Allowed {
+		t.Fatalf(`Allow() next day = %+v, want allowed`, decision)
+	}
+
+	for i := 0; i < 10; i++ {
+		if decision := limiter.Allow("telegram-2", ratelimit.OwnKey, now); !decision.Allowed {
+			t.Fatalf(`Allow() unlimited tier = %+v, want allowed`, decision)
+		}
+	}
+}
+
+// TestAllowForgetsIdleUsers sends messages from many users, checking users idle
+// until their bucket refills are forgotten while active ones are kept limited.
+func TestAllowForgetsIdleUsers(t *testing.T) {
+	limiter := ratelimit.NewLimiter(map[string]ratelimit.Limits{
+		ratelimit.Shared: {PerMinute: 6, Burst: 2},
+	})
+	now := time.Date(2024, 5, 20, 10, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 100; i++ {
+		limiter.Allow(fmt.Sprintf("telegram-%d", i), ratelimit.Shared, now)
+	}
+	if users := limiter.Users(); users != 100 {
+		t.Fatalf(`Users() = %d, want 100`, users)
+	}
+
+	// the refill window is 20s, telegram-0 keeps sending and stays limited
+	for i := 0; i < 2; i++ {
+		limiter.Allow("telegram-0", ratelimit.Shared, now.Add(25*time.Second))
+	}
+	if decision := limiter.Allow("telegram-0", ratelimit.Shared, now.Add(26*time.Second)); decision.Allowed {
+		t.Fatalf(`Allow() of active user = %+v, want limited`, decision)
+	}
+
+	if users := limiter.Users(); users != 1 {
+		t.Fatalf(`Users() after idle window = %d, want 1`, users)
+	}
+}
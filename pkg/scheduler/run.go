@@ -0,0 +1,48 @@
+package scheduler
+
+import (
+	"context"
+	"journie/pkg/storage"
+	"log"
+	"sync"
+	"time"
+)
+
+// Run is one execution of a job for its scheduled time. Jobs handling users one by one skip
+// users already Done and call MarkDone for each user handled, so a partial run can resume
+type Run struct {
+	Job string
+	At  time.Time // scheduled time, the logical date of the run
+
+	id    string
+	store storage.Store // nil when progress is not persisted
+	users map[string]bool
+	mu    sync.Mutex
+}
+
+// NewRun creates a run of job scheduled at, with progress kept in memory only, e.g. to run a job by hand
+func NewRun(job string, at time.Time) *Run {
+	return &Run{Job: job, At: at, id: storage.JobRunId(job, at), users: make(map[string]bool)}
+}
+
+// Done reports whether userId was handled by an earlier attempt of the run
+func (r *Run) Done(userId string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.users[userId]
+}
+
+// MarkDone records userId as handled, it is skipped if the run is resumed
+func (r *Run) MarkDone(userId string) {
+	r.mu.Lock()
+	r.users[userId] = true
+	r.mu.Unlock()
+
+	if r.store == nil {
+		return
+	}
+	if err := r.store.AddJobRunUser(context.Background(), r.id, userId, time.Now()); err != nil {
+		log.Printf("Error recording user %s as handled by run %s: %v", userId, r.id, err)
+	}
+}
@@ -0,0 +1,293 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"journie/pkg/storage"
+	"log"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Trigger sources calling Scheduler.Tick, selected with SCHEDULER_TRIGGERS
+const (
+	Cron   string = "cron"   // in-process, ticks every minute
+	PubSub string = "pubsub" // hourly messages published to remind-topic
+)
+
+// grace is how late a run may start and still count as on time, e.g. a delayed Pub/Sub message.
+// Older missed runs only run within the CatchUp of their job
+const grace = 15 * time.Minute
+
+// lease is how long a run record updated by another attempt is left alone,
+// it is either still in progress, e.g. on another instance, or failed recently
+const lease = 10 * time.Minute
+
+var SchedulerClient *Scheduler
+
+// Job runs on a cron schedule. Run receives the run for a scheduled time, which lies in the past when
+// catching up or resuming, and returns an error if the run should be retried
+type Job struct {
+	Name    string
+	Spec    string        // cron expression, see Parse
+	CatchUp time.Duration // missed or failed runs up to this old are run after downtime, older ones are abandoned
+	Run     func(run *Run) error
+
+	schedule *Schedule
+}
+
+// Scheduler runs jobs when ticked by one or more trigger sources. The last run and pending runs of each
+// job are kept in storage with a record per run, so ticks from several sources or a redelivered
+// Pub/Sub message run a job once per scheduled time, and missed or partial runs are caught up
+type Scheduler struct {
+	store   storage.Store
+	jitter  time.Duration // random delay before running, spreads load of jobs due at the same time
+	jobs    []*Job
+	running map[string]bool // ids of runs in progress in this process
+	mu      sync.Mutex
+}
+
+func New(store storage.Store, jitter time.Duration) *Scheduler {
+	return &Scheduler{store: store, jitter: jitter, running: make(map[string]bool)}
+}
+
+// Init creates the scheduler on storage.StoreClient, jitter is read from SCHEDULER_JITTER, defaulting to 30s
+func Init() error {
+	jitter := 30 * time.Second
+	if value := os.Getenv("SCHEDULER_JITTER"); value != "" {
+		var err error
+		if jitter, err = time.ParseDuration(value); err != nil || jitter < 0 {
+			return fmt.Errorf("invalid SCHEDULER_JITTER %q", value)
+		}
+	}
+
+	SchedulerClient = New(storage.StoreClient, jitter)
+	return nil
+}
+
+// Triggers returns the trigger sources enabled by comma separated SCHEDULER_TRIGGERS, defaulting to pubsub
+// as deployments did before cron, e.g. on Cloud Run scaled to zero where the in-process cron never fires
+func Triggers() (map[string]bool, error) {
+	value := os.Getenv("SCHEDULER_TRIGGERS")
+	if strings.TrimSpace(value) == "" {
+		log.Printf("Warning: SCHEDULER_TRIGGERS is not set, defaulting to %s. Set it to %s to run jobs in process", PubSub, Cron)
+		value = PubSub
+	}
+
+	triggers := make(map[string]bool)
+	for _, trigger := range strings.Split(value, ",") {
+		switch trigger = strings.ToLower(strings.TrimSpace(trigger)); trigger {
+		case Cron, PubSub:
+			triggers[trigger] = true
+		case "":
+		default:
+			return nil, fmt.Errorf("unknown scheduler trigger %q, use cron or pubsub", trigger)
+		}
+	}
+
+	enabled := make([]string, 0, len(triggers))
+	for trigger := range triggers {
+		enabled = append(enabled, trigger)
+	}
+	sort.Strings(enabled)
+	log.Printf("Scheduler triggers: %s", strings.Join(enabled, ", "))
+
+	return triggers, nil
+}
+
+// Add registers a job, returning an error if its cron expression is invalid
+func (s *Scheduler) Add(job Job) error {
+	schedule, err := Parse(job.Spec)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", job.Name, err)
+	}
+	job.schedule = schedule
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.jobs = append(s.jobs, &job)
+	return nil
+}
+
+// Tick runs jobs due at or before now that have not completed yet, each in its own goroutine
+func (s *Scheduler) Tick(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ctx := context.Background()
+	for _, job := range s.jobs {
+		due, err := s.due(ctx, job, now)
+		if err != nil {
+			log.Printf("Error scheduling job %s: %v", job.Name, err)
+			continue
+		}
+
+		var start []time.Time
+		for _, at := range due {
+			id := storage.JobRunId(job.Name, at)
+			if s.running[id] {
+				continue
+			}
+			s.running[id] = true
+			start = append(start, at)
+		}
+		if len(start) == 0 {
+			continue
+		}
+
+		go s.run(job, start)
+	}
+}
+
+// due returns the scheduled times of job worth running, those between its last run and now
+// and pending ones from earlier ticks, recording all of them as pending
+func (s *Scheduler) due(ctx context.Context, job *Job, now time.Time) ([]time.Time, error) {
+	state, err := s.store.GetJob(ctx, job.Name)
+	if err == storage.ErrNotFound {
+		// first run ever, nothing to catch up
+		state = &storage.Job{Name: job.Name, LastRun: now.Add(-time.Minute)}
+	} else if err != nil {
+		return nil, err
+	}
+
+	var (
+		due     []time.Time
+		lastRun = state.LastRun
+	)
+	for _, at := range state.Pending {
+		if s.tooLate(job, at, now) {
+			log.Printf("Abandoning run %s, it did not complete in time", storage.JobRunId(job.Name, at))
+			continue
+		}
+		due = append(due, at)
+	}
+	for next := job.schedule.Next(lastRun); !next.IsZero() && !next.After(now); next = job.schedule.Next(next) {
+		lastRun = next
+		if s.tooLate(job, next, now) {
+			log.Printf("Skipping run %s, %s late", storage.JobRunId(job.Name, next), now.Sub(next).Round(time.Minute))
+			continue
+		}
+		due = append(due, next)
+	}
+
+	if lastRun.Equal(state.LastRun) && len(due) == len(state.Pending) {
+		return due, nil
+	}
+
+	state.LastRun = lastRun
+	state.Pending = due
+	if err := s.store.SaveJob(ctx, state); err != nil {
+		return nil, err
+	}
+
+	return due, nil
+}
+
+func (s *Scheduler) tooLate(job *Job, at time.Time, now time.Time) bool {
+	late := now.Sub(at)
+	return late > grace && late > job.CatchUp
+}
+
+func (s *Scheduler) run(job *Job, due []time.Time) {
+	if s.jitter > 0 {
+		time.Sleep(time.Duration(rand.Int63n(int64(s.jitter))))
+	}
+
+	ctx := context.Background()
+	for _, at := range due {
+		if s.execute(ctx, job, at) {
+			s.complete(ctx, job, at)
+		}
+
+		s.mu.Lock()
+		delete(s.running, storage.JobRunId(job.Name, at))
+		s.mu.Unlock()
+	}
+}
+
+// execute runs job for the scheduled time at unless a record shows it completed or another
+// attempt is in progress, returning whether the run is completed
+func (s *Scheduler) execute(ctx context.Context, job *Job, at time.Time) bool {
+	id := storage.JobRunId(job.Name, at)
+
+	// take the lease atomically, instances ticking the same run at once start it only once
+	record, acquired, err := s.store.AcquireJobRun(ctx, &storage.JobRun{Id: id, Job: job.Name, ScheduledAt: at}, time.Now(), lease)
+	switch {
+	case err != nil:
+		log.Printf("Error acquiring run %s: %v", id, err)
+		return false
+	case record.Status == storage.JobRunCompleted:
+		log.Printf("Run %s already completed, skipping", id)
+		return true
+	case !acquired:
+		log.Printf("Run %s %s %s ago, leaving it for now", id, record.Status, time.Since(record.UpdatedAt).Round(time.Second))
+		return false
+	}
+
+	run := &Run{Job: job.Name, At: at, id: id, store: s.store, users: make(map[string]bool)}
+	for _, userId := range record.Users {
+		run.users[userId] = true
+	}
+
+	log.Printf("Running %s, attempt %d, %d users already handled", id, record.Attempts, len(record.Users))
+	runErr := job.Run(run)
+
+	record.Status = storage.JobRunCompleted
+	record.UpdatedAt = time.Now()
+	if runErr != nil {
+		log.Printf("Run %s failed: %v", id, runErr)
+		record.Status = storage.JobRunFailed
+		record.Error = runErr.Error()
+	}
+	if err := s.store.SaveJobRun(ctx, record); err != nil {
+		log.Printf("Error saving run %s: %v", id, err)
+		return false
+	}
+
+	return runErr == nil
+}
+
+// complete removes the scheduled time at from pending runs of job
+func (s *Scheduler) complete(ctx context.Context, job *Job, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, err := s.store.GetJob(ctx, job.Name)
+	if err != nil {
+		log.Printf("Error getting job %s: %v", job.Name, err)
+		return
+	}
+
+	pending := state.Pending[:0]
+	for _, p := range state.Pending {
+		if !p.Equal(at) {
+			pending = append(pending, p)
+		}
+	}
+	state.Pending = pending
+
+	if err := s.store.SaveJob(ctx, state); err != nil {
+		log.Printf("Error saving job %s: %v", job.Name, err)
+	}
+}
+
+// Start ticks at the start of every minute in the background until ctx is done
+func (s *Scheduler) Start(ctx context.Context) {
+	go func() {
+		for {
+			s.Tick(time.Now())
+
+			next := time.Now().Truncate(time.Minute).Add(time.Minute)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Until(next)):
+			}
+		}
+	}()
+}
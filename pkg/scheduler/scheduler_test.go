@@ -0,0 +1,197 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"journie/pkg/scheduler"
+	"journie/pkg/storage"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestNext parses cron expressions and calls Schedule.Next, checking the following run.
+func TestNext(t *testing.T) {
+	from := time.Date(2024, 5, 20, 10, 30, 0, 0, time.UTC) // a monday
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"0 * * * *", time.Date(2024, 5, 20, 11, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, 5, 20, 11, 0, 0, 0, time.UTC)},
+		{"*/20 * * * *", time.Date(2024, 5, 20, 10, 40, 0, 0, time.UTC)},
+		{"15 4 * * *", time.Date(2024, 5, 21, 4, 15, 0, 0, time.UTC)},
+		{"0 9 * * 0", time.Date(2024, 5, 26, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 7", time.Date(2024, 5, 26, 9, 0, 0, 0, time.UTC)},
+		{"0 0 1 1-3,7 *", time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+
+	for _, test := range tests {
+		schedule, err := scheduler.Parse(test.spec)
+		if err != nil {
+			t.Fatalf(`Parse(%q) = %v, want nil`, test.spec, err)
+		}
+		if got := schedule.Next(from); !got.Equal(test.want) {
+			t.Errorf(`Parse(%q).Next(%s) = %s, want %s`, test.spec, from, got, test.want)
+		}
+	}
+
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := scheduler.Parse(spec); err == nil {
+			t.Errorf(`Parse(%q) = nil, want error`, spec)
+		}
+	}
+}
+
+func receive(t *testing.T, runs chan time.Time, want ...time.Time) {
+	for _, at := range want {
+		select {
+		case got := <-runs:
+			if !got.Equal(at) {
+				t.Fatalf(`job ran at %s, want %s`, got, at)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf(`job did not run at %s`, at)
+		}
+	}
+
+	select {
+	case got := <-runs:
+		t.Fatalf(`job ran at %s, want no more runs`, got)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// TestTick ticks a scheduler from two sources and again after downtime, checking
+// each run happens once and only missed runs within the catch up window are run.
+func TestTick(t *testing.T) {
+	store := storage.NewMemoryStore()
+	runs := make(chan time.Time, 10)
+
+	s := scheduler.New(store, 0)
+	if err := s.Add(scheduler.Job{Name: "remind", Spec: "0 * * * *", CatchUp: 2 * time.Hour, Run: func(run *scheduler.Run) error { runs <- run.At; return nil }}); err != nil {
+		t.Fatalf(`Add() = %v, want nil`, err)
+	}
+
+	ten := time.Date(2024, 5, 20, 10, 0, 0, 0, time.UTC)
+
+	// cron tick on time, then a pubsub tick for the same hour
+	s.Tick(ten.Add(2 * time.Second))
+	s.Tick(ten.Add(40 * time.Second))
+	receive(t, runs, ten)
+
+	// nothing due within the hour
+	s.Tick(ten.Add(30 * time.Minute))
+	receive(t, runs)
+
+	// down until 15:05, runs of 14:00 and 15:00 are caught up, 11:00 to 13:00 are too old
+	s.Tick(ten.Add(5*time.Hour + 5*time.Minute))
+	receive(t, runs, ten.Add(4*time.Hour), ten.Add(5*time.Hour))
+
+	job, err := store.GetJob(context.Background(), "remind")
+	if err != nil || !job.LastRun.Equal(ten.Add(5*time.Hour)) {
+		t.Fatalf(`GetJob("remind") = %v, %v, want last run at 15:00`, job, err)
+	}
+}
+
+// waitStatus polls the run record of job at until it has status
+func waitStatus(t *testing.T, store storage.Store, job string, at time.Time, status string) *storage.JobRun {
+	for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(10 * time.Millisecond) {
+		if run, err := store.GetJobRun(context.Background(), storage.JobRunId(job, at)); err == nil && run.Status == status {
+			return run
+		}
+	}
+
+	t.Fatalf(`run %s not %s`, storage.JobRunId(job, at), status)
+	return nil
+}
+
+// TestTickResume fails a run halfway and ticks again once the failure is old enough,
+// checking the run resumes with users handled before and is not repeated once completed.
+func TestTickResume(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	ten := time.Date(2024, 5, 20, 10, 0, 0, 0, time.UTC)
+
+	var attempts [][]string
+	s := scheduler.New(store, 0)
+	s.Add(scheduler.Job{Name: "summarize", Spec: "@hourly", CatchUp: 24 * time.Hour, Run: func(run *scheduler.Run) error {
+		var handled []string
+		for _, userId := range []string{"alice", "bob"} {
+			if run.Done(userId) {
+				continue
+			}
+			if userId == "bob" && len(attempts) == 0 {
+				attempts = append(attempts, handled)
+				return errors.New("model unavailable")
+			}
+			handled = append(handled, userId)
+			run.MarkDone(userId)
+		}
+		attempts = append(attempts, handled)
+		return nil
+	}})
+
+	s.Tick(ten)
+	failed := waitStatus(t, store, "summarize", ten, storage.JobRunFailed)
+	if failed.Attempts != 1 || failed.Error != "model unavailable" || !reflect.DeepEqual(failed.Users, []string{"alice"}) {
+		t.Fatalf(`run after failure = %+v, want 1 attempt failed after alice`, failed)
+	}
+
+	// a redelivered tick right after the failure leaves the run alone
+	s.Tick(ten.Add(time.Minute))
+	time.Sleep(50 * time.Millisecond)
+	if len(attempts) != 1 {
+		t.Fatalf(`attempts after redelivered tick = %v, want 1`, attempts)
+	}
+
+	// once the failure is old enough the run is resumed
+	failed.UpdatedAt = failed.UpdatedAt.Add(-time.Hour)
+	store.SaveJobRun(ctx, failed)
+	s.Tick(ten.Add(20 * time.Minute))
+
+	completed := waitStatus(t, store, "summarize", ten, storage.JobRunCompleted)
+	if completed.Attempts != 2 || len(attempts) != 2 || !reflect.DeepEqual(attempts[1], []string{"bob"}) {
+		t.Fatalf(`run after resume = %+v with attempts %v, want 2nd attempt handling bob only`, completed, attempts)
+	}
+
+	// completed runs are not pending nor run again, even if the job state is lost
+	for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(10 * time.Millisecond) {
+		if job, _ := store.GetJob(ctx, "summarize"); len(job.Pending) == 0 {
+			break
+		}
+	}
+	if job, _ := store.GetJob(ctx, "summarize"); len(job.Pending) != 0 {
+		t.Fatalf(`pending runs = %v, want none`, job.Pending)
+	}
+
+	store.SaveJob(ctx, &storage.Job{Name: "summarize", LastRun: ten.Add(-time.Minute)})
+	s.Tick(ten.Add(30 * time.Minute))
+	time.Sleep(50 * time.Millisecond)
+	if len(attempts) != 2 {
+		t.Fatalf(`attempts after completion = %v, want 2`, attempts)
+	}
+}
+
+// TestTriggers reads SCHEDULER_TRIGGERS, checking pubsub is the default and unknown triggers are refused.
+func TestTriggers(t *testing.T) {
+	tests := map[string]map[string]bool{
+		"":              {scheduler.PubSub: true},
+		"cron":          {scheduler.Cron: true},
+		" Cron,pubsub ": {scheduler.Cron: true, scheduler.PubSub: true},
+	}
+	for value, want := range tests {
+		t.Setenv("SCHEDULER_TRIGGERS", value)
+		if got, err := scheduler.Triggers(); err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf(`Triggers() of %q = %v, %v, want %v`, value, got, err, want)
+		}
+	}
+
+	t.Setenv("SCHEDULER_TRIGGERS", "cron,cloud-scheduler")
+	if _, err := scheduler.Triggers(); err == nil {
+		t.Errorf(`Triggers() of unknown trigger = nil, want error`)
+	}
+}
@@ -0,0 +1,210 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrNoMasterKey is returned when JOURNIE_MASTER_KEY is not configured
+var ErrNoMasterKey = errors.New("secrets: JOURNIE_MASTER_KEY not configured")
+
+// Key is a 256 bit AES key identified by a short fingerprint,
+// the fingerprint is stored with everything sealed by the key
+type Key struct {
+	Id    string
+	bytes []byte
+}
+
+// NewKey wraps 32 raw bytes as a Key
+func NewKey(raw []byte) (*Key, error) {
+	if len(raw) != 32 {
+		return nil, fmt.Errorf("secrets: key must be 32 bytes, got %d", len(raw))
+	}
+
+	sum := sha256.Sum256(raw)
+	return &Key{Id: hex.EncodeToString(sum[:4]), bytes: raw}, nil
+}
+
+// ParseKey decodes a base64 encoded 32 byte key, e.g. from `openssl rand -base64 32`
+func ParseKey(encoded string) (*Key, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("secrets: invalid base64 key: %w", err)
+	}
+
+	return NewKey(raw)
+}
+
+// GenerateKey creates a random key
+func GenerateKey() (*Key, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+
+	return NewKey(raw)
+}
+
+// Keyring holds the current master key used for sealing,
+// and previous ones still accepted for opening until rotation completes
+type Keyring struct {
+	Current  *Key
+	previous map[string]*Key
+}
+
+func NewKeyring(current *Key, previous ...*Key) *Keyring {
+	keyring := &Keyring{Current: current, previous: make(map[string]*Key)}
+	for _, key := range previous {
+		keyring.previous[key.Id] = key
+	}
+
+	return keyring
+}
+
+// MasterKeyring loads the master key from JOURNIE_MASTER_KEY env, and retired
+// master keys from comma separated JOURNIE_MASTER_KEY_PREVIOUS env
+func MasterKeyring() (*Keyring, error) {
+	encoded := os.Getenv("JOURNIE_MASTER_KEY")
+	if encoded == "" {
+		return nil, ErrNoMasterKey
+	}
+
+	current, err := ParseKey(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	var previous []*Key
+	for _, encoded := range strings.Split(os.Getenv("JOURNIE_MASTER_KEY_PREVIOUS"), ",") {
+		if strings.TrimSpace(encoded) == "" {
+			continue
+		}
+		key, err := ParseKey(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("JOURNIE_MASTER_KEY_PREVIOUS: %w", err)
+		}
+		previous = append(previous, key)
+	}
+
+	return NewKeyring(current, previous...), nil
+}
+
+// Seal encrypts plaintext with the current key
+func (r *Keyring) Seal(plaintext []byte) (string, error) {
+	return r.Current.Seal(plaintext)
+}
+
+// Open decrypts a value sealed with the current or a previous key
+func (r *Keyring) Open(sealed string) ([]byte, error) {
+	keyId := KeyId(sealed)
+	if keyId == r.Current.Id {
+		return r.Current.Open(sealed)
+	}
+
+	key, ok := r.previous[keyId]
+	if !ok {
+		return nil, fmt.Errorf("secrets: unknown key %s", keyId)
+	}
+
+	return key.Open(sealed)
+}
+
+// Reseal re-encrypts a value with the current key, returning false if it already is
+func (r *Keyring) Reseal(sealed string) (string, bool, error) {
+	if KeyId(sealed) == r.Current.Id {
+		return sealed, false, nil
+	}
+
+	plaintext, err := r.Open(sealed)
+	if err != nil {
+		return "", false, err
+	}
+
+	resealed, err := r.Seal(plaintext)
+	if err != nil {
+		return "", false, err
+	}
+
+	return resealed, true, nil
+}
+
+// WrapKey seals a data key with the current key, for envelope encryption
+func (r *Keyring) WrapKey(dataKey *Key) (string, error) {
+	return r.Seal(dataKey.bytes)
+}
+
+// UnwrapKey opens a data key sealed by WrapKey
+func (r *Keyring) UnwrapKey(wrapped string) (*Key, error) {
+	raw, err := r.Open(wrapped)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewKey(raw)
+}
+
+// Seal encrypts plaintext with AES-GCM, returning "{keyId}:{base64 nonce+ciphertext}"
+func (k *Key) Seal(plaintext []byte) (string, error) {
+	aead, err := k.aead()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, plaintext, nil)
+	return k.Id + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts a value produced by Seal with the same key
+func (k *Key) Open(sealed string) ([]byte, error) {
+	keyId, encoded, ok := strings.Cut(sealed, ":")
+	if !ok {
+		return nil, errors.New("secrets: malformed sealed value")
+	}
+	if keyId != k.Id {
+		return nil, fmt.Errorf("secrets: value sealed with key %s, not %s", keyId, k.Id)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("secrets: malformed sealed value: %w", err)
+	}
+
+	aead, err := k.aead()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < aead.NonceSize() {
+		return nil, errors.New("secrets: malformed sealed value")
+	}
+
+	return aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+}
+
+// KeyId returns the id of the key a value was sealed with
+func KeyId(sealed string) string {
+	keyId, _, _ := strings.Cut(sealed, ":")
+	return keyId
+}
+
+func (k *Key) aead() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(k.bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,62 @@
+package secrets_test
+
+import (
+	"journie/pkg/secrets"
+	"strings"
+	"testing"
+)
+
+// TestSealOpen seals a value and opens it again, checking the plaintext
+// survives and is not visible in the sealed value.
+func TestSealOpen(t *testing.T) {
+	key, err := secrets.GenerateKey()
+	if err != nil {
+		t.Fatalf(`GenerateKey() = %v, want nil`, err)
+	}
+
+	sealed, err := key.Seal([]byte("AIza-not-a-real-key"))
+	if err != nil || strings.Contains(sealed, "AIza") || secrets.KeyId(sealed) != key.Id {
+		t.Fatalf(`Seal() = %q, %v, want opaque value prefixed with %q`, sealed, err, key.Id)
+	}
+
+	opened, err := key.Open(sealed)
+	if string(opened) != "AIza-not-a-real-key" || err != nil {
+		t.Fatalf(`Open(Seal()) = %q, %v, want "AIza-not-a-real-key", nil`, opened, err)
+	}
+}
+
+// TestOpenWrongKey opens a value with a different key, checking for an error.
+func TestOpenWrongKey(t *testing.T) {
+	key, _ := secrets.GenerateKey()
+	other, _ := secrets.GenerateKey()
+
+	sealed, _ := key.Seal([]byte("secret"))
+	if opened, err := other.Open(sealed); opened != nil || err == nil {
+		t.Fatalf(`Open() with other key = %q, %v, want nil, error`, opened, err)
+	}
+}
+
+// TestKeyringRotation reseals a value from a previous master key, checking it
+// opens with the current key alone afterwards.
+func TestKeyringRotation(t *testing.T) {
+	old, _ := secrets.GenerateKey()
+	current, _ := secrets.GenerateKey()
+	dataKey, _ := secrets.GenerateKey()
+
+	wrapped, _ := secrets.NewKeyring(old).WrapKey(dataKey)
+
+	keyring := secrets.NewKeyring(current, old)
+	resealed, changed, err := keyring.Reseal(wrapped)
+	if !changed || err != nil || secrets.KeyId(resealed) != current.Id {
+		t.Fatalf(`Reseal() = %q, %v, %v, want value sealed with %s, true, nil`, resealed, changed, err, current.Id)
+	}
+
+	unwrapped, err := secrets.NewKeyring(current).UnwrapKey(resealed)
+	if err != nil || unwrapped.Id != dataKey.Id {
+		t.Fatalf(`UnwrapKey(Reseal()) = %v, %v, want data key %s`, unwrapped, err, dataKey.Id)
+	}
+
+	if _, changed, _ := keyring.Reseal(resealed); changed {
+		t.Fatalf(`Reseal() of current value = changed, want unchanged`)
+	}
+}
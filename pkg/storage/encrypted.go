@@ -0,0 +1,443 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"journie/pkg/secrets"
+	"log"
+	"sync"
+)
+
+// EncryptedStore wraps a Store with envelope encryption of entries, digests and sessions.
+// Each user gets a random data key, wrapped by the master keyring and kept on the user document.
+// Values written before encryption was enabled are read as is
+type EncryptedStore struct {
+	Store
+	keyring   *secrets.Keyring
+	dataKeys  map[string]*secrets.Key // Map of user IDs to unwrapped data keys
+	userLocks map[string]*sync.Mutex  // Map of user IDs to locks held while reading or creating their data key
+	mu        sync.Mutex              // guards the maps only, never held across storage calls
+}
+
+type entryPlaintext struct {
+	Summary string   `json:"summary"`
+	Mood    []string `json:"mood"`
+	Photos  []Photo  `json:"photos,omitempty"`
+
+	Embedding []float32 `json:"embedding,omitempty"`
+}
+
+func NewEncryptedStore(store Store, keyring *secrets.Keyring) *EncryptedStore {
+	return &EncryptedStore{
+		Store:     store,
+		keyring:   keyring,
+		dataKeys:  make(map[string]*secrets.Key),
+		userLocks: make(map[string]*sync.Mutex),
+	}
+}
+
+// dataKey returns the data key of user, generating and storing one if create is set.
+// Storage is read under a lock of the user only, so a slow round trip does not hold up other users
+func (s *EncryptedStore) dataKey(ctx context.Context, userId string, create bool) (*secrets.Key, error) {
+	if key := s.cachedKey(userId); key != nil {
+		return key, nil
+	}
+
+	lock := s.userLock(userId)
+	lock.Lock()
+	defer lock.Unlock()
+
+	// cached while waiting for the lock
+	if key := s.cachedKey(userId); key != nil {
+		return key, nil
+	}
+
+	user, err := s.Store.GetUser(ctx, userId)
+	if err != nil && err != ErrNotFound {
+		return nil, err
+	}
+
+	if user != nil && user.DataKey != "" {
+		return s.unwrapKey(userId, user.DataKey)
+	}
+
+	if !create {
+		return nil, nil
+	}
+
+	key, err := secrets.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	wrapped, err := s.keyring.WrapKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// another instance may have created a key first, entries are sealed with the one stored
+	stored, err := s.Store.CreateUserDataKey(ctx, userId, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	if stored != wrapped {
+		log.Printf("Data key of user %s was created by another instance, using it", userId)
+		return s.unwrapKey(userId, stored)
+	}
+
+	s.cacheKey(userId, key)
+	log.Printf("Data key created for user %s", userId)
+
+	return key, nil
+}
+
+func (s *EncryptedStore) unwrapKey(userId string, wrapped string) (*secrets.Key, error) {
+	key, err := s.keyring.UnwrapKey(wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("unwrapping data key of user %s: %w", userId, err)
+	}
+	s.cacheKey(userId, key)
+
+	return key, nil
+}
+
+func (s *EncryptedStore) cachedKey(userId string) *secrets.Key {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.dataKeys[userId]
+}
+
+func (s *EncryptedStore) cacheKey(userId string, key *secrets.Key) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.dataKeys[userId] = key
+}
+
+// userLock returns the lock taken while the data key of user is read or created
+func (s *EncryptedStore) userLock(userId string) *sync.Mutex {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lock, ok := s.userLocks[userId]
+	if !ok {
+		lock = &sync.Mutex{}
+		s.userLocks[userId] = lock
+	}
+
+	return lock
+}
+
+func (s *EncryptedStore) SaveEntry(ctx context.Context, userId string, entry *Entry) error {
+	key, err := s.dataKey(ctx, userId, true)
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := json.Marshal(entryPlaintext{Summary: entry.Summary, Mood: entry.Mood, Photos: entry.Photos, Embedding: entry.Embedding})
+	if err != nil {
+		return err
+	}
+
+	ciphertext, err := key.Seal(plaintext)
+	if err != nil {
+		return err
+	}
+
+	encrypted := *entry
+	encrypted.Summary = ""
+	encrypted.Mood = nil
+	encrypted.Photos = nil
+	encrypted.Embedding = nil
+	encrypted.Ciphertext = ciphertext
+
+	return s.Store.SaveEntry(ctx, userId, &encrypted)
+}
+
+func (s *EncryptedStore) ListEntries(ctx context.Context, userId string, limit int) ([]Entry, error) {
+	entries, err := s.Store.ListEntries(ctx, userId, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.decryptEntries(ctx, userId, entries)
+}
+
+func (s *EncryptedStore) QueryEntries(ctx context.Context, userId string, query EntryQuery) ([]Entry, error) {
+	entries, err := s.Store.QueryEntries(ctx, userId, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.decryptEntries(ctx, userId, entries)
+}
+
+func (s *EncryptedStore) decryptEntries(ctx context.Context, userId string, entries []Entry) ([]Entry, error) {
+	for i := range entries {
+		if entries[i].Ciphertext == "" {
+			continue
+		}
+
+		key, err := s.dataKey(ctx, userId, false)
+		if err != nil {
+			return nil, err
+		}
+		if key == nil {
+			return nil, fmt.Errorf("entry %s of user %s is encrypted but user has no data key", entries[i].Id, userId)
+		}
+
+		plaintext, err := key.Open(entries[i].Ciphertext)
+		if err != nil {
+			return nil, fmt.Errorf("decrypting entry %s of user %s: %w", entries[i].Id, userId, err)
+		}
+
+		var decrypted entryPlaintext
+		if err := json.Unmarshal(plaintext, &decrypted); err != nil {
+			return nil, err
+		}
+
+		entries[i].Summary = decrypted.Summary
+		entries[i].Mood = decrypted.Mood
+		entries[i].Photos = decrypted.Photos
+		entries[i].Embedding = decrypted.Embedding
+		entries[i].Ciphertext = ""
+	}
+
+	return entries, nil
+}
+
+type digestPlaintext struct {
+	Summary    string         `json:"summary"`
+	Highlights []string       `json:"highlights"`
+	Moods      map[string]int `json:"moods"`
+}
+
+func (s *EncryptedStore) SaveDigest(ctx context.Context, userId string, digest *Digest) error {
+	key, err := s.dataKey(ctx, userId, true)
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := json.Marshal(digestPlaintext{Summary: digest.Summary, Highlights: digest.Highlights, Moods: digest.Moods})
+	if err != nil {
+		return err
+	}
+
+	ciphertext, err := key.Seal(plaintext)
+	if err != nil {
+		return err
+	}
+
+	encrypted := *digest
+	encrypted.Summary = ""
+	encrypted.Highlights = nil
+	encrypted.Moods = nil
+	encrypted.Ciphertext = ciphertext
+
+	return s.Store.SaveDigest(ctx, userId, &encrypted)
+}
+
+func (s *EncryptedStore) ListDigests(ctx context.Context, userId string, period string) ([]Digest, error) {
+	digests, err := s.Store.ListDigests(ctx, userId, period)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range digests {
+		if digests[i].Ciphertext == "" {
+			continue
+		}
+
+		key, err := s.dataKey(ctx, userId, false)
+		if err != nil {
+			return nil, err
+		}
+		if key == nil {
+			return nil, fmt.Errorf("digest %s of user %s is encrypted but user has no data key", digests[i].Id, userId)
+		}
+
+		plaintext, err := key.Open(digests[i].Ciphertext)
+		if err != nil {
+			return nil, fmt.Errorf("decrypting digest %s of user %s: %w", digests[i].Id, userId, err)
+		}
+
+		var decrypted digestPlaintext
+		if err := json.Unmarshal(plaintext, &decrypted); err != nil {
+			return nil, err
+		}
+
+		digests[i].Summary = decrypted.Summary
+		digests[i].Highlights = decrypted.Highlights
+		digests[i].Moods = decrypted.Moods
+		digests[i].Ciphertext = ""
+	}
+
+	return digests, nil
+}
+
+func (s *EncryptedStore) GetSession(ctx context.Context, userId string) (*Session, error) {
+	session, err := s.Store.GetSession(ctx, userId)
+	if err != nil || session.Ciphertext == "" {
+		return session, err
+	}
+
+	if session.History, err = s.openHistory(ctx, userId, session.Ciphertext); err != nil {
+		return nil, fmt.Errorf("session of user %s: %w", userId, err)
+	}
+	session.Ciphertext = ""
+
+	return session, nil
+}
+
+func (s *EncryptedStore) SaveSession(ctx context.Context, userId string, session *Session) error {
+	ciphertext, err := s.sealHistory(ctx, userId, session.History)
+	if err != nil {
+		return err
+	}
+
+	encrypted := *session
+	encrypted.History = nil
+	encrypted.Ciphertext = ciphertext
+
+	return s.Store.SaveSession(ctx, userId, &encrypted)
+}
+
+func (s *EncryptedStore) GetFailedSummary(ctx context.Context, id string) (*FailedSummary, error) {
+	summary, err := s.Store.GetFailedSummary(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return summary, s.openFailedSummary(ctx, summary)
+}
+
+func (s *EncryptedStore) ListFailedSummaries(ctx context.Context, dead bool) ([]FailedSummary, error) {
+	summaries, err := s.Store.ListFailedSummaries(ctx, dead)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range summaries {
+		if err := s.openFailedSummary(ctx, &summaries[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return summaries, nil
+}
+
+func (s *EncryptedStore) SaveFailedSummary(ctx context.Context, summary *FailedSummary) error {
+	ciphertext, err := s.sealHistory(ctx, summary.UserId, summary.History)
+	if err != nil {
+		return err
+	}
+
+	encrypted := *summary
+	encrypted.History = nil
+	encrypted.Ciphertext = ciphertext
+
+	return s.Store.SaveFailedSummary(ctx, &encrypted)
+}
+
+func (s *EncryptedStore) openFailedSummary(ctx context.Context, summary *FailedSummary) error {
+	if summary.Ciphertext == "" {
+		return nil
+	}
+
+	history, err := s.openHistory(ctx, summary.UserId, summary.Ciphertext)
+	if err != nil {
+		return fmt.Errorf("failed summary %s: %w", summary.Id, err)
+	}
+	summary.History = history
+	summary.Ciphertext = ""
+
+	return nil
+}
+
+// sealHistory encrypts chat history with the data key of user
+func (s *EncryptedStore) sealHistory(ctx context.Context, userId string, history []Message) (string, error) {
+	key, err := s.dataKey(ctx, userId, true)
+	if err != nil {
+		return "", err
+	}
+
+	plaintext, err := json.Marshal(history)
+	if err != nil {
+		return "", err
+	}
+
+	return key.Seal(plaintext)
+}
+
+// openHistory decrypts chat history sealed by sealHistory
+func (s *EncryptedStore) openHistory(ctx context.Context, userId string, ciphertext string) ([]Message, error) {
+	key, err := s.dataKey(ctx, userId, false)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, fmt.Errorf("encrypted but user %s has no data key", userId)
+	}
+
+	plaintext, err := key.Open(ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting: %w", err)
+	}
+
+	var history []Message
+	if err := json.Unmarshal(plaintext, &history); err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// RotateMasterKey re-wraps data keys and reseals Gemini keys of all users still sealed
+// with a previous master key, so the previous key can be retired. Returns number of users updated
+func RotateMasterKey(ctx context.Context, store Store, keyring *secrets.Keyring) (int, error) {
+	users, err := store.ListUsers(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	rotated := 0
+	for _, user := range users {
+		changed := false
+
+		if user.DataKey != "" {
+			wrapped, ok, err := keyring.Reseal(user.DataKey)
+			if err != nil {
+				return rotated, fmt.Errorf("re-wrapping data key of user %s: %w", user.Id, err)
+			}
+			if ok {
+				if err := store.UpsertUserDataKey(ctx, user.Id, wrapped); err != nil {
+					return rotated, err
+				}
+				changed = true
+			}
+		}
+
+		if user.GeminiKey != "" {
+			sealed, ok, err := keyring.Reseal(user.GeminiKey)
+			if err != nil {
+				return rotated, fmt.Errorf("resealing gemini key of user %s: %w", user.Id, err)
+			}
+			if ok {
+				if err := store.UpsertUserGeminiKey(ctx, user.Id, sealed); err != nil {
+					return rotated, err
+				}
+				changed = true
+			}
+		}
+
+		if changed {
+			rotated++
+		}
+	}
+
+	log.Printf("Master key rotation re-wrapped keys of %d users", rotated)
+
+	return rotated, nil
+}
@@ -0,0 +1,607 @@
+package storage
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// FirestoreStore stores users under users/{platformUserId}, entries under users/{platformUserId}/entries,
+// digests under users/{platformUserId}/digests,
+// the in-progress chat session under sessions/{platformUserId}, scheduled job state under jobs/{name}
+// job run records under jobRuns/{job@scheduledAt}, and failed summaries under summaryRetries/{userId@day}
+// while they are retried, then under deadLetters/{userId@day}.
+// Timezones users set are registered under timezones/{name with / replaced by :}
+type FirestoreStore struct {
+	client *firestore.Client
+}
+
+func NewFirestoreStore(client *firestore.Client) *FirestoreStore {
+	return &FirestoreStore{client: client}
+}
+
+func (s *FirestoreStore) users() *firestore.CollectionRef {
+	return s.client.Collection("users")
+}
+
+func (s *FirestoreStore) entries(userId string) *firestore.CollectionRef {
+	return s.users().Doc(userId).Collection("entries")
+}
+
+func (s *FirestoreStore) digests(userId string) *firestore.CollectionRef {
+	return s.users().Doc(userId).Collection("digests")
+}
+
+func (s *FirestoreStore) sessions() *firestore.CollectionRef {
+	return s.client.Collection("sessions")
+}
+
+func (s *FirestoreStore) timezones() *firestore.CollectionRef {
+	return s.client.Collection("timezones")
+}
+
+func (s *FirestoreStore) migrations() *firestore.CollectionRef {
+	return s.client.Collection("migrations")
+}
+
+func (s *FirestoreStore) jobs() *firestore.CollectionRef {
+	return s.client.Collection("jobs")
+}
+
+func (s *FirestoreStore) jobRuns() *firestore.CollectionRef {
+	return s.client.Collection("jobRuns")
+}
+
+// failedSummaries returns the collection of dead letters if dead is set, else of failed summaries queued for retry
+func (s *FirestoreStore) failedSummaries(dead bool) *firestore.CollectionRef {
+	if dead {
+		return s.client.Collection("deadLetters")
+	}
+	return s.client.Collection("summaryRetries")
+}
+
+func (s *FirestoreStore) GetUser(ctx context.Context, userId string) (*User, error) {
+	doc, err := s.users().Doc(userId).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var user User
+	if err := doc.DataTo(&user); err != nil {
+		return nil, err
+	}
+	user.Id = doc.Ref.ID
+
+	return &user, nil
+}
+
+func (s *FirestoreStore) UpsertUserLastCreatedSession(ctx context.Context, userId string, at time.Time) error {
+	return s.setUser(ctx, userId, map[string]interface{}{
+		"lastCreatedSession": at,
+	})
+}
+
+func (s *FirestoreStore) UpsertUserTimezone(ctx context.Context, userId string, timezone string) error {
+	// registered first, so a user is never in a timezone ListTimezones misses
+	if _, err := s.timezones().Doc(timezoneDocId(timezone)).Set(ctx, map[string]interface{}{
+		"name": timezone,
+	}); err != nil {
+		return err
+	}
+
+	return s.setUser(ctx, userId, map[string]interface{}{
+		"timezone": timezone,
+	})
+}
+
+func (s *FirestoreStore) UpsertUserSettings(ctx context.Context, userId string, settings *Settings) error {
+	return s.setUser(ctx, userId, map[string]interface{}{
+		"settings": settings,
+	})
+}
+
+func (s *FirestoreStore) UpsertUserLanguageCode(ctx context.Context, userId string, languageCode string) error {
+	return s.setUser(ctx, userId, map[string]interface{}{
+		"languageCode": languageCode,
+	})
+}
+
+func (s *FirestoreStore) UpsertUserGeminiKey(ctx context.Context, userId string, sealedKey string) error {
+	return s.setUser(ctx, userId, map[string]interface{}{
+		"geminiKey": sealedKey,
+	})
+}
+
+func (s *FirestoreStore) UpsertUserDataKey(ctx context.Context, userId string, wrappedKey string) error {
+	return s.setUser(ctx, userId, map[string]interface{}{
+		"dataKey": wrappedKey,
+	})
+}
+
+func (s *FirestoreStore) CreateUserDataKey(ctx context.Context, userId string, wrappedKey string) (string, error) {
+	ref := s.users().Doc(userId)
+
+	var stored string
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		fields := map[string]interface{}{"dataKey": wrappedKey}
+
+		doc, err := tx.Get(ref)
+		switch {
+		case status.Code(err) == codes.NotFound:
+			fields["timezone"] = ""
+		case err != nil:
+			return err
+		default:
+			if existing, _ := doc.Data()["dataKey"].(string); existing != "" {
+				stored = existing
+				return nil
+			}
+		}
+
+		stored = wrappedKey
+		return tx.Set(ref, fields, firestore.MergeAll)
+	})
+
+	return stored, err
+}
+
+// setUser merges fields into the user document. A new document gets an empty timezone,
+// as queries cannot match users without the field
+func (s *FirestoreStore) setUser(ctx context.Context, userId string, fields map[string]interface{}) error {
+	ref := s.users().Doc(userId)
+	return s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		_, err := tx.Get(ref)
+		if status.Code(err) == codes.NotFound {
+			if _, ok := fields["timezone"]; !ok {
+				fields["timezone"] = ""
+			}
+		} else if err != nil {
+			return err
+		}
+
+		return tx.Set(ref, fields, firestore.MergeAll)
+	})
+}
+
+func (s *FirestoreStore) ListUsers(ctx context.Context) ([]User, error) {
+	return s.listUsers(ctx, s.users().Query)
+}
+
+// QueryUsers runs a query per 30 timezones, the most an in filter takes
+func (s *FirestoreStore) QueryUsers(ctx context.Context, query UserQuery) ([]User, error) {
+	var users []User
+	for start := 0; start < len(query.Timezones); start += 30 {
+		end := start + 30
+		if end > len(query.Timezones) {
+			end = len(query.Timezones)
+		}
+
+		q := s.users().Where("timezone", "in", query.Timezones[start:end])
+		if query.ReminderHour != nil {
+			q = q.Where("settings.reminderHour", "==", *query.ReminderHour)
+		}
+
+		chunk, err := s.listUsers(ctx, q)
+		if err != nil {
+			return users, err
+		}
+		users = append(users, chunk...)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].Id < users[j].Id
+	})
+
+	return users, nil
+}
+
+func (s *FirestoreStore) ListTimezones(ctx context.Context) ([]string, error) {
+	iter := s.timezones().Documents(ctx)
+	defer iter.Stop()
+
+	var timezones []string
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return timezones, err
+		}
+
+		if name, ok := doc.Data()["name"].(string); ok {
+			timezones = append(timezones, name)
+		}
+	}
+	sort.Strings(timezones)
+
+	return timezones, nil
+}
+
+// timezoneDocId escapes the slashes of an IANA name, which separate document paths
+func timezoneDocId(timezone string) string {
+	return strings.ReplaceAll(timezone, "/", ":")
+}
+
+// Migrate backfills user documents written before timezones were queried: users without a timezone
+// get an empty one and set timezones are registered. It runs once, recorded under migrations/userTimezones
+func (s *FirestoreStore) Migrate(ctx context.Context) error {
+	ref := s.migrations().Doc("userTimezones")
+	if _, err := ref.Get(ctx); err == nil {
+		return nil
+	} else if status.Code(err) != codes.NotFound {
+		return err
+	}
+
+	iter := s.users().Documents(ctx)
+	defer iter.Stop()
+
+	migrated := 0
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		timezone, ok := doc.Data()["timezone"].(string)
+		switch {
+		case !ok:
+			if _, err := doc.Ref.Set(ctx, map[string]interface{}{"timezone": ""}, firestore.MergeAll); err != nil {
+				return err
+			}
+			migrated++
+		case timezone != "":
+			if _, err := s.timezones().Doc(timezoneDocId(timezone)).Set(ctx, map[string]interface{}{"name": timezone}); err != nil {
+				return err
+			}
+		}
+	}
+
+	if _, err := ref.Set(ctx, map[string]interface{}{"at": time.Now()}); err != nil {
+		return err
+	}
+	log.Printf("Migrated timezones of %d users", migrated)
+
+	return nil
+}
+
+func (s *FirestoreStore) listUsers(ctx context.Context, query firestore.Query) ([]User, error) {
+	iter := query.Documents(ctx)
+	defer iter.Stop()
+
+	var users []User
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			log.Println("Error getting document:", err)
+			return users, err
+		}
+
+		var user User
+		if err := doc.DataTo(&user); err != nil {
+			log.Printf("Error decoding user %s: %v", doc.Ref.ID, err)
+			continue
+		}
+		user.Id = doc.Ref.ID
+
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
+func (s *FirestoreStore) SaveEntry(ctx context.Context, userId string, entry *Entry) error {
+	_, err := s.entries(userId).Doc(entry.Id).Set(ctx, entry)
+	return err
+}
+
+func (s *FirestoreStore) ListEntries(ctx context.Context, userId string, limit int) ([]Entry, error) {
+	query := s.entries(userId).OrderBy("createdAt", firestore.Asc)
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	return s.queryEntries(ctx, query)
+}
+
+func (s *FirestoreStore) QueryEntries(ctx context.Context, userId string, q EntryQuery) ([]Entry, error) {
+	direction := firestore.Asc
+	if q.Descending {
+		direction = firestore.Desc
+	}
+
+	query := s.entries(userId).OrderBy(firestore.DocumentID, direction)
+	if q.Before != "" {
+		query = query.Where(firestore.DocumentID, "<", s.entries(userId).Doc(q.Before))
+	}
+	if q.After != "" {
+		query = query.Where(firestore.DocumentID, ">", s.entries(userId).Doc(q.After))
+	}
+	if q.Limit > 0 {
+		query = query.Limit(q.Limit)
+	}
+
+	return s.queryEntries(ctx, query)
+}
+
+func (s *FirestoreStore) queryEntries(ctx context.Context, query firestore.Query) ([]Entry, error) {
+	iter := query.Documents(ctx)
+	defer iter.Stop()
+
+	var entries []Entry
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var entry Entry
+		if err := doc.DataTo(&entry); err != nil {
+			return nil, err
+		}
+		entry.Id = doc.Ref.ID
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+func (s *FirestoreStore) GetSession(ctx context.Context, userId string) (*Session, error) {
+	doc, err := s.sessions().Doc(userId).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var session Session
+	if err := doc.DataTo(&session); err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+func (s *FirestoreStore) SaveSession(ctx context.Context, userId string, session *Session) error {
+	_, err := s.sessions().Doc(userId).Set(ctx, session)
+	return err
+}
+
+func (s *FirestoreStore) DeleteSession(ctx context.Context, userId string) error {
+	_, err := s.sessions().Doc(userId).Delete(ctx)
+	return err
+}
+
+func (s *FirestoreStore) GetJob(ctx context.Context, name string) (*Job, error) {
+	doc, err := s.jobs().Doc(name).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var job Job
+	if err := doc.DataTo(&job); err != nil {
+		return nil, err
+	}
+	job.Name = doc.Ref.ID
+
+	return &job, nil
+}
+
+func (s *FirestoreStore) SaveJob(ctx context.Context, job *Job) error {
+	_, err := s.jobs().Doc(job.Name).Set(ctx, job)
+	return err
+}
+
+func (s *FirestoreStore) GetJobRun(ctx context.Context, id string) (*JobRun, error) {
+	doc, err := s.jobRuns().Doc(id).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var run JobRun
+	if err := doc.DataTo(&run); err != nil {
+		return nil, err
+	}
+	run.Id = doc.Ref.ID
+
+	return &run, nil
+}
+
+func (s *FirestoreStore) AcquireJobRun(ctx context.Context, run *JobRun, now time.Time, lease time.Duration) (*JobRun, bool, error) {
+	var (
+		stored   JobRun
+		acquired bool
+	)
+	ref := s.jobRuns().Doc(run.Id)
+
+	// retried by firestore if another instance writes the run meanwhile, which then holds it
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		stored = JobRun{Job: run.Job, ScheduledAt: run.ScheduledAt}
+
+		doc, err := tx.Get(ref)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+		if err == nil {
+			if err := doc.DataTo(&stored); err != nil {
+				return err
+			}
+		}
+		stored.Id = run.Id
+
+		acquired = stored.startAttempt(now, lease)
+		if !acquired {
+			return nil
+		}
+
+		return tx.Set(ref, map[string]interface{}{
+			"job":         stored.Job,
+			"scheduledAt": stored.ScheduledAt,
+			"status":      stored.Status,
+			"attempts":    stored.Attempts,
+			"error":       stored.Error,
+			"startedAt":   stored.StartedAt,
+			"updatedAt":   stored.UpdatedAt,
+		}, firestore.MergeAll)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &stored, acquired, nil
+}
+
+func (s *FirestoreStore) SaveJobRun(ctx context.Context, run *JobRun) error {
+	// users are only added with AddJobRunUser, merge so concurrent additions are kept
+	_, err := s.jobRuns().Doc(run.Id).Set(ctx, map[string]interface{}{
+		"job":         run.Job,
+		"scheduledAt": run.ScheduledAt,
+		"status":      run.Status,
+		"attempts":    run.Attempts,
+		"error":       run.Error,
+		"startedAt":   run.StartedAt,
+		"updatedAt":   run.UpdatedAt,
+	}, firestore.MergeAll)
+
+	return err
+}
+
+func (s *FirestoreStore) AddJobRunUser(ctx context.Context, id string, userId string, at time.Time) error {
+	_, err := s.jobRuns().Doc(id).Set(ctx, map[string]interface{}{
+		"users":     firestore.ArrayUnion(userId),
+		"updatedAt": at,
+	}, firestore.MergeAll)
+
+	return err
+}
+
+func (s *FirestoreStore) GetFailedSummary(ctx context.Context, id string) (*FailedSummary, error) {
+	for _, dead := range []bool{false, true} {
+		doc, err := s.failedSummaries(dead).Doc(id).Get(ctx)
+		if status.Code(err) == codes.NotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var summary FailedSummary
+		if err := doc.DataTo(&summary); err != nil {
+			return nil, err
+		}
+		summary.Id = doc.Ref.ID
+
+		return &summary, nil
+	}
+
+	return nil, ErrNotFound
+}
+
+func (s *FirestoreStore) ListFailedSummaries(ctx context.Context, dead bool) ([]FailedSummary, error) {
+	iter := s.failedSummaries(dead).OrderBy("nextAttempt", firestore.Asc).Documents(ctx)
+	defer iter.Stop()
+
+	var summaries []FailedSummary
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var summary FailedSummary
+		if err := doc.DataTo(&summary); err != nil {
+			return nil, err
+		}
+		summary.Id = doc.Ref.ID
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, nil
+}
+
+func (s *FirestoreStore) SaveFailedSummary(ctx context.Context, summary *FailedSummary) error {
+	return s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		if err := tx.Set(s.failedSummaries(summary.Dead).Doc(summary.Id), summary); err != nil {
+			return err
+		}
+		return tx.Delete(s.failedSummaries(!summary.Dead).Doc(summary.Id))
+	})
+}
+
+func (s *FirestoreStore) DeleteFailedSummary(ctx context.Context, id string) error {
+	return s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		if err := tx.Delete(s.failedSummaries(false).Doc(id)); err != nil {
+			return err
+		}
+		return tx.Delete(s.failedSummaries(true).Doc(id))
+	})
+}
+
+func (s *FirestoreStore) SaveDigest(ctx context.Context, userId string, digest *Digest) error {
+	_, err := s.digests(userId).Doc(digest.Id).Set(ctx, digest)
+	return err
+}
+
+func (s *FirestoreStore) ListDigests(ctx context.Context, userId string, period string) ([]Digest, error) {
+	// ids start with the period, a range on them avoids a composite index
+	iter := s.digests(userId).
+		Where(firestore.DocumentID, ">=", s.digests(userId).Doc(period+"-")).
+		Where(firestore.DocumentID, "<", s.digests(userId).Doc(period+".")).
+		OrderBy(firestore.DocumentID, firestore.Asc).
+		Documents(ctx)
+	defer iter.Stop()
+
+	var digests []Digest
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var digest Digest
+		if err := doc.DataTo(&digest); err != nil {
+			return nil, err
+		}
+		digest.Id = doc.Ref.ID
+
+		digests = append(digests, digest)
+	}
+
+	return digests, nil
+}
+
+func (s *FirestoreStore) Close() error {
+	return s.client.Close()
+}
@@ -0,0 +1,432 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps everything in process memory, for tests and local development
+type MemoryStore struct {
+	users    map[string]*User
+	entries  map[string]map[string]Entry // user id -> entry id -> entry
+	sessions map[string]Session
+	jobs     map[string]Job
+	jobRuns  map[string]JobRun
+	failed   map[string]FailedSummary
+	digests  map[string]map[string]Digest // user id -> digest id -> digest
+	mu       sync.Mutex
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		users:    make(map[string]*User),
+		entries:  make(map[string]map[string]Entry),
+		sessions: make(map[string]Session),
+		jobs:     make(map[string]Job),
+		jobRuns:  make(map[string]JobRun),
+		failed:   make(map[string]FailedSummary),
+		digests:  make(map[string]map[string]Digest),
+	}
+}
+
+func (s *MemoryStore) GetUser(ctx context.Context, userId string) (*User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[userId]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	copied := *user
+	return &copied, nil
+}
+
+func (s *MemoryStore) UpsertUserLastCreatedSession(ctx context.Context, userId string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.user(userId).LastCreatedSession = at
+
+	return nil
+}
+
+func (s *MemoryStore) UpsertUserTimezone(ctx context.Context, userId string, timezone string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.user(userId).Timezone = timezone
+
+	return nil
+}
+
+func (s *MemoryStore) UpsertUserGeminiKey(ctx context.Context, userId string, sealedKey string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.user(userId).GeminiKey = sealedKey
+
+	return nil
+}
+
+func (s *MemoryStore) UpsertUserSettings(ctx context.Context, userId string, settings *Settings) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *settings
+	s.user(userId).Settings = &copied
+
+	return nil
+}
+
+func (s *MemoryStore) UpsertUserLanguageCode(ctx context.Context, userId string, languageCode string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.user(userId).LanguageCode = languageCode
+
+	return nil
+}
+
+func (s *MemoryStore) UpsertUserDataKey(ctx context.Context, userId string, wrappedKey string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.user(userId).DataKey = wrappedKey
+
+	return nil
+}
+
+func (s *MemoryStore) CreateUserDataKey(ctx context.Context, userId string, wrappedKey string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user := s.user(userId)
+	if user.DataKey == "" {
+		user.DataKey = wrappedKey
+	}
+
+	return user.DataKey, nil
+}
+
+func (s *MemoryStore) ListUsers(ctx context.Context) ([]User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var users []User
+	for _, user := range s.users {
+		users = append(users, *user)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].Id < users[j].Id
+	})
+
+	return users, nil
+}
+
+func (s *MemoryStore) QueryUsers(ctx context.Context, query UserQuery) ([]User, error) {
+	all, _ := s.ListUsers(ctx)
+
+	var users []User
+	for _, user := range all {
+		if query.matches(&user) {
+			users = append(users, user)
+		}
+	}
+
+	return users, nil
+}
+
+func (s *MemoryStore) ListTimezones(ctx context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seen := make(map[string]bool)
+	var timezones []string
+	for _, user := range s.users {
+		if user.Timezone != "" && !seen[user.Timezone] {
+			seen[user.Timezone] = true
+			timezones = append(timezones, user.Timezone)
+		}
+	}
+	sort.Strings(timezones)
+
+	return timezones, nil
+}
+
+// user returns the user document, creating it if needed. Caller must hold mu
+func (s *MemoryStore) user(userId string) *User {
+	user, ok := s.users[userId]
+	if !ok {
+		user = &User{Id: userId}
+		s.users[userId] = user
+	}
+
+	return user
+}
+
+func (s *MemoryStore) SaveEntry(ctx context.Context, userId string, entry *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.entries[userId]; !ok {
+		s.entries[userId] = make(map[string]Entry)
+	}
+	s.entries[userId][entry.Id] = *entry
+
+	return nil
+}
+
+func (s *MemoryStore) ListEntries(ctx context.Context, userId string, limit int) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var entries []Entry
+	for _, entry := range s.entries[userId] {
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].CreatedAt.Before(entries[j].CreatedAt)
+	})
+
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+
+	return entries, nil
+}
+
+func (s *MemoryStore) QueryEntries(ctx context.Context, userId string, q EntryQuery) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var entries []Entry
+	for id, entry := range s.entries[userId] {
+		if q.Before != "" && id >= q.Before {
+			continue
+		}
+		if q.After != "" && id <= q.After {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if q.Descending {
+			return entries[i].Id > entries[j].Id
+		}
+		return entries[i].Id < entries[j].Id
+	})
+
+	if q.Limit > 0 && len(entries) > q.Limit {
+		entries = entries[:q.Limit]
+	}
+
+	return entries, nil
+}
+
+func (s *MemoryStore) GetSession(ctx context.Context, userId string) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[userId]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	session.History = append([]Message(nil), session.History...)
+	return &session, nil
+}
+
+func (s *MemoryStore) SaveSession(ctx context.Context, userId string, session *Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *session
+	copied.History = append([]Message(nil), session.History...)
+	s.sessions[userId] = copied
+
+	return nil
+}
+
+func (s *MemoryStore) DeleteSession(ctx context.Context, userId string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, userId)
+
+	return nil
+}
+
+func (s *MemoryStore) GetJob(ctx context.Context, name string) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	job.Pending = append([]time.Time(nil), job.Pending...)
+	return &job, nil
+}
+
+func (s *MemoryStore) SaveJob(ctx context.Context, job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *job
+	copied.Pending = append([]time.Time(nil), job.Pending...)
+	s.jobs[job.Name] = copied
+
+	return nil
+}
+
+func (s *MemoryStore) GetJobRun(ctx context.Context, id string) (*JobRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	run, ok := s.jobRuns[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	run.Users = append([]string(nil), run.Users...)
+	return &run, nil
+}
+
+func (s *MemoryStore) AcquireJobRun(ctx context.Context, run *JobRun, now time.Time, lease time.Duration) (*JobRun, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.jobRuns[run.Id]
+	if !ok {
+		stored = JobRun{Id: run.Id, Job: run.Job, ScheduledAt: run.ScheduledAt}
+	}
+
+	acquired := stored.startAttempt(now, lease)
+	if acquired {
+		s.jobRuns[run.Id] = stored
+	}
+
+	stored.Users = append([]string(nil), stored.Users...)
+	return &stored, acquired, nil
+}
+
+func (s *MemoryStore) SaveJobRun(ctx context.Context, run *JobRun) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *run
+	copied.Users = s.jobRuns[run.Id].Users
+	s.jobRuns[run.Id] = copied
+
+	return nil
+}
+
+func (s *MemoryStore) AddJobRunUser(ctx context.Context, id string, userId string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	run := s.jobRuns[id]
+	run.Id = id
+	run.UpdatedAt = at
+	for _, handled := range run.Users {
+		if handled == userId {
+			s.jobRuns[id] = run
+			return nil
+		}
+	}
+	run.Users = append(append([]string(nil), run.Users...), userId)
+	s.jobRuns[id] = run
+
+	return nil
+}
+
+func (s *MemoryStore) GetFailedSummary(ctx context.Context, id string) (*FailedSummary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	summary, ok := s.failed[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	summary.History = append([]Message(nil), summary.History...)
+	return &summary, nil
+}
+
+func (s *MemoryStore) ListFailedSummaries(ctx context.Context, dead bool) ([]FailedSummary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var summaries []FailedSummary
+	for _, summary := range s.failed {
+		if summary.Dead != dead {
+			continue
+		}
+		summary.History = append([]Message(nil), summary.History...)
+		summaries = append(summaries, summary)
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].NextAttempt.Before(summaries[j].NextAttempt)
+	})
+
+	return summaries, nil
+}
+
+func (s *MemoryStore) SaveFailedSummary(ctx context.Context, summary *FailedSummary) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *summary
+	copied.History = append([]Message(nil), summary.History...)
+	s.failed[summary.Id] = copied
+
+	return nil
+}
+
+func (s *MemoryStore) DeleteFailedSummary(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.failed, id)
+
+	return nil
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+func (s *MemoryStore) SaveDigest(ctx context.Context, userId string, digest *Digest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.digests[userId] == nil {
+		s.digests[userId] = make(map[string]Digest)
+	}
+	s.digests[userId][digest.Id] = *digest
+
+	return nil
+}
+
+func (s *MemoryStore) ListDigests(ctx context.Context, userId string, period string) ([]Digest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var digests []Digest
+	for _, digest := range s.digests[userId] {
+		if digest.Period == period {
+			digests = append(digests, digest)
+		}
+	}
+
+	sort.Slice(digests, func(i, j int) bool { return digests[i].Id < digests[j].Id })
+
+	return digests, nil
+}
@@ -0,0 +1,633 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteMigrations are applied in order, tracked by PRAGMA user_version.
+// Append new migrations, never edit released ones
+var sqliteMigrations = []string{
+	`
+	CREATE TABLE IF NOT EXISTS users (
+		id TEXT PRIMARY KEY,
+		last_created_session INTEGER NOT NULL DEFAULT 0
+	);
+
+	CREATE TABLE IF NOT EXISTS entries (
+		user_id TEXT NOT NULL,
+		id TEXT NOT NULL,
+		created_at INTEGER NOT NULL,
+		data TEXT NOT NULL,
+		PRIMARY KEY (user_id, id)
+	);
+
+	CREATE INDEX IF NOT EXISTS entries_created_at ON entries (user_id, created_at);
+
+	CREATE TABLE IF NOT EXISTS sessions (
+		user_id TEXT PRIMARY KEY,
+		day TEXT NOT NULL,
+		data TEXT NOT NULL
+	);
+	`,
+	`ALTER TABLE users ADD COLUMN timezone TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE users ADD COLUMN gemini_key TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE users ADD COLUMN data_key TEXT NOT NULL DEFAULT ''`,
+	`
+	CREATE TABLE IF NOT EXISTS jobs (
+		name TEXT PRIMARY KEY,
+		last_run INTEGER NOT NULL
+	);
+	`,
+	`
+	ALTER TABLE jobs ADD COLUMN pending TEXT NOT NULL DEFAULT '[]';
+
+	CREATE TABLE IF NOT EXISTS job_runs (
+		id TEXT PRIMARY KEY,
+		job TEXT NOT NULL,
+		scheduled_at INTEGER NOT NULL,
+		status TEXT NOT NULL,
+		attempts INTEGER NOT NULL,
+		error TEXT NOT NULL,
+		started_at INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS job_run_users (
+		run_id TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		PRIMARY KEY (run_id, user_id)
+	);
+	`,
+	`
+	CREATE TABLE IF NOT EXISTS failed_summaries (
+		id TEXT PRIMARY KEY,
+		dead INTEGER NOT NULL,
+		next_attempt INTEGER NOT NULL,
+		data TEXT NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS failed_summaries_next_attempt ON failed_summaries (dead, next_attempt);
+	`,
+	`
+	CREATE TABLE IF NOT EXISTS digests (
+		user_id TEXT NOT NULL,
+		id TEXT NOT NULL,
+		period TEXT NOT NULL,
+		data TEXT NOT NULL,
+		PRIMARY KEY (user_id, id)
+	);
+	`,
+	`ALTER TABLE users ADD COLUMN settings TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE users ADD COLUMN language_code TEXT NOT NULL DEFAULT ''`,
+	`CREATE INDEX IF NOT EXISTS users_timezone ON users (timezone)`,
+}
+
+// SQLiteStore is an embedded single file backend for self-hosting without Google Cloud.
+// Timestamps are stored as unix nanoseconds, documents as JSON
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+
+	// sqlite allows a single writer, serialize access instead of handling SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+
+	if err := migrateSQLite(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLiteStore{db: db}, nil
+}
+
+func migrateSQLite(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return err
+	}
+
+	for i := version; i < len(sqliteMigrations); i++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(sqliteMigrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("sqlite migration %d: %w", i+1, err)
+		}
+		if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, i+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+const sqliteUserColumns = `id, last_created_session, timezone, gemini_key, data_key, settings, language_code`
+
+func scanUser(row interface{ Scan(...interface{}) error }) (*User, error) {
+	var (
+		user               User
+		lastCreatedSession int64
+		settings           string
+	)
+	if err := row.Scan(&user.Id, &lastCreatedSession, &user.Timezone, &user.GeminiKey, &user.DataKey, &settings, &user.LanguageCode); err != nil {
+		return nil, err
+	}
+	user.LastCreatedSession = fromUnixNano(lastCreatedSession)
+
+	if settings != "" {
+		user.Settings = &Settings{}
+		if err := json.Unmarshal([]byte(settings), user.Settings); err != nil {
+			return nil, err
+		}
+	}
+
+	return &user, nil
+}
+
+func (s *SQLiteStore) GetUser(ctx context.Context, userId string) (*User, error) {
+	user, err := scanUser(s.db.QueryRowContext(ctx, `SELECT `+sqliteUserColumns+` FROM users WHERE id = ?`, userId))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+
+	return user, err
+}
+
+func (s *SQLiteStore) UpsertUserGeminiKey(ctx context.Context, userId string, sealedKey string) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO users (id, gemini_key) VALUES (?, ?)
+		ON CONFLICT (id) DO UPDATE SET gemini_key = excluded.gemini_key`,
+		userId, sealedKey)
+
+	return err
+}
+
+func (s *SQLiteStore) UpsertUserSettings(ctx context.Context, userId string, settings *Settings) error {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO users (id, settings) VALUES (?, ?)
+		ON CONFLICT (id) DO UPDATE SET settings = excluded.settings`,
+		userId, string(data))
+
+	return err
+}
+
+func (s *SQLiteStore) UpsertUserLanguageCode(ctx context.Context, userId string, languageCode string) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO users (id, language_code) VALUES (?, ?)
+		ON CONFLICT (id) DO UPDATE SET language_code = excluded.language_code`,
+		userId, languageCode)
+
+	return err
+}
+
+func (s *SQLiteStore) UpsertUserDataKey(ctx context.Context, userId string, wrappedKey string) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO users (id, data_key) VALUES (?, ?)
+		ON CONFLICT (id) DO UPDATE SET data_key = excluded.data_key`,
+		userId, wrappedKey)
+
+	return err
+}
+
+func (s *SQLiteStore) CreateUserDataKey(ctx context.Context, userId string, wrappedKey string) (string, error) {
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO users (id, data_key) VALUES (?, ?)
+		ON CONFLICT (id) DO UPDATE SET data_key = excluded.data_key WHERE users.data_key = ''`,
+		userId, wrappedKey); err != nil {
+		return "", err
+	}
+
+	var stored string
+	err := s.db.QueryRowContext(ctx, `SELECT data_key FROM users WHERE id = ?`, userId).Scan(&stored)
+
+	return stored, err
+}
+
+func (s *SQLiteStore) ListUsers(ctx context.Context) ([]User, error) {
+	return s.queryUsers(ctx, `SELECT `+sqliteUserColumns+` FROM users ORDER BY id`)
+}
+
+func (s *SQLiteStore) QueryUsers(ctx context.Context, query UserQuery) ([]User, error) {
+	if len(query.Timezones) == 0 {
+		return nil, nil
+	}
+
+	var args []interface{}
+	for _, timezone := range query.Timezones {
+		args = append(args, timezone)
+	}
+	where := `timezone IN (?` + strings.Repeat(`, ?`, len(query.Timezones)-1) + `)`
+	if query.ReminderHour != nil {
+		// settings are empty until the user changes a default, which is not JSON
+		where += ` AND CASE WHEN settings = '' THEN NULL ELSE json_extract(settings, '$.reminderHour') END = ?`
+		args = append(args, *query.ReminderHour)
+	}
+
+	return s.queryUsers(ctx, `SELECT `+sqliteUserColumns+` FROM users WHERE `+where+` ORDER BY id`, args...)
+}
+
+func (s *SQLiteStore) ListTimezones(ctx context.Context) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT DISTINCT timezone FROM users WHERE timezone != '' ORDER BY timezone`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var timezones []string
+	for rows.Next() {
+		var timezone string
+		if err := rows.Scan(&timezone); err != nil {
+			return nil, err
+		}
+		timezones = append(timezones, timezone)
+	}
+
+	return timezones, rows.Err()
+}
+
+func (s *SQLiteStore) queryUsers(ctx context.Context, query string, args ...interface{}) ([]User, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *user)
+	}
+
+	return users, rows.Err()
+}
+
+func (s *SQLiteStore) UpsertUserLastCreatedSession(ctx context.Context, userId string, at time.Time) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO users (id, last_created_session) VALUES (?, ?)
+		ON CONFLICT (id) DO UPDATE SET last_created_session = excluded.last_created_session`,
+		userId, at.UnixNano())
+
+	return err
+}
+
+func (s *SQLiteStore) UpsertUserTimezone(ctx context.Context, userId string, timezone string) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO users (id, timezone) VALUES (?, ?)
+		ON CONFLICT (id) DO UPDATE SET timezone = excluded.timezone`,
+		userId, timezone)
+
+	return err
+}
+
+func (s *SQLiteStore) SaveEntry(ctx context.Context, userId string, entry *Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO entries (user_id, id, created_at, data) VALUES (?, ?, ?, ?)
+		ON CONFLICT (user_id, id) DO UPDATE SET created_at = excluded.created_at, data = excluded.data`,
+		userId, entry.Id, entry.CreatedAt.UnixNano(), string(data))
+
+	return err
+}
+
+func (s *SQLiteStore) ListEntries(ctx context.Context, userId string, limit int) ([]Entry, error) {
+	if limit <= 0 {
+		limit = -1 // sqlite treats negative limit as unbounded
+	}
+
+	return s.queryEntries(ctx, `SELECT data FROM entries WHERE user_id = ? ORDER BY created_at ASC LIMIT ?`, userId, limit)
+}
+
+func (s *SQLiteStore) QueryEntries(ctx context.Context, userId string, q EntryQuery) ([]Entry, error) {
+	order := "ASC"
+	if q.Descending {
+		order = "DESC"
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = -1
+	}
+
+	return s.queryEntries(ctx, `
+		SELECT data FROM entries
+		WHERE user_id = ? AND (? = '' OR id < ?) AND (? = '' OR id > ?)
+		ORDER BY id `+order+` LIMIT ?`,
+		userId, q.Before, q.Before, q.After, q.After, limit)
+}
+
+func (s *SQLiteStore) queryEntries(ctx context.Context, query string, args ...interface{}) ([]Entry, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+
+		var entry Entry
+		if err := json.Unmarshal([]byte(data), &entry); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+func (s *SQLiteStore) GetSession(ctx context.Context, userId string) (*Session, error) {
+	var data string
+	err := s.db.QueryRowContext(ctx, `SELECT data FROM sessions WHERE user_id = ?`, userId).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var session Session
+	if err := json.Unmarshal([]byte(data), &session); err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+func (s *SQLiteStore) SaveSession(ctx context.Context, userId string, session *Session) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO sessions (user_id, day, data) VALUES (?, ?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET day = excluded.day, data = excluded.data`,
+		userId, session.Day, string(data))
+
+	return err
+}
+
+func (s *SQLiteStore) DeleteSession(ctx context.Context, userId string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM sessions WHERE user_id = ?`, userId)
+	return err
+}
+
+func (s *SQLiteStore) GetJob(ctx context.Context, name string) (*Job, error) {
+	var (
+		lastRun int64
+		pending string
+	)
+	err := s.db.QueryRowContext(ctx, `SELECT last_run, pending FROM jobs WHERE name = ?`, name).Scan(&lastRun, &pending)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	job := Job{Name: name, LastRun: fromUnixNano(lastRun)}
+	if err := json.Unmarshal([]byte(pending), &job.Pending); err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+func (s *SQLiteStore) SaveJob(ctx context.Context, job *Job) error {
+	pending, err := json.Marshal(job.Pending)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO jobs (name, last_run, pending) VALUES (?, ?, ?)
+		ON CONFLICT (name) DO UPDATE SET last_run = excluded.last_run, pending = excluded.pending`,
+		job.Name, job.LastRun.UnixNano(), string(pending))
+
+	return err
+}
+
+func (s *SQLiteStore) GetJobRun(ctx context.Context, id string) (*JobRun, error) {
+	var (
+		run                               JobRun
+		scheduledAt, startedAt, updatedAt int64
+	)
+	err := s.db.QueryRowContext(ctx, `
+		SELECT job, scheduled_at, status, attempts, error, started_at, updated_at FROM job_runs WHERE id = ?`, id).
+		Scan(&run.Job, &scheduledAt, &run.Status, &run.Attempts, &run.Error, &startedAt, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	run.Id = id
+	run.ScheduledAt = fromUnixNano(scheduledAt)
+	run.StartedAt = fromUnixNano(startedAt)
+	run.UpdatedAt = fromUnixNano(updatedAt)
+
+	rows, err := s.db.QueryContext(ctx, `SELECT user_id FROM job_run_users WHERE run_id = ? ORDER BY user_id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userId string
+		if err := rows.Scan(&userId); err != nil {
+			return nil, err
+		}
+		run.Users = append(run.Users, userId)
+	}
+
+	return &run, rows.Err()
+}
+
+func (s *SQLiteStore) AcquireJobRun(ctx context.Context, run *JobRun, now time.Time, lease time.Duration) (*JobRun, bool, error) {
+	// one statement, so the check of an existing run and the start of an attempt cannot interleave
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO job_runs (id, job, scheduled_at, status, attempts, error, started_at, updated_at) VALUES (?, ?, ?, ?, 1, '', ?, ?)
+		ON CONFLICT (id) DO UPDATE SET status = excluded.status, attempts = job_runs.attempts + 1, error = '', updated_at = excluded.updated_at
+		WHERE job_runs.status != ? AND (job_runs.attempts = 0 OR job_runs.updated_at <= ?)`,
+		run.Id, run.Job, run.ScheduledAt.UnixNano(), JobRunStarted, now.UnixNano(), now.UnixNano(),
+		JobRunCompleted, now.Add(-lease).UnixNano())
+	if err != nil {
+		return nil, false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nil, false, err
+	}
+
+	stored, err := s.GetJobRun(ctx, run.Id)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return stored, affected == 1, nil
+}
+
+func (s *SQLiteStore) SaveJobRun(ctx context.Context, run *JobRun) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO job_runs (id, job, scheduled_at, status, attempts, error, started_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET job = excluded.job, scheduled_at = excluded.scheduled_at, status = excluded.status,
+			attempts = excluded.attempts, error = excluded.error, started_at = excluded.started_at, updated_at = excluded.updated_at`,
+		run.Id, run.Job, run.ScheduledAt.UnixNano(), run.Status, run.Attempts, run.Error, run.StartedAt.UnixNano(), run.UpdatedAt.UnixNano())
+
+	return err
+}
+
+func (s *SQLiteStore) AddJobRunUser(ctx context.Context, id string, userId string, at time.Time) error {
+	if _, err := s.db.ExecContext(ctx, `INSERT OR IGNORE INTO job_run_users (run_id, user_id) VALUES (?, ?)`, id, userId); err != nil {
+		return err
+	}
+
+	_, err := s.db.ExecContext(ctx, `UPDATE job_runs SET updated_at = ? WHERE id = ?`, at.UnixNano(), id)
+	return err
+}
+
+func (s *SQLiteStore) GetFailedSummary(ctx context.Context, id string) (*FailedSummary, error) {
+	var data string
+	err := s.db.QueryRowContext(ctx, `SELECT data FROM failed_summaries WHERE id = ?`, id).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var summary FailedSummary
+	if err := json.Unmarshal([]byte(data), &summary); err != nil {
+		return nil, err
+	}
+
+	return &summary, nil
+}
+
+func (s *SQLiteStore) ListFailedSummaries(ctx context.Context, dead bool) ([]FailedSummary, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT data FROM failed_summaries WHERE dead = ? ORDER BY next_attempt`, dead)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var summaries []FailedSummary
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+
+		var summary FailedSummary
+		if err := json.Unmarshal([]byte(data), &summary); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, rows.Err()
+}
+
+func (s *SQLiteStore) SaveFailedSummary(ctx context.Context, summary *FailedSummary) error {
+	data, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO failed_summaries (id, dead, next_attempt, data) VALUES (?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET dead = excluded.dead, next_attempt = excluded.next_attempt, data = excluded.data`,
+		summary.Id, summary.Dead, summary.NextAttempt.UnixNano(), string(data))
+
+	return err
+}
+
+func (s *SQLiteStore) DeleteFailedSummary(ctx context.Context, id string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM failed_summaries WHERE id = ?`, id)
+	return err
+}
+
+func (s *SQLiteStore) SaveDigest(ctx context.Context, userId string, digest *Digest) error {
+	data, err := json.Marshal(digest)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO digests (user_id, id, period, data) VALUES (?, ?, ?, ?)
+		ON CONFLICT (user_id, id) DO UPDATE SET period = excluded.period, data = excluded.data`,
+		userId, digest.Id, digest.Period, string(data))
+
+	return err
+}
+
+func (s *SQLiteStore) ListDigests(ctx context.Context, userId string, period string) ([]Digest, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT data FROM digests WHERE user_id = ? AND period = ? ORDER BY id`, userId, period)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var digests []Digest
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+
+		var digest Digest
+		if err := json.Unmarshal([]byte(data), &digest); err != nil {
+			return nil, err
+		}
+		digests = append(digests, digest)
+	}
+
+	return digests, rows.Err()
+}
+
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+func fromUnixNano(nsec int64) time.Time {
+	if nsec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nsec)
+}
@@ -0,0 +1,366 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	firebaseClient "journie/pkg/firebase"
+	"journie/pkg/secrets"
+	"log"
+	"os"
+	"time"
+)
+
+// StoreClient is the storage backend selected at startup by Init
+var StoreClient Store
+
+// ErrNotFound is returned when a requested document does not exist
+var ErrNotFound = errors.New("storage: not found")
+
+const (
+	Firestore string = "firestore"
+	Memory    string = "memory"
+	SQLite    string = "sqlite"
+)
+
+// Store persists users, journal entries and chat session metadata.
+// userId should be a string in format {platform}-{indentifier}
+type Store interface {
+	// GetUser retrieves a user document, returns ErrNotFound if it does not exist
+	GetUser(ctx context.Context, userId string) (*User, error)
+
+	// ListUsers lists all users
+	ListUsers(ctx context.Context) ([]User, error)
+
+	// QueryUsers lists users filtered by timezone and reminder hour, ordered by id
+	QueryUsers(ctx context.Context, query UserQuery) ([]User, error)
+
+	// ListTimezones lists the distinct timezones users set, sorted
+	ListTimezones(ctx context.Context) ([]string, error)
+
+	// UpsertUserLastCreatedSession marks user as having started a chat session at the given time
+	UpsertUserLastCreatedSession(ctx context.Context, userId string, at time.Time) error
+
+	// UpsertUserTimezone sets the IANA timezone of user
+	UpsertUserTimezone(ctx context.Context, userId string, timezone string) error
+
+	// UpsertUserGeminiKey sets the sealed Gemini API key of user, empty to remove it
+	UpsertUserGeminiKey(ctx context.Context, userId string, sealedKey string) error
+
+	// UpsertUserDataKey sets the wrapped data key used to encrypt entries and sessions of user
+	UpsertUserDataKey(ctx context.Context, userId string, wrappedKey string) error
+
+	// CreateUserDataKey sets the wrapped data key of user unless they have one, atomically.
+	// Returns the stored key, which is not wrappedKey if another was set first
+	CreateUserDataKey(ctx context.Context, userId string, wrappedKey string) (string, error)
+
+	// UpsertUserSettings sets the preferences of user, replacing all of them
+	UpsertUserSettings(ctx context.Context, userId string, settings *Settings) error
+
+	// UpsertUserLanguageCode sets the language of user's chat app, as reported by their platform
+	UpsertUserLanguageCode(ctx context.Context, userId string, languageCode string) error
+
+	// SaveEntry creates or overwrites a journal entry, keyed by entry.Id
+	SaveEntry(ctx context.Context, userId string, entry *Entry) error
+
+	// ListEntries lists up to limit journal entries of user, oldest first. limit <= 0 lists all
+	ListEntries(ctx context.Context, userId string, limit int) ([]Entry, error)
+
+	// QueryEntries lists journal entries of user filtered and ordered by id, i.e. by date
+	QueryEntries(ctx context.Context, userId string, query EntryQuery) ([]Entry, error)
+
+	// GetSession retrieves the in-progress chat session of user, returns ErrNotFound if there is none
+	GetSession(ctx context.Context, userId string) (*Session, error)
+
+	// SaveSession creates or overwrites the in-progress chat session of user
+	SaveSession(ctx context.Context, userId string, session *Session) error
+
+	// DeleteSession removes the in-progress chat session of user, if any
+	DeleteSession(ctx context.Context, userId string) error
+
+	// GetJob retrieves the state of a scheduled job, returns ErrNotFound if it never ran
+	GetJob(ctx context.Context, name string) (*Job, error)
+
+	// SaveJob creates or overwrites the state of a scheduled job, keyed by job.Name
+	SaveJob(ctx context.Context, job *Job) error
+
+	// GetJobRun retrieves a run record of a scheduled job, returns ErrNotFound if the run never started
+	GetJobRun(ctx context.Context, id string) (*JobRun, error)
+
+	// AcquireJobRun starts an attempt of run, keyed by run.Id, unless it is completed or an attempt updated it
+	// less than lease before now. The check and the write are atomic, so only one instance starts an attempt.
+	// Returns the stored run, with the users handled so far, and whether the attempt was started
+	AcquireJobRun(ctx context.Context, run *JobRun, now time.Time, lease time.Duration) (*JobRun, bool, error)
+
+	// SaveJobRun creates or updates a run record, keyed by run.Id. Users handled by the run are left untouched
+	SaveJobRun(ctx context.Context, run *JobRun) error
+
+	// AddJobRunUser records userId as handled by a run, and at as the run's last update
+	AddJobRunUser(ctx context.Context, id string, userId string, at time.Time) error
+
+	// GetFailedSummary retrieves a failed summary queued for retry or dead, returns ErrNotFound if there is none
+	GetFailedSummary(ctx context.Context, id string) (*FailedSummary, error)
+
+	// ListFailedSummaries lists dead letters if dead is set, or failed summaries queued for retry, by next attempt
+	ListFailedSummaries(ctx context.Context, dead bool) ([]FailedSummary, error)
+
+	// SaveFailedSummary creates or overwrites a failed summary, keyed by summary.Id, moving it to dead letters if summary.Dead
+	SaveFailedSummary(ctx context.Context, summary *FailedSummary) error
+
+	// DeleteFailedSummary removes a failed summary, if any, once it has been summarized
+	DeleteFailedSummary(ctx context.Context, id string) error
+
+	// SaveDigest creates or overwrites a digest of user, keyed by digest.Id
+	SaveDigest(ctx context.Context, userId string, digest *Digest) error
+
+	// ListDigests lists digests of user for period, i.e. Weekly, Monthly or Yearly, oldest first
+	ListDigests(ctx context.Context, userId string, period string) ([]Digest, error)
+
+	Close() error
+}
+
+type User struct {
+	Id                 string    `json:"id" firestore:"-"`
+	LastCreatedSession time.Time `json:"lastCreatedSession" firestore:"lastCreatedSession"`
+	Timezone           string    `json:"timezone" firestore:"timezone"`                     // IANA name, empty if not set
+	GeminiKey          string    `json:"geminiKey" firestore:"geminiKey"`                   // sealed with secrets master key, empty if not set
+	DataKey            string    `json:"dataKey" firestore:"dataKey"`                       // wrapped with secrets master key, empty if not set
+	Settings           *Settings `json:"settings,omitempty" firestore:"settings,omitempty"` // nil until the user changes a default
+	LanguageCode       string    `json:"languageCode" firestore:"languageCode"`             // language of the user's chat app, e.g. en or pt-br, empty if unknown
+}
+
+// Settings are the preferences of a user, see users.SettingsOf for the defaults
+type Settings struct {
+	Reminders    bool   `json:"reminders" firestore:"reminders"`       // whether to remind the user to journal daily
+	ReminderHour int    `json:"reminderHour" firestore:"reminderHour"` // local hour of the reminder, 0-23
+	Language     string `json:"language" firestore:"language"`         // language code replies are written in, empty to follow the user's chat app
+	Persona      string `json:"persona" firestore:"persona"`           // how Journie talks to the user
+	SummaryStyle string `json:"summaryStyle" firestore:"summaryStyle"` // how daily entries are written
+}
+
+// Entry is a summarized journal entry for a single day
+type Entry struct {
+	Id        string    `json:"id" firestore:"-"` // date in format 2006-01-02
+	Summary   string    `json:"summary" firestore:"summary"`
+	Mood      []string  `json:"mood" firestore:"mood"`
+	Photos    []Photo   `json:"photos,omitempty" firestore:"photos,omitempty"`
+	CreatedAt time.Time `json:"createdAt" firestore:"createdAt"`
+
+	// Embedding is the vector of the summary for semantic search, empty if embedding failed
+	Embedding []float32 `json:"embedding,omitempty" firestore:"embedding,omitempty"`
+	// EmbeddingModel names the model of Embedding, only vectors of the same model are compared
+	EmbeddingModel string `json:"embeddingModel,omitempty" firestore:"embeddingModel,omitempty"`
+
+	// Ciphertext holds summary, mood, photos and embedding when encrypted at rest, which are then left empty
+	Ciphertext string `json:"ciphertext,omitempty" firestore:"ciphertext,omitempty"`
+}
+
+// Photo is a reference to a photo shared during a day, the image itself stays with the messaging platform
+type Photo struct {
+	FileId  string `json:"fileId" firestore:"fileId"` // platform file id, can be re-sent without uploading again
+	Caption string `json:"caption,omitempty" firestore:"caption,omitempty"`
+}
+
+// UserQuery filters users, so scheduled jobs read only the users due at the hour
+type UserQuery struct {
+	Timezones    []string // users in one of these timezones, "" for users who never set one
+	ReminderHour *int     // if set, only users whose settings remind them at this hour, not users who never changed settings
+}
+
+func (query UserQuery) matches(user *User) bool {
+	inTimezone := false
+	for _, timezone := range query.Timezones {
+		if user.Timezone == timezone {
+			inTimezone = true
+		}
+	}
+	if !inTimezone {
+		return false
+	}
+
+	return query.ReminderHour == nil || user.Settings != nil && user.Settings.ReminderHour == *query.ReminderHour
+}
+
+// EntryQuery filters entries by id, ids are dates in format 2006-01-02 so they sort chronologically
+type EntryQuery struct {
+	Before     string // exclusive, ignored if empty
+	After      string // exclusive, ignored if empty
+	Descending bool
+	Limit      int // <= 0 for no limit
+}
+
+// Periods of digests, daily entries roll up into weeks, weeks into months and months into years
+const (
+	Weekly  string = "week"
+	Monthly string = "month"
+	Yearly  string = "year"
+)
+
+// Digest summarizes a user's journal over a week, month or year
+type Digest struct {
+	Id         string         `json:"id" firestore:"-"` // period and its key, e.g. week-2024-W21, month-2024-05 or year-2024
+	Period     string         `json:"period" firestore:"period"`
+	Start      string         `json:"start" firestore:"start"` // first day covered, in format 2006-01-02
+	End        string         `json:"end" firestore:"end"`     // last day covered, in format 2006-01-02
+	Days       int            `json:"days" firestore:"days"`   // days journaled
+	Summary    string         `json:"summary" firestore:"summary"`
+	Highlights []string       `json:"highlights" firestore:"highlights"`
+	Moods      map[string]int `json:"moods" firestore:"moods"` // days journaled per mood
+	CreatedAt  time.Time      `json:"createdAt" firestore:"createdAt"`
+
+	// Ciphertext holds summary, highlights and moods when encrypted at rest, which are then left empty
+	Ciphertext string `json:"ciphertext,omitempty" firestore:"ciphertext,omitempty"`
+}
+
+// Session is an in-progress chat session, kept until it is summarized into an Entry
+type Session struct {
+	Day       string    `json:"day" firestore:"day"` // journaling day the session belongs to, in format 2006-01-02
+	History   []Message `json:"history" firestore:"history"`
+	CreatedAt time.Time `json:"createdAt" firestore:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt" firestore:"updatedAt"`
+
+	// Ciphertext holds history when encrypted at rest, which is then left empty
+	Ciphertext string `json:"ciphertext,omitempty" firestore:"ciphertext,omitempty"`
+}
+
+// Message is a single turn of a chat session
+type Message struct {
+	Role  string `json:"role" firestore:"role"`
+	Parts []Part `json:"parts" firestore:"parts"`
+}
+
+// Part is either text or inline data of a message.
+// Inline data with a Ref, e.g. a photo, is stored as the reference only
+type Part struct {
+	Text     string `json:"text,omitempty" firestore:"text,omitempty"`
+	MIMEType string `json:"mimeType,omitempty" firestore:"mimeType,omitempty"`
+	Data     []byte `json:"data,omitempty" firestore:"data,omitempty"`
+	Ref      string `json:"ref,omitempty" firestore:"ref,omitempty"`
+}
+
+// Job is the state of a scheduled job, kept so missed runs can be caught up after downtime
+type Job struct {
+	Name    string    `json:"name" firestore:"-"`
+	LastRun time.Time `json:"lastRun" firestore:"lastRun"` // scheduled time of the last run, not when it actually ran
+
+	// Pending are scheduled times of runs not completed yet, retried until they complete or become too old
+	Pending []time.Time `json:"pending" firestore:"pending"`
+}
+
+// Statuses of a job run
+const (
+	JobRunStarted   string = "started"
+	JobRunCompleted string = "completed"
+	JobRunFailed    string = "failed"
+)
+
+// JobRun records an execution of a scheduled job for one scheduled time, its logical date
+type JobRun struct {
+	Id          string    `json:"id" firestore:"-"` // see JobRunId
+	Job         string    `json:"job" firestore:"job"`
+	ScheduledAt time.Time `json:"scheduledAt" firestore:"scheduledAt"`
+	Status      string    `json:"status" firestore:"status"`
+	Attempts    int       `json:"attempts" firestore:"attempts"`
+	Error       string    `json:"error,omitempty" firestore:"error,omitempty"` // of the last failed attempt
+	StartedAt   time.Time `json:"startedAt" firestore:"startedAt"`
+	UpdatedAt   time.Time `json:"updatedAt" firestore:"updatedAt"`
+
+	// Users are ids of users the run handled, skipped when a partial run resumes
+	Users []string `json:"users" firestore:"users"`
+}
+
+// startAttempt starts an attempt of run at now unless it is completed or an attempt holds it for lease
+func (run *JobRun) startAttempt(now time.Time, lease time.Duration) bool {
+	if run.Status == JobRunCompleted || (run.Attempts > 0 && now.Sub(run.UpdatedAt) < lease) {
+		return false
+	}
+
+	if run.StartedAt.IsZero() {
+		run.StartedAt = now
+	}
+	run.Status = JobRunStarted
+	run.Attempts++
+	run.Error = ""
+	run.UpdatedAt = now
+
+	return true
+}
+
+// JobRunId identifies the run of job scheduled at, e.g. remind@2024-05-20T22:00Z
+func JobRunId(job string, scheduledAt time.Time) string {
+	return job + "@" + scheduledAt.UTC().Format("2006-01-02T15:04Z")
+}
+
+// FailedSummary is a chat session that could not be summarized, kept with its raw history.
+// It is retried with backoff, then parked as a dead letter until an operator replays it
+type FailedSummary struct {
+	Id          string    `json:"id" firestore:"-"` // see FailedSummaryId
+	UserId      string    `json:"userId" firestore:"userId"`
+	Day         string    `json:"day" firestore:"day"` // journaling day of the session, in format 2006-01-02
+	History     []Message `json:"history" firestore:"history"`
+	Attempts    int       `json:"attempts" firestore:"attempts"`
+	Error       string    `json:"error" firestore:"error"` // of the last attempt
+	NextAttempt time.Time `json:"nextAttempt" firestore:"nextAttempt"`
+	Dead        bool      `json:"dead" firestore:"dead"`
+	CreatedAt   time.Time `json:"createdAt" firestore:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt" firestore:"updatedAt"`
+
+	// Ciphertext holds history when encrypted at rest, which is then left empty
+	Ciphertext string `json:"ciphertext,omitempty" firestore:"ciphertext,omitempty"`
+}
+
+// FailedSummaryId identifies the failed summary of user for a journaling day, e.g. telegram-1@2024-05-20
+func FailedSummaryId(userId string, day string) string {
+	return userId + "@" + day
+}
+
+// Init selects storage backend from STORAGE_BACKEND env, defaulting to firestore
+func Init(ctx context.Context) error {
+	backend := os.Getenv("STORAGE_BACKEND")
+	if backend == "" {
+		backend = Firestore
+	}
+
+	store, err := New(ctx, backend)
+	if err != nil {
+		return err
+	}
+
+	if os.Getenv("ENCRYPT_AT_REST") == "true" {
+		keyring, err := secrets.MasterKeyring()
+		if err != nil {
+			return fmt.Errorf("ENCRYPT_AT_REST requires a master key: %w", err)
+		}
+		store = NewEncryptedStore(store, keyring)
+	}
+
+	StoreClient = store
+	log.Printf("%s storage initialized", backend)
+
+	return nil
+}
+
+// New creates a store for the given backend
+func New(ctx context.Context, backend string) (Store, error) {
+	switch backend {
+	case Firestore:
+		if err := firebaseClient.Init(ctx); err != nil {
+			return nil, err
+		}
+		store := NewFirestoreStore(firebaseClient.FirestoreClient)
+		if err := store.Migrate(ctx); err != nil {
+			return nil, fmt.Errorf("migrating firestore: %w", err)
+		}
+		return store, nil
+	case Memory:
+		return NewMemoryStore(), nil
+	case SQLite:
+		path := os.Getenv("SQLITE_PATH")
+		if path == "" {
+			path = "journie.db"
+		}
+		return NewSQLiteStore(path)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", backend)
+	}
+}
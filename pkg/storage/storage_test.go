@@ -0,0 +1,529 @@
+package storage_test
+
+import (
+	"context"
+	"fmt"
+	"journie/pkg/secrets"
+	"journie/pkg/storage"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newStores(t *testing.T) map[string]storage.Store {
+	sqliteStore, err := storage.NewSQLiteStore(filepath.Join(t.TempDir(), "journie.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore() = %v, want nil", err)
+	}
+	t.Cleanup(func() { sqliteStore.Close() })
+
+	return map[string]storage.Store{
+		storage.Memory: storage.NewMemoryStore(),
+		storage.SQLite: sqliteStore,
+	}
+}
+
+// TestGetUserNotFound calls Store.GetUser for an unknown user, checking for ErrNotFound.
+func TestGetUserNotFound(t *testing.T) {
+	for name, store := range newStores(t) {
+		user, err := store.GetUser(context.Background(), "telegram-404")
+		if user != nil || err != storage.ErrNotFound {
+			t.Fatalf(`%s: GetUser("telegram-404") = %v, %v, want nil, ErrNotFound`, name, user, err)
+		}
+	}
+}
+
+// TestListUsers upserts lastCreatedSession and timezone for two users,
+// checking that ListUsers returns both with their fields merged.
+func TestListUsers(t *testing.T) {
+	ctx := context.Background()
+	at := time.Date(2024, 5, 20, 14, 0, 0, 0, time.UTC)
+
+	for name, store := range newStores(t) {
+		store.UpsertUserTimezone(ctx, "telegram-1", "Europe/London")
+		store.UpsertUserLastCreatedSession(ctx, "telegram-1", at)
+		store.UpsertUserLastCreatedSession(ctx, "telegram-2", at.Add(time.Hour))
+
+		users, err := store.ListUsers(ctx)
+		if err != nil || len(users) != 2 {
+			t.Fatalf(`%s: ListUsers() = %v, %v, want 2 users`, name, users, err)
+		}
+		if users[0].Id != "telegram-1" || users[0].Timezone != "Europe/London" || !users[0].LastCreatedSession.Equal(at) {
+			t.Fatalf(`%s: ListUsers()[0] = %v, want telegram-1 in Europe/London`, name, users[0])
+		}
+		if users[1].Id != "telegram-2" || users[1].Timezone != "" {
+			t.Fatalf(`%s: ListUsers()[1] = %v, want telegram-2 without timezone`, name, users[1])
+		}
+	}
+}
+
+// TestQueryUsers upserts users in several timezones and with their own reminder hour, checking
+// QueryUsers returns those in the queried timezones, "" matching users who never set one.
+func TestQueryUsers(t *testing.T) {
+	ctx := context.Background()
+	at := time.Date(2024, 5, 20, 14, 0, 0, 0, time.UTC)
+	hour := 15
+
+	ids := func(users []storage.User) []string {
+		var ids []string
+		for _, user := range users {
+			ids = append(ids, user.Id)
+		}
+		return ids
+	}
+
+	for name, store := range newStores(t) {
+		store.UpsertUserTimezone(ctx, "telegram-1", "Europe/London")
+		store.UpsertUserTimezone(ctx, "telegram-2", "Asia/Singapore")
+		store.UpsertUserSettings(ctx, "telegram-2", &storage.Settings{Reminders: true, ReminderHour: hour})
+		store.UpsertUserLastCreatedSession(ctx, "telegram-3", at)
+		store.UpsertUserTimezone(ctx, "telegram-4", "Europe/London")
+		store.UpsertUserSettings(ctx, "telegram-4", &storage.Settings{Reminders: true, ReminderHour: 8})
+
+		if got, err := store.ListTimezones(ctx); err != nil || !reflect.DeepEqual(got, []string{"Asia/Singapore", "Europe/London"}) {
+			t.Fatalf(`%s: ListTimezones() = %v, %v, want the 2 timezones set`, name, got, err)
+		}
+
+		tests := []struct {
+			query storage.UserQuery
+			want  []string
+		}{
+			{storage.UserQuery{Timezones: []string{"Europe/London", ""}}, []string{"telegram-1", "telegram-3", "telegram-4"}},
+			{storage.UserQuery{Timezones: []string{"Asia/Singapore"}}, []string{"telegram-2"}},
+			{storage.UserQuery{Timezones: []string{"Asia/Singapore", "Europe/London", ""}, ReminderHour: &hour}, []string{"telegram-2"}},
+			{storage.UserQuery{}, nil},
+		}
+		for _, test := range tests {
+			got, err := store.QueryUsers(ctx, test.query)
+			if err != nil || !reflect.DeepEqual(ids(got), test.want) {
+				t.Errorf(`%s: QueryUsers(%+v) = %v, %v, want %v`, name, test.query, ids(got), err, test.want)
+			}
+		}
+	}
+}
+
+// TestUpsertUserSettings upserts settings and the chat app language of a user,
+// checking GetUser returns both and settings are replaced as a whole.
+func TestUpsertUserSettings(t *testing.T) {
+	ctx := context.Background()
+
+	for name, store := range newStores(t) {
+		store.UpsertUserSettings(ctx, "telegram-1", &storage.Settings{Reminders: true, ReminderHour: 21, Language: "es"})
+		store.UpsertUserSettings(ctx, "telegram-1", &storage.Settings{ReminderHour: 7, Persona: "stoic"})
+		store.UpsertUserLanguageCode(ctx, "telegram-1", "pt-br")
+
+		user, err := store.GetUser(ctx, "telegram-1")
+		if err != nil || user.Settings == nil || user.LanguageCode != "pt-br" {
+			t.Fatalf(`%s: GetUser("telegram-1") = %+v, %v, want settings and language code`, name, user, err)
+		}
+		if want := (storage.Settings{ReminderHour: 7, Persona: "stoic"}); *user.Settings != want {
+			t.Fatalf(`%s: settings = %+v, want %+v`, name, *user.Settings, want)
+		}
+	}
+}
+
+// TestListEntries saves entries out of order and overwrites one, checking
+// ListEntries returns them oldest first and respects the limit.
+func TestListEntries(t *testing.T) {
+	ctx := context.Background()
+	day := time.Date(2024, 5, 20, 20, 0, 0, 0, time.UTC)
+
+	for name, store := range newStores(t) {
+		for _, offset := range []int{2, 0, 1} {
+			createdAt := day.AddDate(0, 0, offset)
+			store.SaveEntry(ctx, "telegram-1", &storage.Entry{
+				Id:        createdAt.Format("2006-01-02"),
+				Summary:   "draft",
+				Mood:      []string{"neutral"},
+				CreatedAt: createdAt,
+			})
+		}
+		store.SaveEntry(ctx, "telegram-1", &storage.Entry{
+			Id:        "2024-05-20",
+			Summary:   "You went for a run.",
+			Mood:      []string{"happy"},
+			CreatedAt: day,
+		})
+
+		entries, err := store.ListEntries(ctx, "telegram-1", 2)
+		if err != nil || len(entries) != 2 {
+			t.Fatalf(`%s: ListEntries() = %v, %v, want 2 entries`, name, entries, err)
+		}
+		if entries[0].Id != "2024-05-20" || entries[0].Summary != "You went for a run." || entries[1].Id != "2024-05-21" {
+			t.Fatalf(`%s: ListEntries() = %v, want 2024-05-20 (overwritten), 2024-05-21`, name, entries)
+		}
+	}
+}
+
+// TestSessionRoundTrip saves, retrieves and deletes a chat session, checking
+// that history and journaling day are preserved.
+func TestSessionRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	session := &storage.Session{
+		Day: "2024-05-20",
+		History: []storage.Message{
+			{Role: "user", Parts: []storage.Part{{Text: "hi journie"}}},
+			{Role: "model", Parts: []storage.Part{{Text: "Hi there!"}}},
+		},
+		CreatedAt: time.Date(2024, 5, 20, 21, 0, 0, 0, time.UTC),
+		UpdatedAt: time.Date(2024, 5, 20, 21, 5, 0, 0, time.UTC),
+	}
+
+	for name, store := range newStores(t) {
+		if err := store.SaveSession(ctx, "telegram-1", session); err != nil {
+			t.Fatalf(`%s: SaveSession() = %v, want nil`, name, err)
+		}
+
+		got, err := store.GetSession(ctx, "telegram-1")
+		if err != nil || got.Day != session.Day || !reflect.DeepEqual(got.History, session.History) {
+			t.Fatalf(`%s: GetSession() = %v, %v, want %v, nil`, name, got, err, session)
+		}
+
+		store.DeleteSession(ctx, "telegram-1")
+		if _, err := store.GetSession(ctx, "telegram-1"); err != storage.ErrNotFound {
+			t.Fatalf(`%s: GetSession() after delete = %v, want ErrNotFound`, name, err)
+		}
+	}
+}
+
+// TestQueryEntries pages through entries by date in both directions, checking
+// bounds are exclusive and ordering follows Descending.
+func TestQueryEntries(t *testing.T) {
+	ctx := context.Background()
+
+	for name, store := range newStores(t) {
+		for _, id := range []string{"2024-05-18", "2024-05-20", "2024-05-19", "2024-05-21"} {
+			createdAt, _ := time.Parse("2006-01-02", id)
+			store.SaveEntry(ctx, "telegram-1", &storage.Entry{Id: id, CreatedAt: createdAt})
+		}
+
+		older, err := store.QueryEntries(ctx, "telegram-1", storage.EntryQuery{Before: "2024-05-20", Descending: true, Limit: 1})
+		if err != nil || len(older) != 1 || older[0].Id != "2024-05-19" {
+			t.Fatalf(`%s: QueryEntries(before 2024-05-20) = %v, %v, want [2024-05-19]`, name, older, err)
+		}
+
+		newer, err := store.QueryEntries(ctx, "telegram-1", storage.EntryQuery{After: "2024-05-18", Before: "2024-05-21"})
+		if err != nil || len(newer) != 2 || newer[0].Id != "2024-05-19" || newer[1].Id != "2024-05-20" {
+			t.Fatalf(`%s: QueryEntries(after 2024-05-18, before 2024-05-21) = %v, %v, want [2024-05-19 2024-05-20]`, name, newer, err)
+		}
+	}
+}
+
+// TestEncryptedStore saves an entry and session through an EncryptedStore,
+// checking the wrapped store only sees ciphertext and reads decrypt transparently.
+func TestEncryptedStore(t *testing.T) {
+	ctx := context.Background()
+	masterKey, _ := secrets.GenerateKey()
+	inner := storage.NewMemoryStore()
+	store := storage.NewEncryptedStore(inner, secrets.NewKeyring(masterKey))
+
+	entry := &storage.Entry{Id: "2024-05-20", Summary: "You went for a run.", Mood: []string{"happy"}, CreatedAt: time.Now(), Embedding: []float32{0.6, 0.8}}
+	if err := store.SaveEntry(ctx, "telegram-1", entry); err != nil {
+		t.Fatalf(`SaveEntry() = %v, want nil`, err)
+	}
+	store.SaveSession(ctx, "telegram-1", &storage.Session{Day: "2024-05-21", History: []storage.Message{{Role: "user", Parts: []storage.Part{{Text: "hi"}}}}})
+
+	raw, _ := inner.ListEntries(ctx, "telegram-1", 0)
+	if raw[0].Summary != "" || raw[0].Mood != nil || raw[0].Embedding != nil || raw[0].Ciphertext == "" {
+		t.Fatalf(`wrapped ListEntries() = %v, want only ciphertext`, raw)
+	}
+	rawSession, _ := inner.GetSession(ctx, "telegram-1")
+	if rawSession.History != nil || rawSession.Ciphertext == "" {
+		t.Fatalf(`wrapped GetSession() = %v, want only ciphertext`, rawSession)
+	}
+
+	entries, err := store.QueryEntries(ctx, "telegram-1", storage.EntryQuery{})
+	if err != nil || entries[0].Summary != entry.Summary || !reflect.DeepEqual(entries[0].Mood, entry.Mood) || !reflect.DeepEqual(entries[0].Embedding, entry.Embedding) {
+		t.Fatalf(`QueryEntries() = %v, %v, want decrypted %v`, entries, err, entry)
+	}
+	session, err := store.GetSession(ctx, "telegram-1")
+	if err != nil || session.History[0].Parts[0].Text != "hi" {
+		t.Fatalf(`GetSession() = %v, %v, want decrypted history`, session, err)
+	}
+}
+
+// TestCreateUserDataKey creates data keys of a new user and of one with other fields set,
+// checking the first key stored is kept and returned to later attempts.
+func TestCreateUserDataKey(t *testing.T) {
+	ctx := context.Background()
+
+	for name, store := range newStores(t) {
+		store.UpsertUserTimezone(ctx, "telegram-2", "Europe/London")
+
+		for _, userId := range []string{"telegram-1", "telegram-2"} {
+			if stored, err := store.CreateUserDataKey(ctx, userId, "first"); err != nil || stored != "first" {
+				t.Fatalf(`%s: CreateUserDataKey(%s, first) = %q, %v, want first`, name, userId, stored, err)
+			}
+			if stored, err := store.CreateUserDataKey(ctx, userId, "second"); err != nil || stored != "first" {
+				t.Fatalf(`%s: CreateUserDataKey(%s, second) = %q, %v, want first kept`, name, userId, stored, err)
+			}
+		}
+
+		if user, err := store.GetUser(ctx, "telegram-2"); err != nil || user.DataKey != "first" || user.Timezone != "Europe/London" {
+			t.Fatalf(`%s: GetUser() = %+v, %v, want data key added to the user`, name, user, err)
+		}
+	}
+}
+
+// slowStore takes a while to read users, as a remote store does
+type slowStore struct {
+	*storage.MemoryStore
+}
+
+func (s slowStore) GetUser(ctx context.Context, userId string) (*storage.User, error) {
+	user, err := s.MemoryStore.GetUser(ctx, userId)
+	time.Sleep(10 * time.Millisecond)
+	return user, err
+}
+
+// TestEncryptedStoreConcurrentKeys saves entries of a new user through two instances at once,
+// checking both seal with the same data key so every entry can be read.
+func TestEncryptedStoreConcurrentKeys(t *testing.T) {
+	ctx := context.Background()
+	masterKey, _ := secrets.GenerateKey()
+	inner := slowStore{storage.NewMemoryStore()}
+	instances := []*storage.EncryptedStore{
+		storage.NewEncryptedStore(inner, secrets.NewKeyring(masterKey)),
+		storage.NewEncryptedStore(inner, secrets.NewKeyring(masterKey)),
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			entry := &storage.Entry{Id: fmt.Sprintf("2024-05-%02d", i+1), Summary: "You went for a run.", CreatedAt: time.Now()}
+			if err := instances[i%2].SaveEntry(ctx, "telegram-1", entry); err != nil {
+				t.Errorf(`SaveEntry() = %v, want nil`, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i, instance := range instances {
+		entries, err := instance.ListEntries(ctx, "telegram-1", 0)
+		if err != nil || len(entries) != 8 {
+			t.Fatalf(`instance %d: ListEntries() = %d entries, %v, want 8 decrypted`, i, len(entries), err)
+		}
+	}
+}
+
+// TestRotateMasterKey rotates to a new master key, checking data keys are
+// re-wrapped and entries stay readable with only the new key.
+func TestRotateMasterKey(t *testing.T) {
+	ctx := context.Background()
+	oldKey, _ := secrets.GenerateKey()
+	newKey, _ := secrets.GenerateKey()
+	inner := storage.NewMemoryStore()
+
+	storage.NewEncryptedStore(inner, secrets.NewKeyring(oldKey)).SaveEntry(ctx, "telegram-1", &storage.Entry{Id: "2024-05-20", Summary: "You went for a run."})
+
+	rotated, err := storage.RotateMasterKey(ctx, inner, secrets.NewKeyring(newKey, oldKey))
+	if rotated != 1 || err != nil {
+		t.Fatalf(`RotateMasterKey() = %d, %v, want 1, nil`, rotated, err)
+	}
+
+	entries, err := storage.NewEncryptedStore(inner, secrets.NewKeyring(newKey)).ListEntries(ctx, "telegram-1", 0)
+	if err != nil || entries[0].Summary != "You went for a run." {
+		t.Fatalf(`ListEntries() after rotation = %v, %v, want decrypted entry`, entries, err)
+	}
+}
+
+// TestJobRoundTrip saves the state of a scheduled job, checking it is read back
+// and unknown jobs are not found.
+func TestJobRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	at := time.Date(2024, 5, 20, 10, 0, 0, 0, time.UTC)
+
+	for name, store := range newStores(t) {
+		if job, err := store.GetJob(ctx, "remind"); job != nil || err != storage.ErrNotFound {
+			t.Fatalf(`%s: GetJob("remind") = %v, %v, want nil, ErrNotFound`, name, job, err)
+		}
+
+		store.SaveJob(ctx, &storage.Job{Name: "remind", LastRun: at})
+		store.SaveJob(ctx, &storage.Job{Name: "remind", LastRun: at.Add(time.Hour), Pending: []time.Time{at}})
+
+		job, err := store.GetJob(ctx, "remind")
+		if err != nil || job.Name != "remind" || !job.LastRun.Equal(at.Add(time.Hour)) {
+			t.Fatalf(`%s: GetJob("remind") = %v, %v, want last run at 11:00`, name, job, err)
+		}
+		if len(job.Pending) != 1 || !job.Pending[0].Equal(at) {
+			t.Fatalf(`%s: GetJob("remind").Pending = %v, want [10:00]`, name, job.Pending)
+		}
+	}
+}
+
+// TestJobRunRoundTrip saves a run record, adds handled users and saves it again,
+// checking users are kept and recorded once.
+func TestJobRunRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	at := time.Date(2024, 5, 20, 22, 0, 0, 0, time.UTC)
+	id := storage.JobRunId("remind", at)
+
+	for name, store := range newStores(t) {
+		if id != "remind@2024-05-20T22:00Z" {
+			t.Fatalf(`JobRunId() = %q, want "remind@2024-05-20T22:00Z"`, id)
+		}
+		if run, err := store.GetJobRun(ctx, id); run != nil || err != storage.ErrNotFound {
+			t.Fatalf(`%s: GetJobRun(%q) = %v, %v, want nil, ErrNotFound`, name, id, run, err)
+		}
+
+		run := &storage.JobRun{Id: id, Job: "remind", ScheduledAt: at, Status: storage.JobRunStarted, Attempts: 1, StartedAt: at, UpdatedAt: at}
+		store.SaveJobRun(ctx, run)
+		store.AddJobRunUser(ctx, id, "telegram-1", at.Add(time.Minute))
+		store.AddJobRunUser(ctx, id, "telegram-1", at.Add(time.Minute))
+		store.AddJobRunUser(ctx, id, "telegram-2", at.Add(2*time.Minute))
+
+		run.Status = storage.JobRunFailed
+		run.Error = "1 of 3 reminders failed"
+		run.UpdatedAt = at.Add(3 * time.Minute)
+		store.SaveJobRun(ctx, run)
+
+		got, err := store.GetJobRun(ctx, id)
+		if err != nil || got.Status != storage.JobRunFailed || got.Error != run.Error || !got.ScheduledAt.Equal(at) || !got.UpdatedAt.Equal(run.UpdatedAt) {
+			t.Fatalf(`%s: GetJobRun(%q) = %+v, %v, want failed run`, name, id, got, err)
+		}
+		if !reflect.DeepEqual(got.Users, []string{"telegram-1", "telegram-2"}) {
+			t.Fatalf(`%s: GetJobRun(%q).Users = %v, want [telegram-1 telegram-2]`, name, id, got.Users)
+		}
+	}
+}
+
+// TestAcquireJobRun acquires a run from concurrent callers, checking only one starts an attempt,
+// and that the run can be acquired again once the lease expires but not once completed.
+func TestAcquireJobRun(t *testing.T) {
+	ctx := context.Background()
+	at := time.Date(2024, 5, 20, 14, 0, 0, 0, time.UTC)
+	lease := 10 * time.Minute
+
+	for name, store := range newStores(t) {
+		run := &storage.JobRun{Id: storage.JobRunId("remind", at), Job: "remind", ScheduledAt: at}
+
+		var (
+			wg       sync.WaitGroup
+			acquired atomic.Int32
+		)
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, ok, err := store.AcquireJobRun(ctx, run, at, lease); err != nil {
+					t.Errorf(`%s: AcquireJobRun() = %v, want nil`, name, err)
+				} else if ok {
+					acquired.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+		if got := acquired.Load(); got != 1 {
+			t.Fatalf(`%s: %d concurrent AcquireJobRun() started an attempt, want 1`, name, got)
+		}
+
+		store.AddJobRunUser(ctx, run.Id, "telegram-1", at.Add(time.Minute))
+		if _, ok, _ := store.AcquireJobRun(ctx, run, at.Add(5*time.Minute), lease); ok {
+			t.Fatalf(`%s: AcquireJobRun() within the lease = true, want false`, name)
+		}
+
+		stored, ok, err := store.AcquireJobRun(ctx, run, at.Add(time.Hour), lease)
+		if err != nil || !ok || stored.Attempts != 2 || stored.Status != storage.JobRunStarted || !reflect.DeepEqual(stored.Users, []string{"telegram-1"}) {
+			t.Fatalf(`%s: AcquireJobRun() after the lease = %+v, %v, %v, want attempt 2 resuming telegram-1`, name, stored, ok, err)
+		}
+
+		stored.Status = storage.JobRunCompleted
+		store.SaveJobRun(ctx, stored)
+		if stored, ok, _ := store.AcquireJobRun(ctx, run, at.Add(2*time.Hour), lease); ok || stored.Status != storage.JobRunCompleted {
+			t.Fatalf(`%s: AcquireJobRun() of completed run = %+v, %v, want not started`, name, stored, ok)
+		}
+	}
+}
+
+// TestFailedSummaries queues a failed summary and parks it as a dead letter, checking
+// it moves between the lists with its history, also when encrypted at rest.
+func TestFailedSummaries(t *testing.T) {
+	ctx := context.Background()
+	at := time.Date(2024, 5, 21, 4, 0, 0, 0, time.UTC)
+
+	stores := newStores(t)
+	masterKey, _ := secrets.GenerateKey()
+	stores["encrypted"] = storage.NewEncryptedStore(storage.NewMemoryStore(), secrets.NewKeyring(masterKey))
+
+	for name, store := range stores {
+		id := storage.FailedSummaryId("telegram-1", "2024-05-20")
+		failed := &storage.FailedSummary{
+			Id:          id,
+			UserId:      "telegram-1",
+			Day:         "2024-05-20",
+			History:     []storage.Message{{Role: "user", Parts: []storage.Part{{Text: "I went for a run"}}}},
+			Attempts:    1,
+			NextAttempt: at.Add(5 * time.Minute),
+		}
+		store.SaveFailedSummary(ctx, failed)
+
+		queued, err := store.ListFailedSummaries(ctx, false)
+		if err != nil || len(queued) != 1 || queued[0].Id != id || !reflect.DeepEqual(queued[0].History, failed.History) {
+			t.Fatalf(`%s: ListFailedSummaries(false) = %+v, %v, want queued summary with history`, name, queued, err)
+		}
+
+		failed.Attempts = 2
+		failed.Dead = true
+		store.SaveFailedSummary(ctx, failed)
+
+		queued, _ = store.ListFailedSummaries(ctx, false)
+		dead, _ := store.ListFailedSummaries(ctx, true)
+		if len(queued) != 0 || len(dead) != 1 || dead[0].Attempts != 2 {
+			t.Fatalf(`%s: ListFailedSummaries() = %+v, %+v, want 1 dead letter`, name, queued, dead)
+		}
+
+		got, err := store.GetFailedSummary(ctx, id)
+		if err != nil || !got.Dead || !reflect.DeepEqual(got.History, failed.History) {
+			t.Fatalf(`%s: GetFailedSummary(%q) = %+v, %v, want dead letter with history`, name, id, got, err)
+		}
+
+		store.DeleteFailedSummary(ctx, id)
+		if got, err := store.GetFailedSummary(ctx, id); err != storage.ErrNotFound {
+			t.Fatalf(`%s: GetFailedSummary(%q) after delete = %+v, %v, want ErrNotFound`, name, id, got, err)
+		}
+	}
+}
+
+// TestDigests saves digests of several periods, checking they are listed per period in order
+// and survive encryption.
+func TestDigests(t *testing.T) {
+	ctx := context.Background()
+
+	stores := newStores(t)
+	masterKey, _ := secrets.GenerateKey()
+	stores["encrypted"] = storage.NewEncryptedStore(storage.NewMemoryStore(), secrets.NewKeyring(masterKey))
+
+	for name, store := range stores {
+		for _, id := range []string{"week-2024-W21", "month-2024-05", "week-2024-W20"} {
+			period, _, _ := strings.Cut(id, "-")
+			store.SaveDigest(ctx, "telegram-1", &storage.Digest{
+				Id:         id,
+				Period:     period,
+				Summary:    "You ran a lot.",
+				Highlights: []string{"a 10k run"},
+				Moods:      map[string]int{"happy": 3},
+			})
+		}
+
+		weeks, err := store.ListDigests(ctx, "telegram-1", storage.Weekly)
+		if err != nil || len(weeks) != 2 || weeks[0].Id != "week-2024-W20" || weeks[1].Id != "week-2024-W21" {
+			t.Fatalf(`%s: ListDigests(week) = %+v, %v, want W20 and W21`, name, weeks, err)
+		}
+		if weeks[0].Summary != "You ran a lot." || !reflect.DeepEqual(weeks[0].Moods, map[string]int{"happy": 3}) {
+			t.Fatalf(`%s: ListDigests(week)[0] = %+v, want saved digest`, name, weeks[0])
+		}
+
+		if months, _ := store.ListDigests(ctx, "telegram-1", storage.Monthly); len(months) != 1 {
+			t.Fatalf(`%s: ListDigests(month) = %+v, want 1`, name, months)
+		}
+		if other, _ := store.ListDigests(ctx, "telegram-2", storage.Weekly); len(other) != 0 {
+			t.Fatalf(`%s: ListDigests() of another user = %+v, want none`, name, other)
+		}
+	}
+}
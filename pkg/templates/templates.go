@@ -1,52 +1,330 @@
 package templates
 
-import "fmt"
+import (
+	"fmt"
+	"journie/pkg/i18n"
+	"journie/pkg/personas"
+	"journie/pkg/storage"
+	"journie/pkg/users"
+	"strings"
+	"time"
+)
 
-const GeminiKeyInstructions = `*Get Your Gemini API Key (Desktop Required for Now)*
+// Messages below are written in lang, a language code of i18n, falling back to English
 
-*Heads up!* Currently, creating an API key can only be done on a desktop computer using Google AI Studio. Let's get you set up in a few easy steps:
+// Errors shown to users when a request fails, see Error
+const (
+	ErrorUser       = "error.user"
+	ErrorRequest    = "error.request"
+	ErrorCreateChat = "error.createChat"
+	ErrorGetChat    = "error.getChat"
+	ErrorDeleteChat = "error.deleteChat"
+	ErrorAnalysis   = "error.analysis"
+	ErrorEntries    = "error.entries"
+	ErrorExport     = "error.export"
+	ErrorChart      = "error.chart"
+	ErrorPhoto      = "error.photo"
+	ErrorVoice      = "error.voice"
+	ErrorTranscribe = "error.transcribe"
+	ErrorSaveKey    = "error.saveKey"
+	ErrorRemoveKey  = "error.removeKey"
+)
 
-	1. *Head to Google AI Studio:*  Open your favorite web browser on your desktop and visit this link: [https://aistudio.google.com/app/apikey](https://aistudio.google.com/app/apikey)
+// Error returns the error message of key, one of the Error constants
+func Error(lang string, key string) string {
+	return i18n.T(lang, key)
+}
 
-	2. *Sign in to Google (if needed):* You might be prompted to sign in with your Google account. Go ahead and do that!
+func GeminiKeyInstructions(lang string) string {
+	return i18n.T(lang, "gemini.instructions")
+}
 
-	3. *Choose "Get API key":*  Once you're signed in, you might see a screen with options like "New Project" or "Get API key." Choose *Get API key*.
+func GeminiKeyReason(lang string) string {
+	return i18n.T(lang, "gemini.reason")
+}
 
-	4. *Create a new project:*  Click on *"Create API key in new project"*. This will set up a new project for your Journie API key.
+func GeminiKeyWhyButton(lang string) string {
+	return i18n.T(lang, "button.geminiWhy")
+}
 
-	5. *Copy your API key:*  A new window will pop up showing your API key. *Copy* this key to your clipboard.
+// WelcomeMessageSharedApiKey is written in MarkdownV2, username is escaped
+func WelcomeMessageSharedApiKey(lang string, username string) string {
+	return i18n.Markdown(lang, "welcome", username)
+}
+
+func Reminder(lang string) string {
+	return i18n.T(lang, "reminder")
+}
+
+func VideoUnsupported(lang string) string {
+	return i18n.T(lang, "video.unsupported")
+}
+
+func ChatSessionDeleted(lang string) string {
+	return i18n.T(lang, "session.deleted")
+}
+
+func TimezonePrompt(lang string, defaultTimezone string) string {
+	return i18n.T(lang, "timezone.prompt", defaultTimezone)
+}
 
-	6. *Send your key and say Hi!:*  Come back to Journie and send the key into the chat. Once that's done, simply say *"Hi"* and we can start your journaling adventure!
+func TimezoneCurrent(lang string, timezone string) string {
+	return i18n.T(lang, "timezone.current", timezone)
+}
 
-`
+func TimezoneUpdated(lang string, timezone string, localTime string) string {
+	return i18n.T(lang, "timezone.updated", timezone, localTime)
+}
 
-const GeminiKeyReason = `*Don't worry, creating an API key is safe and free!*
+func TimezoneInvalid(lang string, timezone string) string {
+	return i18n.T(lang, "timezone.invalid", timezone)
+}
 
-We understand you might be hesitant to create an API key. Here's why this step is secure and important for Journie:
+func SettingsMenu(lang string) string {
+	return i18n.T(lang, "settings.menu")
+}
 
-	· *Security:*  This process happens entirely within Google AI Studio, a secure platform from Google. We never ask for your payment information, and the API key itself doesn't grant access to any sensitive data.
+func SettingOptions(lang string, setting string) string {
+	return i18n.T(lang, "settings.options", setting)
+}
 
-	· *Improved experience:*  The API key helps Journie identify you uniquely and avoid any rate limits. This means you'll get smoother interactions and faster replies without interruption. 
+func SettingSaved(lang string) string {
+	return i18n.T(lang, "settings.saved")
+}
 
-	· *Keeps Journie free:*  API keys help us prevent abuse from spammers and bots. These automated programs can send a lot of requests, which can be expensive for us to maintain. By limiting access with API keys, we can keep Journie free for everyone.
+func SettingInvalid(lang string) string {
+	return i18n.T(lang, "settings.invalid")
+}
 
-Think of the API key as a handshake that allows Journie to leverage Google Gemini's power to offer this personalized journaling experience.
+// SettingLabel names the setting key on buttons and above its options
+func SettingLabel(lang string, key string) string {
+	return i18n.T(lang, "setting."+key)
+}
 
-Ready to get started? Let's head to Google AI Studio!
+// SettingToggle shows whether a setting that is on or off, such as reminders, is on
+func SettingToggle(lang string, on bool) string {
+	if on {
+		return i18n.T(lang, "settings.on")
+	}
+	return i18n.T(lang, "settings.off")
+}
 
-`
+// OptionLabel shows option of the setting key, options without a message, such as the
+// languages named in their own language, keep their label
+func OptionLabel(lang string, key string, option users.Option) string {
+	value := option.Value
+	if value == "" {
+		value = "auto"
+	}
+	if message, ok := i18n.Find(lang, "option."+key+"."+value); ok {
+		return message.Other
+	}
+	return option.Label
+}
 
-func WelcomeMessageSharedApiKey(username string) string {
+// PersonaName names persona in lang, operator personas missing from the catalogs keep their name
+func PersonaName(lang string, persona personas.Persona) string {
+	return OptionLabel(lang, users.SettingPersona, users.Option{Value: persona.Key, Label: persona.Name})
+}
 
-	const template = `
-Hi there %s\!
-	
-Welcome to Journie, your private and engaging journaling companion\. 
+// PersonaMenu shows the current persona and describes each of all
+func PersonaMenu(lang string, current personas.Persona, all []personas.Persona) string {
+	lines := make([]string, len(all))
+	for i, persona := range all {
+		description := persona.Description
+		if message, ok := i18n.Find(lang, "description.persona."+persona.Key); ok {
+			description = message.Other
+		}
+		lines[i] = fmt.Sprintf("• %s: %s", PersonaName(lang, persona), description)
+	}
+	return i18n.T(lang, "persona.menu", PersonaName(lang, current), strings.Join(lines, "\n"))
+}
 
-Here, you can chat with a friendly genie who remembers your past entries and helps you explore your thoughts and feelings\.
-	
-Simply say *Hi* and we can begin\!
-	`
+func PersonaUpdated(lang string, persona personas.Persona) string {
+	return i18n.T(lang, "persona.updated", PersonaName(lang, persona))
+}
 
-	return fmt.Sprintf(template, username)
+func PersonaInvalid(lang string, name string, all []personas.Persona) string {
+	names := make([]string, len(all))
+	for i, persona := range all {
+		names[i] = PersonaName(lang, persona)
+	}
+	return i18n.T(lang, "persona.invalid", name, strings.Join(names, ", "))
 }
+
+func BackButton(lang string) string {
+	return i18n.T(lang, "button.back")
+}
+
+func ExportEmpty(lang string) string {
+	return i18n.T(lang, "export.empty")
+}
+
+func ExportUsage(lang string, formats []string) string {
+	return i18n.T(lang, "export.usage", strings.Join(formats, "|"), formats[0])
+}
+
+func ExportCaption(lang string, count int) string {
+	return i18n.N(lang, "export.caption", count)
+}
+
+func HistoryEmpty(lang string) string {
+	return i18n.T(lang, "history.empty")
+}
+
+func HistoryNoNewer(lang string) string {
+	return i18n.T(lang, "history.noNewer")
+}
+
+func HistoryNoOlder(lang string) string {
+	return i18n.T(lang, "history.noOlder")
+}
+
+func HistoryJumpPrompt(lang string) string {
+	return i18n.T(lang, "history.jumpPrompt")
+}
+
+func HistoryNewerButton(lang string) string {
+	return i18n.T(lang, "button.newer")
+}
+
+func HistoryOlderButton(lang string) string {
+	return i18n.T(lang, "button.older")
+}
+
+func HistoryJumpButton(lang string) string {
+	return i18n.T(lang, "button.jump")
+}
+
+func HistoryEntry(lang string, entry *storage.Entry) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "📔 %s\n", entry.Id)
+	if len(entry.Mood) != 0 {
+		moods := make([]string, len(entry.Mood))
+		for i, mood := range entry.Mood {
+			moods[i] = Mood(lang, mood)
+		}
+		fmt.Fprintf(&b, "%s\n", i18n.T(lang, "history.mood", strings.Join(moods, ", ")))
+	}
+	if len(entry.Photos) != 0 {
+		fmt.Fprintf(&b, "%s\n", i18n.N(lang, "history.photos", len(entry.Photos)))
+	}
+	fmt.Fprintf(&b, "\n%s", entry.Summary)
+
+	return b.String()
+}
+
+func HistoryInvalidDate(lang string, date string) string {
+	return i18n.T(lang, "history.invalidDate", date)
+}
+
+func HistoryNoneBefore(lang string, date string) string {
+	return i18n.T(lang, "history.noneBefore", date)
+}
+
+// Mood names one of moods.All
+func Mood(lang string, mood string) string {
+	if message, ok := i18n.Find(lang, "mood."+mood); ok {
+		return message.Other
+	}
+	return mood
+}
+
+// period names one of charts.Periods
+func period(lang string, period string) string {
+	return i18n.T(lang, "period."+period)
+}
+
+func MoodUsage(lang string, periods []string) string {
+	return i18n.T(lang, "mood.usage", strings.Join(periods, "|"), periods[0])
+}
+
+func MoodEmpty(lang string, p string) string {
+	return i18n.T(lang, "mood.empty", period(lang, p))
+}
+
+// MoodChartTitle stays in English, the chart font only covers ASCII
+func MoodChartTitle(period string, today string) string {
+	return fmt.Sprintf("Your moods over the past %s, up to %s", period, today)
+}
+
+func MoodCaption(lang string, p string, count int) string {
+	return i18n.N(lang, "mood.caption", count, period(lang, p))
+}
+
+func GeminiKeySaved(lang string) string {
+	return i18n.T(lang, "gemini.saved")
+}
+
+func GeminiKeyRemoved(lang string) string {
+	return i18n.T(lang, "gemini.removed")
+}
+
+func GeminiKeyInvalid(lang string) string {
+	return i18n.T(lang, "gemini.invalid")
+}
+
+func GeminiKeyNotFound(lang string) string {
+	return i18n.T(lang, "gemini.notFound")
+}
+
+func GeminiKeyUnavailable(lang string) string {
+	return i18n.T(lang, "gemini.unavailable")
+}
+
+func GeminiKeyRejected(lang string) string {
+	return i18n.T(lang, "gemini.rejected")
+}
+
+// GeminiKeyForbidden tells a user Gemini refused a request with their key for another reason than the key itself
+func GeminiKeyForbidden(lang string) string {
+	return i18n.T(lang, "gemini.forbidden")
+}
+
+func SharedQuotaExhausted(lang string) string {
+	return i18n.T(lang, "quota.shared")
+}
+
+// RateLimited asks a user sending messages too quickly to slow down
+func RateLimited(lang string, wait time.Duration) string {
+	if wait < time.Minute {
+		return i18n.N(lang, "limit.seconds", int(wait.Seconds())+1)
+	}
+	return i18n.N(lang, "limit.minutes", int(wait.Minutes())+1)
+}
+
+// DailyLimitReached tells a user they can continue at retryAt, in their local time
+func DailyLimitReached(lang string, retryAt time.Time, ownKey bool) string {
+	message := i18n.T(lang, "limit.daily", retryAt.Format("15:04"))
+	if !ownKey {
+		message += " " + i18n.T(lang, "limit.dailyOwnKey")
+	}
+	return message
+}
+
+func VoiceTranscript(lang string, transcript string) string {
+	return i18n.T(lang, "voice.transcript", transcript)
+}
+
+func VoiceEmpty(lang string) string {
+	return i18n.T(lang, "voice.empty")
+}
+
+func VoiceTooLong(lang string) string {
+	return i18n.T(lang, "voice.tooLong")
+}
+
+func VoiceUnavailable(lang string) string {
+	return i18n.T(lang, "voice.unavailable")
+}
+
+func SafetyPromptBlocked(lang string) string {
+	return i18n.T(lang, "safety.prompt")
+}
+
+func SafetyResponseBlocked(lang string) string {
+	return i18n.T(lang, "safety.response")
+}
+
+const ReplyPlaceholder = "✍️ …"
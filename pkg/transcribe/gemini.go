@@ -0,0 +1,36 @@
+package transcribe
+
+import (
+	"context"
+	"journie/pkg/generative"
+	"strings"
+)
+
+// transcribePrompt asks for the words only, so the transcript reads as if the user typed it
+const transcribePrompt = "Transcribe this voice note verbatim in the language it is spoken. Respond with the transcript only, without commentary or timestamps. If there is no speech, respond with nothing."
+
+// GeminiTranscriber sends audio inline to a multimodal provider
+type GeminiTranscriber struct {
+	provider generative.Provider
+}
+
+func NewGeminiTranscriber(provider generative.Provider) *GeminiTranscriber {
+	return &GeminiTranscriber{provider: provider}
+}
+
+func (t *GeminiTranscriber) Transcribe(ctx context.Context, audio []byte, mimeType string) (string, error) {
+	resp, err := t.provider.Generate(ctx, nil,
+		generative.Text(transcribePrompt),
+		generative.Part{MIMEType: mimeType, Data: audio},
+	)
+	if err != nil {
+		return "", err
+	}
+
+	transcript := strings.TrimSpace(resp.Text)
+	if transcript == "" {
+		return "", ErrEmptyTranscript
+	}
+
+	return transcript, nil
+}
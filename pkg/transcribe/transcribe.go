@@ -0,0 +1,60 @@
+package transcribe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"journie/pkg/generative"
+	"log"
+	"os"
+	"strings"
+)
+
+const (
+	Gemini  string = "gemini"
+	Whisper string = "whisper"
+	None    string = "none"
+)
+
+// ErrEmptyTranscript is returned when no speech was recognised in the audio
+var ErrEmptyTranscript = errors.New("transcribe: no speech recognised")
+
+// Transcriber turns a voice note into text, selected with TRANSCRIBER env
+type Transcriber interface {
+	Transcribe(ctx context.Context, audio []byte, mimeType string) (string, error)
+}
+
+// TranscriberClient is nil when voice notes are disabled
+var TranscriberClient Transcriber
+
+func Init(ctx context.Context) error {
+	transcriber, err := New(ctx, os.Getenv("TRANSCRIBER"))
+	if err != nil {
+		return err
+	}
+
+	TranscriberClient = transcriber
+	if transcriber == nil {
+		log.Println("transcriber disabled, voice notes will not be journaled")
+	}
+
+	return nil
+}
+
+// New creates the transcriber called name from its env config, defaulting to gemini
+func New(ctx context.Context, name string) (Transcriber, error) {
+	switch strings.ToLower(name) {
+	case "", Gemini:
+		provider, err := generative.NewGeminiProvider(ctx, os.Getenv("GEMINI_API_KEY"), os.Getenv("GEMINI_MODEL"))
+		if err != nil {
+			return nil, err
+		}
+		return NewGeminiTranscriber(provider), nil
+	case Whisper:
+		return NewWhisperTranscriber(os.Getenv("WHISPER_URL"), os.Getenv("WHISPER_API_KEY"), os.Getenv("WHISPER_MODEL")), nil
+	case None:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unsupported transcriber %q", name)
+	}
+}
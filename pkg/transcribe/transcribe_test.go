@@ -0,0 +1,50 @@
+package transcribe_test
+
+import (
+	"context"
+	"errors"
+	"journie/pkg/generative"
+	"journie/pkg/transcribe"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestWhisperTranscriber posts a voice note to a stub whisper server,
+// checking the audio is uploaded as a file and the transcript is returned.
+func TestWhisperTranscriber(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, header, err := r.FormFile("file")
+		if err != nil || header.Filename != "voice.ogg" || r.FormValue("model") != "whisper-1" {
+			http.Error(w, "unexpected upload", http.StatusBadRequest)
+			return
+		}
+		file.Close()
+		w.Write([]byte(`{"text": " Today I finally went for a run. "}`))
+	}))
+	defer server.Close()
+
+	transcript, err := transcribe.NewWhisperTranscriber(server.URL, "", "").Transcribe(context.Background(), []byte("OggS"), "audio/ogg")
+	if err != nil || transcript != "Today I finally went for a run." {
+		t.Fatalf(`Transcribe() = %q, %v, want trimmed transcript`, transcript, err)
+	}
+}
+
+// TestGeminiTranscriber transcribes through a fake provider, checking the audio
+// is sent inline and silence is reported as ErrEmptyTranscript.
+func TestGeminiTranscriber(t *testing.T) {
+	fake := generative.NewFakeProvider("Today I went for a run.", "  ")
+	transcriber := transcribe.NewGeminiTranscriber(fake)
+
+	transcript, err := transcriber.Transcribe(context.Background(), []byte("OggS"), "audio/ogg")
+	if err != nil || transcript != "Today I went for a run." {
+		t.Fatalf(`Transcribe() = %q, %v, want scripted transcript`, transcript, err)
+	}
+	if parts := fake.Calls[0].Messages[0].Parts; len(parts) != 2 || parts[1].MIMEType != "audio/ogg" {
+		t.Fatalf(`sent parts = %v, want prompt and inline audio`, parts)
+	}
+
+	if _, err := transcriber.Transcribe(context.Background(), []byte("OggS"), "audio/ogg"); !errors.Is(err, transcribe.ErrEmptyTranscript) {
+		t.Fatalf(`Transcribe() of silence = %v, want ErrEmptyTranscript`, err)
+	}
+}
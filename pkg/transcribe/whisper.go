@@ -0,0 +1,104 @@
+package transcribe
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const defaultWhisperURL = "http://localhost:8000/v1/audio/transcriptions"
+
+var httpClient = &http.Client{Timeout: 2 * time.Minute}
+
+// WhisperTranscriber posts audio to a whisper server speaking the OpenAI transcriptions API,
+// e.g. faster-whisper-server, whisper.cpp with --convert, or OpenAI itself
+type WhisperTranscriber struct {
+	url    string
+	apiKey string
+	model  string
+}
+
+func NewWhisperTranscriber(url string, apiKey string, model string) *WhisperTranscriber {
+	if url == "" {
+		url = defaultWhisperURL
+	}
+	if model == "" {
+		model = "whisper-1"
+	}
+
+	return &WhisperTranscriber{url: url, apiKey: apiKey, model: model}
+}
+
+func (t *WhisperTranscriber) Transcribe(ctx context.Context, audio []byte, mimeType string) (string, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+
+	file, err := form.CreateFormFile("file", "voice"+extension(mimeType))
+	if err != nil {
+		return "", err
+	}
+	if _, err := file.Write(audio); err != nil {
+		return "", err
+	}
+	form.WriteField("model", t.model)
+	form.WriteField("response_format", "json")
+	if err := form.Close(); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, &body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	if t.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+t.apiKey)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return "", fmt.Errorf("whisper: status %d: %s", resp.StatusCode, data)
+	}
+
+	var result struct {
+		Text string `json:"text"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+
+	transcript := strings.TrimSpace(result.Text)
+	if transcript == "" {
+		return "", ErrEmptyTranscript
+	}
+
+	return transcript, nil
+}
+
+// extension of mimeType for the uploaded file name, servers detect the format from it
+func extension(mimeType string) string {
+	switch mimeType {
+	case "audio/ogg", "audio/opus":
+		return ".ogg"
+	case "audio/mpeg":
+		return ".mp3"
+	}
+
+	if extensions, err := mime.ExtensionsByType(mimeType); err == nil && len(extensions) > 0 {
+		return extensions[0]
+	}
+	return ".ogg"
+}
@@ -0,0 +1,209 @@
+package users
+
+import (
+	"context"
+	"fmt"
+	"journie/pkg/i18n"
+	"journie/pkg/personas"
+	"journie/pkg/storage"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// DefaultReminderHour is the local hour users are reminded at until they pick another
+const DefaultReminderHour = 22
+
+// Keys of settings, as changed by UpdateSetting
+const (
+	SettingReminders    string = "reminders"
+	SettingReminderHour string = "reminderHour"
+	SettingLanguage     string = "language"
+	SettingPersona      string = "persona"
+	SettingSummaryStyle string = "summaryStyle"
+)
+
+// Option is a value a setting can take, Label is shown to the user
+type Option struct {
+	Value string
+	Label string
+}
+
+// Languages replies can be written in, each with a message catalog.
+// An empty value follows the language of the user's chat app
+var Languages = []Option{
+	{"", "Automatic"},
+	{"en", "English"},
+	{"id", "Bahasa Indonesia"},
+	{"ms", "Bahasa Melayu"},
+	{"zh", "中文"},
+	{"ja", "日本語"},
+	{"es", "Español"},
+}
+
+// Personas Journie can take, the first is the default, see personas.All
+func Personas() []Option {
+	all := personas.All()
+	options := make([]Option, len(all))
+	for i, persona := range all {
+		options[i] = Option{persona.Key, persona.Name}
+	}
+	return options
+}
+
+// SummaryStyles daily entries can be written in, the first is the default
+var SummaryStyles = []Option{
+	{"paragraph", "Paragraph"},
+	{"bullets", "Bullet points"},
+	{"brief", "One or two sentences"},
+}
+
+// DefaultSettings are the settings of users who never changed them
+func DefaultSettings() storage.Settings {
+	return storage.Settings{
+		Reminders:    true,
+		ReminderHour: DefaultReminderHour,
+		Persona:      personas.Default().Key,
+		SummaryStyle: SummaryStyles[0].Value,
+	}
+}
+
+// SettingsOf returns the settings of user, the defaults if user is nil or never changed them.
+// Values no longer offered, e.g. a removed persona, fall back to their default
+func SettingsOf(user *storage.User) storage.Settings {
+	defaults := DefaultSettings()
+	if user == nil || user.Settings == nil {
+		return defaults
+	}
+
+	settings := *user.Settings
+	if _, ok := OptionLabel(Languages, settings.Language); !ok {
+		settings.Language = defaults.Language
+	}
+	if _, ok := OptionLabel(Personas(), settings.Persona); !ok {
+		settings.Persona = defaults.Persona
+	}
+	if _, ok := OptionLabel(SummaryStyles, settings.SummaryStyle); !ok {
+		settings.SummaryStyle = defaults.SummaryStyle
+	}
+	if settings.ReminderHour < 0 || settings.ReminderHour > 23 {
+		settings.ReminderHour = defaults.ReminderHour
+	}
+
+	return settings
+}
+
+// GetSettings retrieves user and returns their settings
+func GetSettings(ctx context.Context, userId string) storage.Settings {
+	user, err := storage.StoreClient.GetUser(ctx, userId)
+	if err != nil && err != storage.ErrNotFound {
+		log.Printf("Error retrieving user %s: %v", userId, err)
+	}
+
+	return SettingsOf(user)
+}
+
+// UpdateSetting validates value of the setting key and stores it for user, returning the updated settings
+func UpdateSetting(ctx context.Context, userId string, key string, value string) (*storage.Settings, error) {
+	settings := GetSettings(ctx, userId)
+
+	switch key {
+	case SettingReminders:
+		on, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q", key, value)
+		}
+		settings.Reminders = on
+	case SettingReminderHour:
+		hour, err := strconv.Atoi(value)
+		if err != nil || hour < 0 || hour > 23 {
+			return nil, fmt.Errorf("invalid %s %q", key, value)
+		}
+		settings.ReminderHour = hour
+	case SettingLanguage:
+		if err := setOption(&settings.Language, Languages, key, value); err != nil {
+			return nil, err
+		}
+	case SettingPersona:
+		if err := setOption(&settings.Persona, Personas(), key, value); err != nil {
+			return nil, err
+		}
+	case SettingSummaryStyle:
+		if err := setOption(&settings.SummaryStyle, SummaryStyles, key, value); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown setting %q", key)
+	}
+
+	if err := storage.StoreClient.UpsertUserSettings(ctx, userId, &settings); err != nil {
+		return nil, err
+	}
+
+	return &settings, nil
+}
+
+func setOption(setting *string, options []Option, key string, value string) error {
+	if _, ok := OptionLabel(options, value); !ok {
+		return fmt.Errorf("invalid %s %q", key, value)
+	}
+	*setting = value
+	return nil
+}
+
+// OptionLabel returns the label of value among options, and whether it is one of them
+func OptionLabel(options []Option, value string) (string, bool) {
+	for _, option := range options {
+		if option.Value == value {
+			return option.Label, true
+		}
+	}
+	return "", false
+}
+
+// Language returns the language code to write to user in, the one picked in settings,
+// else their chat app's if there is a catalog for it, else empty to follow the user
+func Language(user *storage.User) string {
+	if language := SettingsOf(user).Language; language != "" {
+		return language
+	}
+	if user == nil {
+		return ""
+	}
+	return i18n.Match(user.LanguageCode)
+}
+
+// GetLanguage retrieves user and returns the language to write to them in
+func GetLanguage(ctx context.Context, userId string) string {
+	user, err := storage.StoreClient.GetUser(ctx, userId)
+	if err != nil && err != storage.ErrNotFound {
+		log.Printf("Error retrieving user %s: %v", userId, err)
+	}
+
+	return Language(user)
+}
+
+// GetUsersToRemind queries for users with reminders on whose local time at now is their reminder hour,
+// with lastCreatedSession < their current local day
+func GetUsersToRemind(now time.Time) []string {
+	due := func(user *storage.User, local time.Time) bool {
+		settings := SettingsOf(user)
+		return settings.Reminders && local.Hour() == settings.ReminderHour && user.LastCreatedSession.Before(StartOfDay(local))
+	}
+
+	// users who never changed settings are reminded at the default hour, where all users of the timezones are read
+	var users []string
+	for hour, timezones := range timezonesByHour(now) {
+		var reminderHour *int
+		if hour != DefaultReminderHour {
+			reminderHour = &hour
+		}
+		users = append(users, filterUsers(now, timezones, reminderHour, due)...)
+	}
+	sort.Strings(users)
+
+	log.Printf("Found %d users to remind\n", len(users))
+
+	return users
+}
@@ -3,73 +3,182 @@ package users
 import (
 	"context"
 	"fmt"
-	firebaseClient "journie/pkg/firebase"
+	"journie/pkg/secrets"
+	"journie/pkg/storage"
 	"log"
+	"os"
 	"time"
-
-	"google.golang.org/api/iterator"
 )
 
-// GetUsersWithoutSession queries for users with lastCreatedSession < current day
-func GetUsersWithoutSession(datestring string) []string {
-	ctx := context.Background()
-	client := firebaseClient.FirestoreClient
-
-	// Create a new time object with the desired date and zeroed hours and minutes
-	now := time.Now()
-	currentDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-
-	iter := client.Collection("users").Where(
-		"lastCreatedSession", "<", currentDay,
-	).Documents(ctx)
-
-	var usersWithoutSession []string
-	for {
-		doc, err := iter.Next()
-		if err == iterator.Done {
-			fmt.Println("Retrieved all matching users")
-			break
-		}
-		if err != nil {
-			fmt.Println("Error getting document:", err)
-			continue
+// fallbackTimezone preserves the original Singapore schedule for users who never set a timezone
+const fallbackTimezone = "Asia/Singapore"
+
+// DefaultTimezone used for users without a timezone, configurable with DEFAULT_TIMEZONE env
+func DefaultTimezone() string {
+	if tz := os.Getenv("DEFAULT_TIMEZONE"); tz != "" {
+		return tz
+	}
+	return fallbackTimezone
+}
+
+// Location returns the time.Location of user, falling back to DefaultTimezone
+func Location(user *storage.User) *time.Location {
+	if user != nil && user.Timezone != "" {
+		if loc, err := time.LoadLocation(user.Timezone); err == nil {
+			return loc
 		}
-		usersWithoutSession = append(usersWithoutSession, doc.Ref.ID)
+		log.Printf("Invalid timezone %q for user %s, using default", user.Timezone, user.Id)
+	}
+
+	loc, err := time.LoadLocation(DefaultTimezone())
+	if err != nil {
+		log.Printf("Invalid default timezone %q, using UTC", DefaultTimezone())
+		return time.UTC
+	}
+
+	return loc
+}
+
+// GetUserLocation retrieves user and returns their time.Location
+func GetUserLocation(ctx context.Context, userId string) *time.Location {
+	user, err := storage.StoreClient.GetUser(ctx, userId)
+	if err != nil && err != storage.ErrNotFound {
+		log.Printf("Error retrieving user %s: %v", userId, err)
+	}
+
+	return Location(user)
+}
+
+// SetTimezone validates an IANA timezone name and stores it for user
+func SetTimezone(ctx context.Context, userId string, timezone string) (*time.Location, error) {
+	if timezone == "" || timezone == "Local" {
+		return nil, fmt.Errorf("invalid timezone %q", timezone)
 	}
 
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := storage.StoreClient.UpsertUserTimezone(ctx, userId, loc.String()); err != nil {
+		return nil, err
+	}
+
+	return loc, nil
+}
+
+// StartOfDay returns midnight of the day t falls on, in t's location
+func StartOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// GetUsersWithoutSession queries for users whose local time at now is hour,
+// with lastCreatedSession < their current local day
+func GetUsersWithoutSession(now time.Time, hour int) []string {
+	usersWithoutSession := filterUsersAtHour(now, hour, func(user *storage.User, local time.Time) bool {
+		return user.LastCreatedSession.Before(StartOfDay(local))
+	})
+
 	userCount := len(usersWithoutSession)
 	log.Printf("Found %d users without session\n", userCount)
 
 	return usersWithoutSession
 }
 
-// GetUsersWithSession queries for users with lastCreatedSession >= datetime
-// todo timezone filter
-func GetUsersWithSession(datetime time.Time) []string {
-	ctx := context.Background()
-	client := firebaseClient.FirestoreClient
+// GetUsersWithSession queries for users whose local time at now is hour,
+// with lastCreatedSession >= start of their previous local day
+func GetUsersWithSession(now time.Time, hour int) []string {
+	users := filterUsersAtHour(now, hour, func(user *storage.User, local time.Time) bool {
+		return !user.LastCreatedSession.Before(StartOfDay(local.AddDate(0, 0, -1)))
+	})
 
-	iter := client.Collection("users").Where(
-		"lastCreatedSession", ">=", datetime,
-	).Documents(ctx)
+	userCount := len(users)
+	log.Printf("Found %d users with session\n", userCount)
 
-	var users []string
+	return users
+}
 
-	for {
-		doc, err := iter.Next()
-		if err == iterator.Done {
-			log.Println("Retrieved all matching users")
-			break
-		}
-		if err != nil {
-			log.Println("Error getting document:", err)
-			continue
-		}
-		users = append(users, doc.Ref.ID)
+func filterUsersAtHour(now time.Time, hour int, predicate func(user *storage.User, local time.Time) bool) []string {
+	return filterUsers(now, timezonesByHour(now)[hour], nil, func(user *storage.User, local time.Time) bool {
+		return local.Hour() == hour && predicate(user, local)
+	})
+}
+
+// timezonesByHour groups the timezones of users by their local hour at now, "" standing for
+// users who never set one
+func timezonesByHour(now time.Time) map[int][]string {
+	timezones, err := storage.StoreClient.ListTimezones(context.Background())
+	if err != nil {
+		log.Println("Error listing timezones:", err)
 	}
 
-	userCount := len(users)
-	log.Printf("Found %d users with session\n", userCount)
+	byHour := make(map[int][]string)
+	for _, timezone := range append([]string{""}, timezones...) {
+		hour := now.In(Location(&storage.User{Timezone: timezone})).Hour()
+		byHour[hour] = append(byHour[hour], timezone)
+	}
+
+	return byHour
+}
+
+// filterUsers returns ids of users in timezones, who remind at reminderHour if set, for whom predicate
+// holds given their local time at now
+func filterUsers(now time.Time, timezones []string, reminderHour *int, predicate func(user *storage.User, local time.Time) bool) []string {
+	if len(timezones) == 0 {
+		return nil
+	}
+
+	found, err := storage.StoreClient.QueryUsers(context.Background(), storage.UserQuery{Timezones: timezones, ReminderHour: reminderHour})
+	if err != nil {
+		log.Println("Error querying users:", err)
+	}
+
+	var users []string
+	for i := range found {
+		user := &found[i]
+		if predicate(user, now.In(Location(user))) {
+			users = append(users, user.Id)
+		}
+	}
 
 	return users
 }
+
+// SetGeminiKey seals apiKey with the master key and stores it for user
+func SetGeminiKey(ctx context.Context, userId string, apiKey string) error {
+	keyring, err := secrets.MasterKeyring()
+	if err != nil {
+		return err
+	}
+
+	sealed, err := keyring.Seal([]byte(apiKey))
+	if err != nil {
+		return err
+	}
+
+	return storage.StoreClient.UpsertUserGeminiKey(ctx, userId, sealed)
+}
+
+// RemoveGeminiKey deletes the stored Gemini key of user
+func RemoveGeminiKey(ctx context.Context, userId string) error {
+	return storage.StoreClient.UpsertUserGeminiKey(ctx, userId, "")
+}
+
+// GeminiKey opens the stored Gemini key of user, empty if user has none
+func GeminiKey(user *storage.User) (string, error) {
+	if user == nil || user.GeminiKey == "" {
+		return "", nil
+	}
+
+	keyring, err := secrets.MasterKeyring()
+	if err != nil {
+		return "", err
+	}
+
+	apiKey, err := keyring.Open(user.GeminiKey)
+	if err != nil {
+		return "", err
+	}
+
+	return string(apiKey), nil
+}
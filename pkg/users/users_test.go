@@ -0,0 +1,123 @@
+package users_test
+
+import (
+	"context"
+	"journie/pkg/storage"
+	"journie/pkg/users"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestGetUsersWithoutSessionByTimezone calls users.GetUsersWithoutSession at 14:00 UTC,
+// checking that only users whose local time is 10pm, the default timezone's for users
+// who never set one, and who have not journaled on their local day are returned.
+func TestGetUsersWithoutSessionByTimezone(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	storage.StoreClient = store
+
+	now := time.Date(2024, 5, 20, 14, 0, 0, 0, time.UTC) // 10pm in Singapore, 3pm in London
+
+	store.UpsertUserTimezone(ctx, "telegram-1", "Asia/Singapore")
+	store.UpsertUserLastCreatedSession(ctx, "telegram-1", now.AddDate(0, 0, -1))
+	store.UpsertUserTimezone(ctx, "telegram-2", "Asia/Singapore")
+	store.UpsertUserLastCreatedSession(ctx, "telegram-2", now.Add(-time.Hour))
+	store.UpsertUserTimezone(ctx, "telegram-3", "Europe/London")
+	store.UpsertUserLastCreatedSession(ctx, "telegram-3", now.AddDate(0, 0, -1))
+	store.UpsertUserLastCreatedSession(ctx, "telegram-4", now.AddDate(0, 0, -1))
+
+	got := users.GetUsersWithoutSession(now, 22)
+	if want := []string{"telegram-1", "telegram-4"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf(`GetUsersWithoutSession(%v, 22) = %v, want %v`, now, got, want)
+	}
+}
+
+// TestSetTimezoneInvalid calls users.SetTimezone with names that are not IANA
+// timezones, checking for an error.
+func TestSetTimezoneInvalid(t *testing.T) {
+	storage.StoreClient = storage.NewMemoryStore()
+
+	for _, tz := range []string{"", "Local", "Mars/Olympus_Mons"} {
+		if loc, err := users.SetTimezone(context.Background(), "telegram-1", tz); loc != nil || err == nil {
+			t.Fatalf(`SetTimezone(%q) = %v, %v, want nil, error`, tz, loc, err)
+		}
+	}
+}
+
+// TestUpdateSetting changes settings of a user, checking valid values are stored
+// on top of the defaults and invalid ones are refused.
+func TestUpdateSetting(t *testing.T) {
+	ctx := context.Background()
+	storage.StoreClient = storage.NewMemoryStore()
+
+	if got, want := users.GetSettings(ctx, "telegram-1"), users.DefaultSettings(); got != want {
+		t.Fatalf(`GetSettings() of new user = %+v, want defaults %+v`, got, want)
+	}
+
+	users.UpdateSetting(ctx, "telegram-1", users.SettingReminderHour, "7")
+	settings, err := users.UpdateSetting(ctx, "telegram-1", users.SettingPersona, "stoic")
+	if err != nil || settings.ReminderHour != 7 || settings.Persona != "stoic" || !settings.Reminders {
+		t.Fatalf(`UpdateSetting() = %+v, %v, want reminder at 7 with stoic persona`, settings, err)
+	}
+	if got := users.GetSettings(ctx, "telegram-1"); got != *settings {
+		t.Fatalf(`GetSettings() = %+v, want stored %+v`, got, settings)
+	}
+
+	invalid := [][2]string{
+		{users.SettingReminderHour, "24"},
+		{users.SettingReminders, "maybe"},
+		{users.SettingLanguage, "klingon"},
+		{users.SettingSummaryStyle, ""},
+		{"theme", "dark"},
+	}
+	for _, setting := range invalid {
+		if _, err := users.UpdateSetting(ctx, "telegram-1", setting[0], setting[1]); err == nil {
+			t.Errorf(`UpdateSetting(%q, %q) = nil, want error`, setting[0], setting[1])
+		}
+	}
+}
+
+// TestLanguage calls users.Language, checking the language setting wins over the
+// language of the chat app, which is only followed when there is a catalog for it.
+func TestLanguage(t *testing.T) {
+	cases := []struct {
+		user *storage.User
+		want string
+	}{
+		{nil, ""},
+		{&storage.User{LanguageCode: "es-MX"}, "es"},
+		{&storage.User{LanguageCode: "pt-BR"}, ""},
+		{&storage.User{LanguageCode: "es", Settings: &storage.Settings{Language: "ja"}}, "ja"},
+		{&storage.User{LanguageCode: "pt", Settings: &storage.Settings{Language: "fr"}}, ""},
+	}
+	for _, c := range cases {
+		if got := users.Language(c.user); got != c.want {
+			t.Errorf(`Language(%+v) = %q, want %q`, c.user, got, c.want)
+		}
+	}
+}
+
+// TestGetUsersToRemind calls users.GetUsersToRemind at 14:00 UTC, checking users are
+// reminded at their own reminder hour and not at all with reminders off.
+func TestGetUsersToRemind(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	storage.StoreClient = store
+
+	now := time.Date(2024, 5, 20, 14, 0, 0, 0, time.UTC) // 10pm in Singapore, 3pm in London
+
+	for _, userId := range []string{"telegram-1", "telegram-2", "telegram-3"} {
+		store.UpsertUserLastCreatedSession(ctx, userId, now.AddDate(0, 0, -1))
+	}
+	store.UpsertUserTimezone(ctx, "telegram-1", "Asia/Singapore")
+	store.UpsertUserTimezone(ctx, "telegram-2", "Asia/Singapore")
+	users.UpdateSetting(ctx, "telegram-2", users.SettingReminders, "false")
+	store.UpsertUserTimezone(ctx, "telegram-3", "Europe/London")
+	users.UpdateSetting(ctx, "telegram-3", users.SettingReminderHour, "15")
+
+	got := users.GetUsersToRemind(now)
+	if want := []string{"telegram-1", "telegram-3"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf(`GetUsersToRemind(%v) = %v, want %v`, now, got, want)
+	}
+}
@@ -0,0 +1,76 @@
+package vectors
+
+import (
+	"math"
+	"sort"
+)
+
+// Cosine returns the cosine similarity of a and b, 0 if either is empty, zero or their lengths differ
+func Cosine(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// Match is a result of Index.Search
+type Match struct {
+	Id    string
+	Score float64 // cosine similarity to the query
+}
+
+// Index is an in-memory vector index searched exhaustively,
+// fast enough for the few thousand entries a journal collects over years
+type Index struct {
+	ids     []string
+	vectors [][]float32
+}
+
+func NewIndex() *Index {
+	return &Index{}
+}
+
+// Add indexes vector under id, empty vectors are ignored
+func (i *Index) Add(id string, vector []float32) {
+	if len(vector) == 0 {
+		return
+	}
+
+	i.ids = append(i.ids, id)
+	i.vectors = append(i.vectors, vector)
+}
+
+// Len returns the number of indexed vectors
+func (i *Index) Len() int {
+	return len(i.ids)
+}
+
+// Search returns up to k vectors most similar to query, best first. Vectors of a different
+// length than query, e.g. from another embedding model, are skipped. k <= 0 returns all
+func (i *Index) Search(query []float32, k int) []Match {
+	var matches []Match
+	for j, vector := range i.vectors {
+		if len(vector) != len(query) {
+			continue
+		}
+		matches = append(matches, Match{Id: i.ids[j], Score: Cosine(query, vector)})
+	}
+
+	sort.SliceStable(matches, func(a, b int) bool { return matches[a].Score > matches[b].Score })
+	if k > 0 && len(matches) > k {
+		matches = matches[:k]
+	}
+
+	return matches
+}
@@ -0,0 +1,56 @@
+package vectors_test
+
+import (
+	"journie/pkg/vectors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// TestCosine calls vectors.Cosine with parallel, orthogonal and mismatched vectors, checking the similarity.
+func TestCosine(t *testing.T) {
+	tests := []struct {
+		a, b []float32
+		want float64
+	}{
+		{[]float32{1, 2}, []float32{2, 4}, 1},
+		{[]float32{1, 0}, []float32{0, 1}, 0},
+		{[]float32{1, 0}, []float32{-1, 0}, -1},
+		{[]float32{1, 0}, []float32{1, 0, 0}, 0},
+		{[]float32{0, 0}, []float32{1, 0}, 0},
+		{nil, nil, 0},
+	}
+
+	for _, test := range tests {
+		if got := vectors.Cosine(test.a, test.b); math.Abs(got-test.want) > 1e-9 {
+			t.Errorf(`Cosine(%v, %v) = %v, want %v`, test.a, test.b, got, test.want)
+		}
+	}
+}
+
+// TestSearch indexes vectors of two lengths and searches, checking matches are ranked
+// by similarity and vectors of another length are skipped.
+func TestSearch(t *testing.T) {
+	index := vectors.NewIndex()
+	index.Add("run", []float32{1, 0.1, 0})
+	index.Add("work", []float32{0, 1, 0})
+	index.Add("swim", []float32{0.8, 0, 0.6})
+	index.Add("other-model", []float32{1, 0})
+	index.Add("empty", nil)
+
+	if index.Len() != 4 {
+		t.Fatalf(`Len() = %d, want 4`, index.Len())
+	}
+
+	var ids []string
+	for _, match := range index.Search([]float32{1, 0, 0}, 2) {
+		ids = append(ids, match.Id)
+	}
+	if !reflect.DeepEqual(ids, []string{"run", "swim"}) {
+		t.Fatalf(`Search(k=2) = %v, want [run swim]`, ids)
+	}
+
+	if matches := index.Search([]float32{1, 0, 0}, 0); len(matches) != 3 || matches[2].Id != "work" {
+		t.Fatalf(`Search(k=0) = %v, want 3 matches ending with work`, matches)
+	}
+}